@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clear the persistent prompt and response caches",
+	Long: `Inspect and clear the prompt and response caches shared by every roleplay
+process under ~/.config/roleplay/cache.
+
+Prompt entries are broken down by cache layer with their token counts, time
+left before they expire and how often they were hit.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarise the cache by layer and character",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached prompts and responses",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheList,
+}
+
+var cacheInspectCmd = &cobra.Command{
+	Use:   "inspect <key>",
+	Short: "Show a cache entry's layers and content",
+	Long: `Show a cached prompt's layers or a cached response. The key may be
+abbreviated to any unique prefix, as shown by 'roleplay cache list'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCacheInspect,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove cache entries",
+	Long: `Remove cached prompts and responses, optionally only those of one character
+or only prompts holding one layer. Processes already running keep their
+in-memory copies until they expire.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheClear,
+}
+
+// cacheFilter selects entries by character and prompt layer
+type cacheFilter struct {
+	character string
+	layer     cache.CacheLayer
+}
+
+// cacheLayers lists the layers in prompt order
+var cacheLayers = []cache.CacheLayer{
+	cache.SystemAdminLayer,
+	cache.ScenarioContextLayer,
+	cache.CorePersonalityLayer,
+	cache.LearnedBehaviorLayer,
+	cache.EmotionalStateLayer,
+	cache.UserMemoryLayer,
+	cache.ConversationLayer,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheInspectCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	for _, c := range []*cobra.Command{cacheListCmd, cacheClearCmd} {
+		c.Flags().String("character", "", "Only entries for this character")
+		c.Flags().String("layer", "", "Only prompts holding this layer, e.g. core_personality")
+	}
+}
+
+// cacheStore opens the cache shared by every roleplay process
+func cacheStore() *cache.DiskStore {
+	return cache.NewDiskStore(filepath.Join(os.Getenv("HOME"), ".config", "roleplay", "cache"))
+}
+
+// getCacheFilter reads --character and --layer
+func getCacheFilter(cmd *cobra.Command) (cacheFilter, error) {
+	character, _ := cmd.Flags().GetString("character")
+	layer, _ := cmd.Flags().GetString("layer")
+
+	filter := cacheFilter{character: character}
+	if layer == "" {
+		return filter, nil
+	}
+	for _, l := range cacheLayers {
+		if string(l) == layer {
+			filter.layer = l
+			return filter, nil
+		}
+	}
+	names := make([]string, len(cacheLayers))
+	for i, l := range cacheLayers {
+		names[i] = string(l)
+	}
+	return filter, fmt.Errorf("unknown layer %q (expected one of %s)", layer, strings.Join(names, ", "))
+}
+
+func (f cacheFilter) prompt(entry *cache.CacheEntry) bool {
+	if f.character != "" && entry.CharacterID != f.character {
+		return false
+	}
+	if f.layer == "" {
+		return true
+	}
+	for _, bp := range entry.Breakpoints {
+		if bp.Layer == f.layer {
+			return true
+		}
+	}
+	return false
+}
+
+// response reports whether a response matches; a layer filter only ever
+// selects prompts
+func (f cacheFilter) response(resp *cache.CachedResponse) bool {
+	return f.layer == "" && (f.character == "" || resp.CharacterID == f.character)
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	store := cacheStore()
+	prompts, err := store.SharedPrompts()
+	if err != nil {
+		return err
+	}
+	responses, err := store.SharedResponses()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	type layerStats struct{ entries, tokens int }
+	layers := make(map[cache.CacheLayer]*layerStats)
+	type characterStats struct{ prompts, responses, tokens, hits int }
+	characters := make(map[string]*characterStats)
+	character := func(id string) *characterStats {
+		if id == "" {
+			id = "(unknown)"
+		}
+		if characters[id] == nil {
+			characters[id] = &characterStats{}
+		}
+		return characters[id]
+	}
+
+	var promptBytes int64
+	var promptHits, promptExpired int
+	for _, entry := range prompts {
+		promptBytes += entry.Size()
+		promptHits += entry.HitCount
+		if isExpired(entry.Expiry(), now) {
+			promptExpired++
+		}
+		for _, bp := range entry.Breakpoints {
+			if layers[bp.Layer] == nil {
+				layers[bp.Layer] = &layerStats{}
+			}
+			layers[bp.Layer].entries++
+			layers[bp.Layer].tokens += bp.TokenCount
+		}
+		c := character(entry.CharacterID)
+		c.prompts++
+		c.tokens += entry.TokenCount()
+		c.hits += entry.HitCount
+	}
+
+	var responseHits, responseExpired, tokensSaved int
+	for _, resp := range responses {
+		responseHits += resp.HitCount
+		tokensSaved += resp.HitCount * resp.TokensUsed.Total
+		if isExpired(resp.ExpiresAt, now) {
+			responseExpired++
+		}
+		c := character(resp.CharacterID)
+		c.responses++
+		c.hits += resp.HitCount
+	}
+
+	fmt.Printf("Cache: %s\n", store.Dir())
+	fmt.Println("\nPrompt Cache:")
+	fmt.Printf("  Entries: %d (%d expired)\n", len(prompts), promptExpired)
+	fmt.Printf("  Size: %.1f KB\n", float64(promptBytes)/1024)
+	fmt.Printf("  Hits: %d\n", promptHits)
+	fmt.Println("\nResponse Cache:")
+	fmt.Printf("  Entries: %d (%d expired)\n", len(responses), responseExpired)
+	fmt.Printf("  Hits: %d\n", responseHits)
+	fmt.Printf("  Tokens Saved: %d\n", tokensSaved)
+
+	if len(layers) > 0 {
+		fmt.Println("\nTokens by Layer:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LAYER\tENTRIES\tTOKENS")
+		for _, layer := range cacheLayers {
+			if s, ok := layers[layer]; ok {
+				fmt.Fprintf(w, "%s\t%d\t%d\n", layer, s.entries, s.tokens)
+			}
+		}
+		w.Flush()
+	}
+
+	if len(characters) > 0 {
+		ids := make([]string, 0, len(characters))
+		for id := range characters {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		fmt.Println("\nBy Character:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CHARACTER\tPROMPTS\tRESPONSES\tPROMPT TOKENS\tHITS")
+		for _, id := range ids {
+			c := characters[id]
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", id, c.prompts, c.responses, c.tokens, c.hits)
+		}
+		w.Flush()
+	}
+
+	return nil
+}
+
+func runCacheList(cmd *cobra.Command, args []string) error {
+	filter, err := getCacheFilter(cmd)
+	if err != nil {
+		return err
+	}
+	store := cacheStore()
+	prompts, err := store.SharedPrompts()
+	if err != nil {
+		return err
+	}
+	responses, err := store.SharedResponses()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tCHARACTER\tTOKENS\tHITS\tEXPIRES IN")
+
+	count := 0
+	for _, entry := range prompts {
+		if !filter.prompt(entry) {
+			continue
+		}
+		layers := make([]string, len(entry.Breakpoints))
+		for i, bp := range entry.Breakpoints {
+			layers[i] = fmt.Sprintf("%s=%d", bp.Layer, bp.TokenCount)
+		}
+		fmt.Fprintf(w, "%s\tprompt\t%s\t%s\t%d\t%s\n",
+			shortCacheKey(entry.Key),
+			entry.CharacterID,
+			strings.Join(layers, " "),
+			entry.HitCount,
+			formatTTL(entry.Expiry(), now),
+		)
+		count++
+	}
+	for _, resp := range responses {
+		if !filter.response(resp) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\tresponse\t%s\t%d\t%d\t%s\n",
+			shortCacheKey(resp.Key),
+			resp.CharacterID,
+			resp.TokensUsed.Total,
+			resp.HitCount,
+			formatTTL(resp.ExpiresAt, now),
+		)
+		count++
+	}
+
+	if count == 0 {
+		fmt.Println("No cache entries found")
+		return nil
+	}
+	w.Flush()
+	return nil
+}
+
+func runCacheInspect(cmd *cobra.Command, args []string) error {
+	store := cacheStore()
+	prompts, err := store.SharedPrompts()
+	if err != nil {
+		return err
+	}
+	responses, err := store.SharedResponses()
+	if err != nil {
+		return err
+	}
+
+	prefix := args[0]
+	var prompt *cache.CacheEntry
+	var response *cache.CachedResponse
+	var matches []string
+	for _, entry := range prompts {
+		if strings.HasPrefix(entry.Key, prefix) {
+			prompt = entry
+			matches = append(matches, entry.Key)
+		}
+	}
+	for _, resp := range responses {
+		if strings.HasPrefix(resp.Key, prefix) {
+			response = resp
+			matches = append(matches, resp.Key)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return fmt.Errorf("no cache entry matches %q", prefix)
+	case len(matches) > 1:
+		return fmt.Errorf("key %q is ambiguous: matches %s", prefix, strings.Join(matches, ", "))
+	}
+
+	now := time.Now()
+	if response != nil {
+		fmt.Printf("Key: %s\n", response.Key)
+		fmt.Println("Type: response")
+		fmt.Printf("Character: %s\n", response.CharacterID)
+		fmt.Printf("User: %s\n", response.UserID)
+		fmt.Printf("Cached: %s\n", response.CachedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Expires In: %s\n", formatTTL(response.ExpiresAt, now))
+		fmt.Printf("Hits: %d\n", response.HitCount)
+		fmt.Printf("Tokens: %d prompt, %d completion, %d total\n",
+			response.TokensUsed.Prompt, response.TokensUsed.Completion, response.TokensUsed.Total)
+		fmt.Printf("\n%s\n", response.Content)
+		return nil
+	}
+
+	fmt.Printf("Key: %s\n", prompt.Key)
+	fmt.Println("Type: prompt")
+	fmt.Printf("Character: %s\n", prompt.CharacterID)
+	if prompt.UserID != "" {
+		fmt.Printf("User: %s\n", prompt.UserID)
+	}
+	fmt.Printf("Created: %s\n", prompt.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Last Access: %s\n", prompt.LastAccess.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Expires In: %s\n", formatTTL(prompt.Expiry(), now))
+	fmt.Printf("Hits: %d\n", prompt.HitCount)
+	fmt.Printf("Tokens: %d\n", prompt.TokenCount())
+
+	for _, bp := range prompt.Breakpoints {
+		fmt.Printf("\n[%s] %d tokens, TTL %s, expires in %s\n",
+			bp.Layer, bp.TokenCount, bp.TTL, formatTTL(bp.LastUsed.Add(bp.TTL), now))
+		fmt.Println(bp.Content)
+	}
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	filter, err := getCacheFilter(cmd)
+	if err != nil {
+		return err
+	}
+	store := cacheStore()
+	prompts, err := store.SharedPrompts()
+	if err != nil {
+		return err
+	}
+	responses, err := store.SharedResponses()
+	if err != nil {
+		return err
+	}
+
+	var clearedPrompts, clearedResponses int
+	for _, entry := range prompts {
+		if !filter.prompt(entry) {
+			continue
+		}
+		if err := store.DeleteSharedPrompt(entry.Key); err != nil {
+			return err
+		}
+		clearedPrompts++
+	}
+	for _, resp := range responses {
+		if !filter.response(resp) {
+			continue
+		}
+		if err := store.DeleteSharedResponse(resp.Key); err != nil {
+			return err
+		}
+		clearedResponses++
+	}
+
+	fmt.Printf("✓ Cleared %d prompts and %d responses\n", clearedPrompts, clearedResponses)
+	return nil
+}
+
+// shortCacheKey abbreviates hashed keys for tables; inspect accepts the prefix
+func shortCacheKey(key string) string {
+	if len(key) > 12 && !strings.Contains(key, "::") {
+		return key[:12]
+	}
+	return key
+}
+
+func isExpired(expiry, now time.Time) bool {
+	return !expiry.IsZero() && now.After(expiry)
+}
+
+// formatTTL describes the time left before expiry
+func formatTTL(expiry, now time.Time) string {
+	if expiry.IsZero() {
+		return "never"
+	}
+	if isExpired(expiry, now) {
+		return "expired"
+	}
+	return expiry.Sub(now).Round(time.Second).String()
+}
@@ -62,6 +62,18 @@ var showCharacterCmd = &cobra.Command{
 	RunE:  runShowCharacter,
 }
 
+var characterStateCmd = &cobra.Command{
+	Use:   "state [character-id]",
+	Short: "Show a character's mood, memories and relationship with a user",
+	Long: `Show the state a character has built up with one user: current mood,
+relationship metrics, personality drift from the base definition, and memories.
+
+Each user has their own state, so conversations with one user never affect
+the character's mood or memories with another.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCharacterState,
+}
+
 var exampleCharacterCmd = &cobra.Command{
 	Use:   "example",
 	Short: "Generate an example character JSON file",
@@ -74,6 +86,13 @@ func init() {
 	characterCmd.AddCommand(showCharacterCmd)
 	characterCmd.AddCommand(exampleCharacterCmd)
 	characterCmd.AddCommand(listCharactersCmd)
+	characterCmd.AddCommand(characterStateCmd)
+
+	characterStateCmd.Flags().StringP("user", "u", "", "User ID whose state to show (required)")
+	characterStateCmd.Flags().StringP("format", "f", "text", "Output format: text or json")
+	if err := characterStateCmd.MarkFlagRequired("user"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error marking user flag as required: %v\n", err)
+	}
 }
 
 func runCreateCharacter(cmd *cobra.Command, args []string) error {
@@ -91,6 +110,11 @@ func runCreateCharacter(cmd *cobra.Command, args []string) error {
 	if err := json.Unmarshal(data, &char); err != nil {
 		return fmt.Errorf("failed to parse character JSON: %w", err)
 	}
+	if char.Generation != nil {
+		if err := char.Generation.Validate(); err != nil {
+			return fmt.Errorf("invalid generation settings: %w", err)
+		}
+	}
 
 	// Initialize manager without provider (don't need AI for creating characters)
 	mgr, err := manager.NewCharacterManagerWithoutProvider(config)
@@ -133,6 +157,93 @@ func runShowCharacter(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runCharacterState(cmd *cobra.Command, args []string) error {
+	characterID := args[0]
+	userID, _ := cmd.Flags().GetString("user")
+	outputFormat, _ := cmd.Flags().GetString("format")
+
+	mgr, err := manager.NewCharacterManagerWithoutProvider(GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to initialize manager: %w", err)
+	}
+
+	char, err := mgr.GetOrLoadCharacter(characterID)
+	if err != nil {
+		return err
+	}
+
+	state, err := mgr.GetBot().GetCharacterState(characterID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load character state: %w", err)
+	}
+
+	state.RLock()
+	defer state.RUnlock()
+
+	if outputFormat == "json" {
+		output, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format character state: %w", err)
+		}
+		cmd.Println(string(output))
+		return nil
+	}
+
+	cmd.Printf("🎭 %s (%s) with %s\n", char.Name, char.ID, userID)
+	cmd.Println(strings.Repeat("─", 50))
+
+	if state.InteractionCount == 0 {
+		cmd.Println("   No interactions yet; showing the base definition")
+	} else {
+		cmd.Printf("   Interactions: %d (last %s)\n", state.InteractionCount, state.LastModified.Format("2006-01-02 15:04"))
+	}
+
+	cmd.Println()
+	cmd.Println("   😊 Mood:")
+	cmd.Printf("      Joy: %.2f  Surprise: %.2f  Anger: %.2f\n", state.CurrentMood.Joy, state.CurrentMood.Surprise, state.CurrentMood.Anger)
+	cmd.Printf("      Fear: %.2f  Sadness: %.2f  Disgust: %.2f\n", state.CurrentMood.Fear, state.CurrentMood.Sadness, state.CurrentMood.Disgust)
+
+	cmd.Println()
+	cmd.Println("   🤝 Relationship:")
+	cmd.Printf("      Trust: %.2f  Affection: %.2f  Familiarity: %.2f\n",
+		state.Relationship.Trust, state.Relationship.Affection, state.Relationship.Familiarity)
+
+	char.RLock()
+	base := char.Personality
+	char.RUnlock()
+	effective := state.EffectivePersonality(base)
+	cmd.Println()
+	cmd.Println("   🧠 Personality (base → with this user):")
+	cmd.Printf("      Openness:          %.2f → %.2f\n", base.Openness, effective.Openness)
+	cmd.Printf("      Conscientiousness: %.2f → %.2f\n", base.Conscientiousness, effective.Conscientiousness)
+	cmd.Printf("      Extraversion:      %.2f → %.2f\n", base.Extraversion, effective.Extraversion)
+	cmd.Printf("      Agreeableness:     %.2f → %.2f\n", base.Agreeableness, effective.Agreeableness)
+	cmd.Printf("      Neuroticism:       %.2f → %.2f\n", base.Neuroticism, effective.Neuroticism)
+
+	counts := make(map[models.MemoryType]int)
+	for _, mem := range state.Memories {
+		counts[mem.Type]++
+	}
+	cmd.Println()
+	cmd.Printf("   💭 Memories: %d short-term, %d medium-term, %d long-term\n",
+		counts[models.ShortTermMemory], counts[models.MediumTermMemory], counts[models.LongTermMemory])
+
+	// Show the most recent few
+	recent := state.Memories
+	if len(recent) > 5 {
+		recent = recent[len(recent)-5:]
+	}
+	for _, mem := range recent {
+		content := mem.Content
+		if runes := []rune(content); len(runes) > 70 {
+			content = string(runes[:67]) + "..."
+		}
+		cmd.Printf("      • [%s] %s\n", mem.Type, content)
+	}
+
+	return nil
+}
+
 func runExampleCharacter(cmd *cobra.Command, args []string) error {
 	example := models.Character{
 		ID:   "warrior-123",
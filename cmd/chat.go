@@ -9,7 +9,8 @@ import (
 
 	"github.com/dotcommander/roleplay/internal/manager"
 	"github.com/dotcommander/roleplay/internal/models"
-	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/services"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +20,8 @@ var (
 	sessionID   string
 	format      string
 	scenarioID  string
+	streamReply bool
+	rateLimit   string
 )
 
 var chatCmd = &cobra.Command{
@@ -30,7 +33,9 @@ personality, emotional state, and conversation history.
 
 Examples:
   roleplay chat "Hello, how are you?" --character warrior-123 --user user-789
-  roleplay chat "Tell me about your adventures" -c warrior-123 -u user-789`,
+  roleplay chat "Tell me about your adventures" -c warrior-123 -u user-789
+  roleplay chat "Tell me a story" -c warrior-123 -u user-789 --stream
+  roleplay chat "Hello" -c warrior-123 -u user-789 --rate-limit wait`,
 	Args: cobra.ExactArgs(1),
 	RunE: runChat,
 }
@@ -43,6 +48,9 @@ func init() {
 	chatCmd.Flags().StringVarP(&sessionID, "session", "s", "", "Session ID (optional, generates new if not provided)")
 	chatCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text or json")
 	chatCmd.Flags().StringVar(&scenarioID, "scenario", "", "Scenario ID to set the interaction context (optional)")
+	chatCmd.Flags().BoolVar(&streamReply, "stream", false, "Stream the reply as it is generated (text format only)")
+	chatCmd.Flags().StringVar(&rateLimit, "rate-limit", "fail", "When a provider's rate limit is reached: wait for a slot or fail")
+	chatCmd.Flags().Bool("no-cache", false, "Always ask the provider and don't cache the reply")
 
 	if err := chatCmd.MarkFlagRequired("character"); err != nil {
 		fmt.Fprintf(os.Stderr, "Error marking character flag as required: %v\n", err)
@@ -56,6 +64,11 @@ func runChat(cmd *cobra.Command, args []string) error {
 	message := args[0]
 	config := GetConfig()
 
+	rateLimitMode, err := services.ParseRateLimitMode(rateLimit)
+	if err != nil {
+		return err
+	}
+
 	// Validate API key
 	// Initialize manager without provider first to check if character exists
 	mgr, err := manager.NewCharacterManagerWithoutProvider(config)
@@ -63,6 +76,9 @@ func runChat(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize manager: %w", err)
 	}
 
+	// Write evolved character state back to disk on exit
+	defer mgr.GetBot().Stop()
+
 	// Check if character exists before requiring API key
 	if _, err := mgr.GetOrLoadCharacter(characterID); err != nil {
 		return fmt.Errorf("character %s not found. Create it first with 'roleplay character create'", characterID)
@@ -78,117 +94,38 @@ func runChat(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize AI provider: %w", err)
 	}
 
-	// Get session repository
-	sessionRepo := mgr.GetSessionRepository()
-
-	// Generate session ID if not provided
-	if sessionID == "" {
-		sessionID = fmt.Sprintf("session-%d", time.Now().Unix())
-	}
-
-	// Load existing session or create new one
-	var session *repository.Session
-	existingSession, err := sessionRepo.LoadSession(characterID, sessionID)
-	if err != nil {
-		// Create new session if it doesn't exist
-		session = &repository.Session{
-			ID:           sessionID,
-			CharacterID:  characterID,
-			UserID:       userID,
-			StartTime:    time.Now(),
-			LastActivity: time.Now(),
-			Messages:     []repository.SessionMessage{},
-			CacheMetrics: repository.CacheMetrics{},
-		}
-	} else {
-		session = existingSession
-	}
-
-	// Convert session messages to conversation context
-	var recentMessages []models.Message
-	// Get last 10 messages for context
-	startIdx := 0
-	if len(session.Messages) > 10 {
-		startIdx = len(session.Messages) - 10
-	}
-	for i := startIdx; i < len(session.Messages); i++ {
-		msg := session.Messages[i]
-		// Map "character" role to "assistant" for API compatibility
-		role := msg.Role
-		if role == "character" {
-			role = "assistant"
-		}
-		recentMessages = append(recentMessages, models.Message{
-			Role:    role,
-			Content: msg.Content,
+	// Process request
+	mgr.GetBot().SetRateLimitMode(rateLimitMode)
+	ctx := context.Background()
+	if rateLimitMode == services.RateLimitWait {
+		announced := false
+		ctx = services.WithRateLimitNotifier(ctx, func(ev services.RateLimitWaitEvent) {
+			if !announced {
+				announced = true
+				fmt.Fprintf(os.Stderr, "Rate limited by %s; waiting %s for a slot...\n",
+					ev.Provider, time.Until(ev.Until).Round(time.Second))
+			}
 		})
 	}
-
-	// Create conversation request
-	req := &models.ConversationRequest{
+	req := manager.ChatRequest{
 		CharacterID: characterID,
 		UserID:      userID,
-		Message:     message,
+		SessionID:   sessionID,
 		ScenarioID:  scenarioID,
-		Context: models.ConversationContext{
-			SessionID:      sessionID,
-			StartTime:      session.StartTime,
-			RecentMessages: recentMessages,
-		},
-	}
-
-	// Process request
-	ctx := context.Background()
-	resp, err := mgr.GetBot().ProcessRequest(ctx, req)
-	if err != nil {
-		return fmt.Errorf("failed to process request: %w", err)
-	}
-
-	// Update session with new messages
-	session.Messages = append(session.Messages, repository.SessionMessage{
-		Timestamp:  time.Now(),
-		Role:       "user",
-		Content:    message,
-		TokensUsed: 0, // User messages don't consume tokens
-	})
-
-	cacheHits := 0
-	cacheMisses := 0
-	if resp.CacheMetrics.Hit {
-		cacheHits = 1
-	} else {
-		cacheMisses = 1
+		Message:     message,
+		CachePolicy: cachePolicy(cmd),
 	}
-
-	session.Messages = append(session.Messages, repository.SessionMessage{
-		Timestamp:   time.Now(),
-		Role:        "character",
-		Content:     resp.Content,
-		TokensUsed:  resp.TokensUsed.Total,
-		CacheHits:   cacheHits,
-		CacheMisses: cacheMisses,
-	})
-
-	// Update cache metrics
-	session.CacheMetrics.TotalRequests++
-	if resp.CacheMetrics.Hit {
-		session.CacheMetrics.CacheHits++
+	streamed := streamReply && format != "json"
+	var result *manager.ChatResult
+	if streamed {
+		result, err = streamChat(ctx, cmd, mgr, req)
 	} else {
-		session.CacheMetrics.CacheMisses++
+		result, err = mgr.Chat(ctx, req)
 	}
-	session.CacheMetrics.TokensSaved += resp.CacheMetrics.SavedTokens
-	session.CacheMetrics.HitRate = float64(session.CacheMetrics.CacheHits) / float64(session.CacheMetrics.TotalRequests)
-	session.CacheMetrics.CostSaved = float64(session.CacheMetrics.TokensSaved) * 0.000003 // Approximate cost per token
-	session.LastActivity = time.Now()
-
-	// Save session BEFORE checking for profile updates
-	// This ensures the async goroutine has access to the latest messages
-	if err := sessionRepo.SaveSession(session); err != nil {
-		// Log error but don't fail the command
-		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to save session: %v\n", err)
-		}
+	if err != nil {
+		return fmt.Errorf("failed to process request: %w", err)
 	}
+	resp, session := result.Response, result.Session
 
 	// Check if we should update the user profile
 	// For the chat command, we do this synchronously to ensure it completes
@@ -201,15 +138,16 @@ func runChat(cmd *cobra.Command, args []string) error {
 		char, err := mgr.GetOrLoadCharacter(characterID)
 		if err == nil {
 			// Call the bot's profile update method directly
-			mgr.GetBot().UpdateUserProfile(userID, char, sessionID)
+			mgr.GetBot().UpdateUserProfile(userID, char, session.ID)
 		}
 	}
 
 	// Display response based on format
 	if format == "json" {
 		output := map[string]interface{}{
-			"session_id": sessionID,
+			"session_id": session.ID,
 			"response":   resp.Content,
+			"provider":   resp.Provider,
 			"cache_metrics": map[string]interface{}{
 				"cache_hit":    resp.CacheMetrics.Hit,
 				"layers":       resp.CacheMetrics.Layers,
@@ -222,21 +160,28 @@ func runChat(cmd *cobra.Command, args []string) error {
 				"cached_prompt": resp.TokensUsed.CachedPrompt,
 				"total":         resp.TokensUsed.Total,
 			},
+			"model":      resp.Model,
+			"cost":       resp.Cost,
+			"cost_saved": resp.CostSaved,
 		}
 		jsonBytes, _ := json.MarshalIndent(output, "", "  ")
 		cmd.Println(string(jsonBytes))
 	} else {
-		// Display response
-		cmd.Printf("\n%s\n", resp.Content)
+		// Display response (already printed when streamed)
+		if !streamed {
+			cmd.Printf("\n%s\n", resp.Content)
+		}
 
 		// Show cache metrics if verbose
 		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
 			fmt.Fprintf(os.Stderr, "\n--- Performance Metrics ---\n")
-			fmt.Fprintf(os.Stderr, "Session ID: %s\n", sessionID)
+			fmt.Fprintf(os.Stderr, "Session ID: %s\n", session.ID)
+			fmt.Fprintf(os.Stderr, "Provider: %s\n", resp.Provider)
 			fmt.Fprintf(os.Stderr, "Cache Hit: %v\n", resp.CacheMetrics.Hit)
 			fmt.Fprintf(os.Stderr, "Tokens Used: %d (cached: %d)\n",
 				resp.TokensUsed.Total, resp.TokensUsed.CachedPrompt)
 			fmt.Fprintf(os.Stderr, "Tokens Saved: %d\n", resp.CacheMetrics.SavedTokens)
+			fmt.Fprintf(os.Stderr, "Cost: $%.6f (saved $%.6f)\n", resp.Cost, resp.CostSaved)
 			fmt.Fprintf(os.Stderr, "Latency: %v\n", resp.CacheMetrics.Latency)
 			fmt.Fprintf(os.Stderr, "Session Messages: %d\n", len(session.Messages))
 		}
@@ -244,3 +189,40 @@ func runChat(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// streamChat prints the reply chunk by chunk as it arrives
+func streamChat(ctx context.Context, cmd *cobra.Command, mgr *manager.CharacterManager, req manager.ChatRequest) (*manager.ChatResult, error) {
+	chunks := make(chan providers.PartialAIResponse)
+	printed := make(chan struct{})
+	req.Stream = chunks
+
+	go func() {
+		defer close(printed)
+		started := false
+		for chunk := range chunks {
+			if chunk.Content == "" {
+				continue
+			}
+			if !started {
+				cmd.Print("\n")
+				started = true
+			}
+			cmd.Print(chunk.Content)
+		}
+		if started {
+			cmd.Print("\n")
+		}
+	}()
+
+	result, err := mgr.Chat(ctx, req)
+	<-printed
+	return result, err
+}
+
+// cachePolicy returns the response cache policy chosen by a command's --no-cache flag
+func cachePolicy(cmd *cobra.Command) models.CachePolicy {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return models.CacheBypass
+	}
+	return models.CacheUse
+}
@@ -70,6 +70,33 @@ func TestChatCommand(t *testing.T) {
 			},
 			wantErr:    false,
 			wantOutput: "doing well",  // Look for partial match in mock response
+			checkResult: func(t *testing.T, tempDir string) {
+				// The reply is written to this user's character state on exit
+				data, err := os.ReadFile(filepath.Join(tempDir, ".config", "roleplay", "character_states", "test-char", "test-user.json"))
+				if err != nil {
+					t.Fatalf("Failed to read character state file: %v", err)
+				}
+				var state models.CharacterState
+				if err := json.Unmarshal(data, &state); err != nil {
+					t.Fatalf("Failed to parse character state file: %v", err)
+				}
+				if len(state.Memories) == 0 || !strings.Contains(state.Memories[len(state.Memories)-1].Content, "doing well") {
+					t.Errorf("Expected the reply to be persisted as a memory, got %+v", state.Memories)
+				}
+
+				// The base definition is left untouched
+				data, err = os.ReadFile(filepath.Join(tempDir, ".config", "roleplay", "characters", "test-char.json"))
+				if err != nil {
+					t.Fatalf("Failed to read character file: %v", err)
+				}
+				var char models.Character
+				if err := json.Unmarshal(data, &char); err != nil {
+					t.Fatalf("Failed to parse character file: %v", err)
+				}
+				if len(char.Memories) != 0 {
+					t.Errorf("Expected no memories on the base character, got %+v", char.Memories)
+				}
+			},
 		},
 		{
 			name: "chat with session",
@@ -207,6 +234,45 @@ func TestChatCommand(t *testing.T) {
 				// This would need to capture and parse stdout
 			},
 		},
+		{
+			name: "streamed chat",
+			args: []string{"chat", "Hello"},
+			flags: map[string]string{
+				"character": "test-char",
+				"user":      "test-user",
+				"stream":    "true",
+			},
+			setup: func(t *testing.T) string {
+				tempDir := t.TempDir()
+				
+				os.Setenv("ROLEPLAY_PROVIDER", "mock")
+				os.Setenv("ROLEPLAY_API_KEY", "mock-key")
+				
+				charDir := filepath.Join(tempDir, ".config", "roleplay", "characters")
+				if err := os.MkdirAll(charDir, 0755); err != nil {
+					t.Fatalf("Failed to create character directory: %v", err)
+				}
+				
+				char := models.Character{
+					ID:   "test-char",
+					Name: "Test Character",
+				}
+				
+				data, err := json.Marshal(&char)
+				if err != nil {
+					t.Fatalf("Failed to marshal character: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(charDir, "test-char.json"), data, 0644); err != nil {
+					t.Fatalf("Failed to write character file: %v", err)
+				}
+				
+				setupMockProvider("Streamed hello from the mock")
+				
+				return tempDir
+			},
+			wantErr:    false,
+			wantOutput: "Streamed hello from the mock",
+		},
 		{
 			name: "chat without character flag",
 			args: []string{"chat", "Hello"},
@@ -522,6 +588,8 @@ func resetCommands() {
 	sessionID = ""
 	format = ""
 	scenarioID = ""
+	streamReply = false
+	rateLimit = "fail"
 	
 	rootCmd = &cobra.Command{
 		Use:   "roleplay",
@@ -543,6 +611,8 @@ func resetCommands() {
 	chatCmd.Flags().StringVarP(&sessionID, "session", "s", "", "Session ID (optional, auto-generated if not provided)")
 	chatCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text or json")
 	chatCmd.Flags().StringVar(&scenarioID, "scenario", "", "Scenario ID to use (optional)")
+	chatCmd.Flags().BoolVar(&streamReply, "stream", false, "Stream the reply as it is generated (text format only)")
+	chatCmd.Flags().StringVar(&rateLimit, "rate-limit", "fail", "When a provider's rate limit is reached: wait for a slot or fail")
 	chatCmd.Flags().Bool("no-cache", false, "Disable response caching")
 	_ = chatCmd.MarkFlagRequired("character")
 	_ = chatCmd.MarkFlagRequired("user")
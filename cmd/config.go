@@ -88,6 +88,7 @@ func runConfigList(cmd *cobra.Command, args []string) error {
 		"cache.cleanup_interval",
 		"cache.adaptive_ttl",
 		"cache.max_entries",
+		"cache.max_bytes",
 	}
 	for _, key := range cacheSettings {
 		displaySettingWithSource(key)
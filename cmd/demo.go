@@ -30,6 +30,7 @@ func init() {
 	rootCmd.AddCommand(demoCmd)
 	demoCmd.Flags().String("character", "rick-c137", "Character ID to use for demo")
 	demoCmd.Flags().Bool("create-character", true, "Create demo character if it doesn't exist")
+	demoCmd.Flags().Bool("no-cache", false, "Always ask the provider and don't cache replies")
 }
 
 func runDemo(cmd *cobra.Command, args []string) error {
@@ -45,6 +46,9 @@ func runDemo(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Write evolved character state back to disk on exit
+	defer mgr.GetBot().Stop()
+
 	// Create or load demo character
 	if createChar {
 		if err := createDemoCharacter(mgr, characterID); err != nil {
@@ -102,6 +106,7 @@ func runDemo(cmd *cobra.Command, args []string) error {
 			CharacterID: characterID,
 			UserID:      "demo-user",
 			Message:     demo.message,
+			CachePolicy: cachePolicy(cmd),
 		}
 
 		resp, _, err := processDemoMessage(ctx, mgr.GetBot(), &req, char, styles)
@@ -119,7 +124,6 @@ func runDemo(cmd *cobra.Command, args []string) error {
 		session.CacheMetrics.HitRate = float64(session.CacheMetrics.CacheHits) /
 			float64(session.CacheMetrics.TotalRequests)
 	}
-	session.CacheMetrics.CostSaved = float64(session.CacheMetrics.TokensSaved) * 0.000003 // Approximate cost per token
 	session.LastActivity = time.Now()
 
 	// Save session
@@ -402,6 +406,7 @@ func updateSessionMetrics(
 		CachedTokens: resp.TokensUsed.CachedPrompt,
 		CacheHits:    cacheHits,
 		CacheMisses:  cacheMisses,
+		Usage:        manager.ReplyUsage(resp),
 	})
 
 	// Update cumulative metrics
@@ -412,6 +417,7 @@ func updateSessionMetrics(
 		session.CacheMetrics.CacheMisses++
 	}
 	session.CacheMetrics.TokensSaved += resp.CacheMetrics.SavedTokens
+	session.CacheMetrics.CostSaved += resp.CostSaved
 	session.CacheMetrics.CachedTokensTotal += resp.TokensUsed.CachedPrompt
 	
 	// Calculate prompt cache hit rate only if we have prompt tokens
@@ -444,7 +450,7 @@ func displayDemoSummary(session *repository.Session, styles *demoStyles) {
 	}
 	
 	fmt.Printf("Total Tokens Saved: %d\n", session.CacheMetrics.TokensSaved)
-	fmt.Printf("Estimated Cost Saved: $%.4f\n", session.CacheMetrics.CostSaved)
+	fmt.Printf("Cost Saved: $%.4f\n", session.CacheMetrics.CostSaved)
 	fmt.Printf("\nSession saved as: %s\n", session.ID)
 	fmt.Println("\nView detailed metrics with: roleplay session stats")
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/dotcommander/roleplay/internal/cache"
 	"github.com/dotcommander/roleplay/internal/manager"
 	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
 	"github.com/dotcommander/roleplay/internal/repository"
 	"github.com/dotcommander/roleplay/internal/services"
 	"github.com/dotcommander/roleplay/internal/utils"
@@ -50,6 +51,8 @@ func init() {
 	interactiveCmd.Flags().StringP("session", "s", "", "Session ID (optional)")
 	interactiveCmd.Flags().Bool("new-session", false, "Start a new session instead of resuming")
 	interactiveCmd.Flags().String("scenario", "", "Scenario ID to set the interaction context (optional)")
+	interactiveCmd.Flags().String("rate-limit", "fail", "When a provider's rate limit is reached: wait for a slot or fail")
+	interactiveCmd.Flags().Bool("no-cache", false, "Always ask the provider and don't cache replies")
 }
 
 // Styles - Gruvbox Dark Theme
@@ -162,13 +165,39 @@ type chatMsg struct {
 	role    string
 	content string
 	time    time.Time
-	msgType string // "normal", "help", "list", "stats", etc.
+	msgType string                   // "normal", "help", "list", "stats", etc.
+	usage   *repository.MessageUsage // Model and cost of a character reply
 }
 
 type responseMsg struct {
+	content      string
+	metrics      *cache.CacheMetrics
+	conversation models.ConversationContext // Context after the bot fitted it to the model
+	usage        *repository.MessageUsage
+	err          error
+}
+
+// replyStream carries a reply being streamed from the bot
+type replyStream struct {
+	chunks     chan providers.PartialAIResponse
+	retries    chan providers.RetryEvent
+	rateLimits chan services.RateLimitWaitEvent
+	result     chan responseMsg
+}
+
+type streamChunkMsg struct {
 	content string
-	metrics *cache.CacheMetrics
-	err     error
+	stream  *replyStream
+}
+
+type retryMsg struct {
+	event  providers.RetryEvent
+	stream *replyStream
+}
+
+type rateLimitMsg struct {
+	event  services.RateLimitWaitEvent
+	stream *replyStream
 }
 
 type characterInfoMsg struct {
@@ -196,10 +225,14 @@ type model struct {
 	userID      string
 	sessionID   string
 	scenarioID  string
+	cachePolicy models.CachePolicy
 	bot         *services.CharacterBot
 	character   *models.Character
 	context     models.ConversationContext
 	loading     bool
+	streaming   bool      // reply is arriving chunk by chunk
+	retryStatus string    // e.g. "retrying (2/4)…" while the provider backs off
+	queuedUntil time.Time // When a request queued behind a rate limit is sent
 	err         error
 	width       int
 	height      int
@@ -211,6 +244,7 @@ type model struct {
 	lastTokensSaved int
 	totalRequests   int
 	cacheHits       int
+	costSaved       float64
 
 	// Command history
 	commandHistory []string
@@ -346,6 +380,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle special system commands
 		if msg.msgType == "clear" && msg.content == "clear_history" {
 			m.messages = []chatMsg{}
+			m.context.Summary = ""
+			m.context.SummarizedCount = 0
+			m.updateContext()
 			m.viewport.SetContent(m.renderMessages())
 			// Add confirmation message
 			m.messages = append(m.messages, chatMsg{
@@ -400,17 +437,52 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.SetContent(m.renderMessages())
 		m.viewport.GotoBottom()
 
-	case responseMsg:
-		m.loading = false
-		if msg.err != nil {
-			m.err = msg.err
-		} else {
+	case retryMsg:
+		m.retryStatus = fmt.Sprintf("retrying (%d/%d)…", msg.event.Attempt, msg.event.MaxAttempts)
+		cmds = append(cmds, waitForStream(msg.stream))
+
+	case rateLimitMsg:
+		m.queuedUntil = msg.event.Until
+		cmds = append(cmds, waitForStream(msg.stream))
+
+	case streamChunkMsg:
+		m.retryStatus = ""
+		m.queuedUntil = time.Time{}
+		if !m.streaming {
+			m.streaming = true
 			m.messages = append(m.messages, chatMsg{
 				role:    m.character.Name,
-				content: msg.content,
 				time:    time.Now(),
 				msgType: "normal",
 			})
+		}
+		m.messages[len(m.messages)-1].content += msg.content
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		cmds = append(cmds, waitForStream(msg.stream))
+
+	case responseMsg:
+		m.loading = false
+		m.retryStatus = ""
+		m.queuedUntil = time.Time{}
+		streamed := m.streaming
+		m.streaming = false
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			// Streamed replies are already in the message list
+			if !streamed {
+				m.messages = append(m.messages, chatMsg{
+					role:    m.character.Name,
+					content: msg.content,
+					time:    time.Now(),
+					msgType: "normal",
+				})
+			}
+			m.messages[len(m.messages)-1].usage = msg.usage
+			if msg.usage != nil {
+				m.costSaved += msg.usage.CostSaved
+			}
 
 			// Update cache metrics
 			if msg.metrics != nil {
@@ -421,7 +493,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-			// Update context with recent messages
+			// Keep the rolling summary, then update context with recent messages
+			m.context.Summary = msg.conversation.Summary
+			m.context.SummarizedCount = msg.conversation.SummarizedCount
 			m.updateContext()
 
 			// Save session after each interaction
@@ -598,7 +672,20 @@ func (m model) renderInputArea() string {
 	}
 
 	if m.loading {
-		spinnerText := mutedStyle.Render("thinking...")
+		status := "thinking..."
+		if m.retryStatus != "" {
+			status = m.retryStatus
+		} else if !m.queuedUntil.IsZero() {
+			// Redrawn on every spinner tick, so the countdown keeps moving
+			wait := time.Until(m.queuedUntil).Round(time.Second)
+			if wait < 0 {
+				wait = 0
+			}
+			status = fmt.Sprintf("waiting for rate limit (%s)…", wait)
+		} else if m.streaming {
+			status = "typing..."
+		}
+		spinnerText := mutedStyle.Render(status)
 		return fmt.Sprintf("%s %s", m.spinner.View(), spinnerText)
 	}
 
@@ -631,18 +718,32 @@ func (m model) renderStatusBar() string {
 	return statusBarStyle.Width(m.width).Render(status)
 }
 
+// currentMood returns the character's mood toward this user, falling back to
+// the base definition when no state is available
+func (m model) currentMood() models.EmotionalState {
+	if m.bot != nil {
+		if state, err := m.bot.GetCharacterState(m.characterID, m.userID); err == nil {
+			state.RLock()
+			defer state.RUnlock()
+			return state.CurrentMood
+		}
+	}
+	return m.character.CurrentMood
+}
+
 func (m model) getDominantMood() string {
 	if m.character == nil {
 		return "Unknown"
 	}
 
+	mood := m.currentMood()
 	moods := map[string]float64{
-		"Joy":      m.character.CurrentMood.Joy,
-		"Surprise": m.character.CurrentMood.Surprise,
-		"Anger":    m.character.CurrentMood.Anger,
-		"Fear":     m.character.CurrentMood.Fear,
-		"Sadness":  m.character.CurrentMood.Sadness,
-		"Disgust":  m.character.CurrentMood.Disgust,
+		"Joy":      mood.Joy,
+		"Surprise": mood.Surprise,
+		"Anger":    mood.Anger,
+		"Fear":     mood.Fear,
+		"Sadness":  mood.Sadness,
+		"Disgust":  mood.Disgust,
 	}
 
 	maxMood := "Neutral"
@@ -683,26 +784,35 @@ func (m model) getMoodIcon(mood string) string {
 	}
 }
 
+// updateContext passes every turn not yet summarised to the bot, which fits
+// them to the model's context window
 func (m *model) updateContext() {
-	// Keep last 10 messages in context
-	startIdx := 0
-	if len(m.messages) > 10 {
-		startIdx = len(m.messages) - 10
+	turns := conversationTurns(m.messages)
+	if m.context.SummarizedCount > len(turns) {
+		m.context.SummarizedCount = len(turns)
 	}
+	m.context.RecentMessages = turns[m.context.SummarizedCount:]
+}
 
-	m.context.RecentMessages = make([]models.Message, 0)
-	for i := startIdx; i < len(m.messages); i++ {
+// conversationTurns returns the user and character messages of a chat,
+// skipping system notices such as help and errors
+func conversationTurns(messages []chatMsg) []models.Message {
+	turns := make([]models.Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.role == "system" || msg.msgType != "normal" {
+			continue
+		}
 		role := "user"
-		if m.messages[i].role != "user" {
+		if msg.role != "user" {
 			role = "assistant"
 		}
-
-		m.context.RecentMessages = append(m.context.RecentMessages, models.Message{
+		turns = append(turns, models.Message{
 			Role:      role,
-			Content:   m.messages[i].content,
-			Timestamp: m.messages[i].time,
+			Content:   msg.content,
+			Timestamp: msg.time,
 		})
 	}
+	return turns
 }
 
 func (m *model) saveSession() {
@@ -711,9 +821,13 @@ func (m *model) saveSession() {
 		dataDir := filepath.Join(os.Getenv("HOME"), ".config", "roleplay")
 		sessionRepo := repository.NewSessionRepository(dataDir)
 
-		// Convert chat messages back to session messages
+		// Convert chat messages back to session messages, leaving out system
+		// notices so the summarised count lines up with the saved turns
 		var sessionMessages []repository.SessionMessage
 		for _, msg := range m.messages {
+			if msg.role == "system" || msg.msgType != "normal" {
+				continue
+			}
 			sessionMessages = append(sessionMessages, repository.SessionMessage{
 				Timestamp: msg.time,
 				Role: func() string {
@@ -722,9 +836,12 @@ func (m *model) saveSession() {
 					}
 					return "character"
 				}(),
-				Content:    msg.content,
-				TokensUsed: 0, // Could track this per message if needed
+				Content: msg.content,
+				Usage:   msg.usage,
 			})
+			if msg.usage != nil {
+				sessionMessages[len(sessionMessages)-1].TokensUsed = msg.usage.PromptTokens + msg.usage.CompletionTokens
+			}
 		}
 
 		session := &repository.Session{
@@ -739,6 +856,7 @@ func (m *model) saveSession() {
 				CacheHits:     m.cacheHits,
 				CacheMisses:   m.totalRequests - m.cacheHits,
 				TokensSaved:   m.lastTokensSaved,
+				CostSaved:     m.costSaved,
 				HitRate: func() float64 {
 					if m.totalRequests > 0 {
 						return float64(m.cacheHits) / float64(m.totalRequests)
@@ -748,6 +866,9 @@ func (m *model) saveSession() {
 			},
 		}
 
+		session.Summary = m.context.Summary
+		session.SummarizedCount = m.context.SummarizedCount
+
 		if err := sessionRepo.SaveSession(session); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
 		}
@@ -755,19 +876,40 @@ func (m *model) saveSession() {
 }
 
 func (m model) sendMessage(message string) tea.Cmd {
-	return func() tea.Msg {
-		req := &models.ConversationRequest{
-			CharacterID: m.characterID,
-			UserID:      m.userID,
-			Message:     message,
-			ScenarioID:  m.scenarioID,
-			Context:     m.context,
-		}
+	req := &models.ConversationRequest{
+		CharacterID: m.characterID,
+		UserID:      m.userID,
+		Message:     message,
+		ScenarioID:  m.scenarioID,
+		CachePolicy: m.cachePolicy,
+		Context:     m.context,
+	}
+
+	stream := &replyStream{
+		chunks:     make(chan providers.PartialAIResponse),
+		retries:    make(chan providers.RetryEvent, 4),
+		rateLimits: make(chan services.RateLimitWaitEvent, 4),
+		result:     make(chan responseMsg, 1),
+	}
 
-		ctx := context.Background()
-		resp, err := m.bot.ProcessRequest(ctx, req)
+	go func() {
+		ctx := providers.WithRetryNotifier(context.Background(), func(ev providers.RetryEvent) {
+			// Never block the provider on a slow UI
+			select {
+			case stream.retries <- ev:
+			default:
+			}
+		})
+		ctx = services.WithRateLimitNotifier(ctx, func(ev services.RateLimitWaitEvent) {
+			select {
+			case stream.rateLimits <- ev:
+			default:
+			}
+		})
+		resp, err := m.bot.ProcessStreamRequest(ctx, req, stream.chunks)
 		if err != nil {
-			return responseMsg{err: err}
+			stream.result <- responseMsg{err: err}
+			return
 		}
 
 		// Get updated character state
@@ -776,9 +918,34 @@ func (m model) sendMessage(message string) tea.Cmd {
 			m.character = char
 		}
 
-		return responseMsg{
-			content: resp.Content,
-			metrics: &resp.CacheMetrics,
+		stream.result <- responseMsg{
+			content:      resp.Content,
+			metrics:      &resp.CacheMetrics,
+			conversation: req.Context,
+			usage:        manager.ReplyUsage(resp),
+		}
+	}()
+
+	return waitForStream(stream)
+}
+
+// waitForStream delivers the next chunk, or the final response once the stream closes
+func waitForStream(stream *replyStream) tea.Cmd {
+	return func() tea.Msg {
+		for {
+			select {
+			case ev := <-stream.retries:
+				return retryMsg{event: ev, stream: stream}
+			case ev := <-stream.rateLimits:
+				return rateLimitMsg{event: ev, stream: stream}
+			case chunk, ok := <-stream.chunks:
+				if !ok {
+					return <-stream.result
+				}
+				if chunk.Content != "" {
+					return streamChunkMsg{content: chunk.Content, stream: stream}
+				}
+			}
 		}
 	}
 }
@@ -978,6 +1145,7 @@ func (m model) handleSlashCommand(input string) tea.Cmd {
 			}
 			mood := m.getDominantMood()
 			icon := m.getMoodIcon(mood)
+			current := m.currentMood()
 			moodText := fmt.Sprintf(`%s Current Mood: %s
 
 Emotional State:
@@ -985,12 +1153,12 @@ Emotional State:
 • Anger: %.1f    • Fear: %.1f  
 • Sadness: %.1f  • Disgust: %.1f`,
 				icon, mood,
-				m.character.CurrentMood.Joy,
-				m.character.CurrentMood.Surprise,
-				m.character.CurrentMood.Anger,
-				m.character.CurrentMood.Fear,
-				m.character.CurrentMood.Sadness,
-				m.character.CurrentMood.Disgust)
+				current.Joy,
+				current.Surprise,
+				current.Anger,
+				current.Fear,
+				current.Sadness,
+				current.Disgust)
 			return systemMsg{content: moodText, msgType: "info"}
 
 		case "/personality":
@@ -1093,6 +1261,11 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 	sessionID, _ := cmd.Flags().GetString("session")
 	newSession, _ := cmd.Flags().GetBool("new-session")
 	scenarioID, _ := cmd.Flags().GetString("scenario")
+	rateLimit, _ := cmd.Flags().GetString("rate-limit")
+	rateLimitMode, err := services.ParseRateLimitMode(rateLimit)
+	if err != nil {
+		return err
+	}
 
 	// Apply smart defaults
 	if characterID == "" {
@@ -1141,6 +1314,7 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 					content: msg.Content,
 					time:    msg.Timestamp,
 					msgType: "normal",
+					usage:   msg.Usage,
 				})
 			}
 		} else {
@@ -1174,6 +1348,9 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize manager: %w", err)
 	}
 
+	// Write evolved character state back to disk on exit
+	defer mgr.GetBot().Stop()
+
 	// Load all available characters
 	if err := mgr.LoadAllCharacters(); err != nil {
 		fmt.Printf("Warning: Could not load all characters: %v\n", err)
@@ -1185,6 +1362,7 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 	}
 
 	bot := mgr.GetBot()
+	bot.SetRateLimitMode(rateLimitMode)
 
 	// Auto-create Rick Sanchez if requested and doesn't exist
 	if characterID == "rick-c137" {
@@ -1211,6 +1389,7 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 		userID:      userID,
 		sessionID:   sessionID,
 		scenarioID:  scenarioID,
+		cachePolicy: cachePolicy(cmd),
 		bot:         bot,
 		messages:    existingMessages,
 		spinner:     s,
@@ -1252,7 +1431,20 @@ func runInteractive(cmd *cobra.Command, args []string) error {
 			}
 			return 0
 		}(),
+		costSaved: func() float64 {
+			if existingSession != nil {
+				return existingSession.CacheMetrics.CostSaved
+			}
+			return 0
+		}(),
+	}
+
+	// Resume the rolling summary and send the resumed turns as history
+	if existingSession != nil {
+		m.context.Summary = existingSession.Summary
+		m.context.SummarizedCount = existingSession.SummarizedCount
 	}
+	m.updateContext()
 
 	// Start TUI
 	p := tea.NewProgram(m, tea.WithAltScreen())
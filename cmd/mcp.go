@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve characters to Model Context Protocol clients over stdio",
+	Long: `Run a Model Context Protocol (MCP) server on stdin and stdout, so MCP
+clients and agents can use your characters.
+
+Resources:
+  roleplay://characters/{id}   A character as JSON
+  roleplay://scenarios/{id}    A scenario as JSON
+
+Tools:
+  chat_with_character                 Talk to a character, with memory and mood
+  create_character_from_description   Generate and save a character, like quickgen
+  search_sessions                     Search past conversations
+
+Prompts:
+  One per character, named by its ID, holding the character's core system
+  prompt so other agents can borrow the persona.
+
+Register it with a client by running "roleplay mcp" as a stdio server, e.g.:
+  {"mcpServers": {"roleplay": {"command": "roleplay", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	config := GetConfig()
+
+	// Stdout carries the protocol, so anything else printed there would
+	// corrupt it; background output goes to stderr instead
+	protocol := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = protocol }()
+
+	// The provider is initialized on the first chat, so browsing characters
+	// works without an API key
+	mgr, err := manager.NewCharacterManagerWithoutProvider(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize manager: %w", err)
+	}
+	defer mgr.GetBot().Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return mcp.New(mgr, Version).Serve(ctx, os.Stdin, protocol)
+}
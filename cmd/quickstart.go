@@ -24,6 +24,7 @@ to start chatting immediately with Rick Sanchez`,
 
 func init() {
 	rootCmd.AddCommand(quickstartCmd)
+	quickstartCmd.Flags().Bool("no-cache", false, "Always ask the provider and don't cache the reply")
 }
 
 func runQuickstart(cmd *cobra.Command, args []string) error {
@@ -90,6 +91,9 @@ func runQuickstart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize: %w", err)
 	}
 
+	// Write evolved character state back to disk on exit
+	defer mgr.GetBot().Stop()
+
 	// Create or load Rick
 	characterID := "rick-c137"
 	if _, err := mgr.GetOrLoadCharacter(characterID); err != nil {
@@ -123,6 +127,7 @@ func runQuickstart(cmd *cobra.Command, args []string) error {
 		CharacterID: characterID,
 		UserID:      userID,
 		Message:     "Hello Rick!",
+		CachePolicy: cachePolicy(cmd),
 		Context: models.ConversationContext{
 			SessionID:      sessionID,
 			StartTime:      time.Now(),
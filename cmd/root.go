@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -151,16 +153,7 @@ func initConfig() {
 		apiKey = os.Getenv("ROLEPLAY_API_KEY")
 		if apiKey == "" {
 			// Check provider-specific environment variables
-			switch profileName {
-			case "openai":
-				apiKey = os.Getenv("OPENAI_API_KEY")
-			case "anthropic", "anthropic_compatible":
-				apiKey = os.Getenv("ANTHROPIC_API_KEY")
-			case "gemini", "gemini_compatible":
-				apiKey = os.Getenv("GEMINI_API_KEY")
-			case "groq":
-				apiKey = os.Getenv("GROQ_API_KEY")
-			}
+			apiKey = providerEnvAPIKey(profileName)
 		}
 	}
 
@@ -198,6 +191,7 @@ func initConfig() {
 		ModelAliases:    viper.GetStringMapString("model_aliases"),
 		CacheConfig: config.CacheConfig{
 			MaxEntries:                   viper.GetInt("cache.max_entries"),
+			MaxBytes:                     viper.GetInt64("cache.max_bytes"),
 			CleanupInterval:              viper.GetDuration("cache.cleanup_interval"),
 			DefaultTTL:                   viper.GetDuration("cache.default_ttl"),
 			EnableAdaptiveTTL:            viper.GetBool("cache.adaptive_ttl"),
@@ -207,6 +201,8 @@ func initConfig() {
 			ShortTermWindow:    viper.GetInt("memory.short_term_window"),
 			MediumTermDuration: viper.GetDuration("memory.medium_term_duration"),
 			ConsolidationRate:  viper.GetFloat64("memory.consolidation_rate"),
+			RetrievalTopK:      viper.GetInt("memory.retrieval_top_k"),
+			EmbeddingModel:     viper.GetString("memory.embedding_model"),
 		},
 		PersonalityConfig: config.PersonalityConfig{
 			EvolutionEnabled:   viper.GetBool("personality.evolution_enabled"),
@@ -222,10 +218,85 @@ func initConfig() {
 		},
 	}
 
+	// Failover chain; fallback keys come from the profile or its environment variable
+	if err := viper.UnmarshalKey("fallback_providers", &cfg.FallbackProviders); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invalid fallback_providers config: %v\n", err)
+	}
+	for i := range cfg.FallbackProviders {
+		if cfg.FallbackProviders[i].APIKey == "" {
+			cfg.FallbackProviders[i].APIKey = providerEnvAPIKey(cfg.FallbackProviders[i].Name)
+		}
+	}
+	cfg.CircuitBreaker = config.CircuitBreakerConfig{
+		FailureThreshold: viper.GetInt("circuit_breaker.failure_threshold"),
+		Cooldown:         viper.GetDuration("circuit_breaker.cooldown"),
+	}
+
+	if err := viper.UnmarshalKey("generation", &cfg.Generation); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invalid generation config: %v\n", err)
+	} else if err := cfg.Generation.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Ignoring generation defaults: %v\n", err)
+		cfg.Generation = models.GenerationParams{}
+	}
+	if err := viper.UnmarshalKey("pricing", &cfg.Pricing); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invalid pricing config: %v\n", err)
+	}
+	if err := viper.UnmarshalKey("rate_limits", &cfg.RateLimits); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invalid rate_limits config: %v\n", err)
+	}
+	if err := viper.UnmarshalKey("budgets", &cfg.Budgets); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invalid budgets config: %v\n", err)
+	}
+	cfg.Emotion = config.EmotionConfig{
+		Analyzer: viper.GetString("emotion.analyzer"),
+	}
+	cfg.Persistence = config.PersistenceConfig{
+		SaveDebounce: viper.GetDuration("persistence.save_debounce"),
+	}
+	cfg.Context = config.ContextConfig{
+		Window:        viper.GetInt("context.window"),
+		ReserveTokens: viper.GetInt("context.reserve_tokens"),
+	}
+	cfg.Tokenizer = config.TokenizerConfig{
+		Dir: viper.GetString("tokenizer.dir"),
+	}
+	cfg.Retry = config.RetryConfig{
+		MaxAttempts: viper.GetInt("retry.max_attempts"),
+		BaseDelay:   viper.GetDuration("retry.base_delay"),
+		MaxDelay:    viper.GetDuration("retry.max_delay"),
+	}
+
 	// Set defaults if not configured
+	if cfg.Emotion.Analyzer == "" {
+		cfg.Emotion.Analyzer = "lexicon"
+	}
+	if cfg.Budgets.WarnAt == 0 {
+		cfg.Budgets.WarnAt = 0.8
+	}
+	if cfg.Persistence.SaveDebounce == 0 {
+		cfg.Persistence.SaveDebounce = 2 * time.Second
+	}
+	if cfg.Tokenizer.Dir == "" {
+		cfg.Tokenizer.Dir = filepath.Join(os.Getenv("HOME"), ".config", "roleplay", "tokenizers")
+	} else if strings.HasPrefix(cfg.Tokenizer.Dir, "~") {
+		// Expand ~ to home directory
+		cfg.Tokenizer.Dir = filepath.Join(os.Getenv("HOME"), cfg.Tokenizer.Dir[1:])
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = 4
+	}
+	if cfg.CircuitBreaker.FailureThreshold == 0 {
+		cfg.CircuitBreaker.FailureThreshold = 3
+	}
+	if cfg.CircuitBreaker.Cooldown == 0 {
+		cfg.CircuitBreaker.Cooldown = 30 * time.Second
+	}
 	if cfg.CacheConfig.MaxEntries == 0 {
 		cfg.CacheConfig.MaxEntries = 10000
 	}
+	if cfg.CacheConfig.MaxBytes == 0 {
+		cfg.CacheConfig.MaxBytes = 64 << 20
+	}
 	if cfg.CacheConfig.CleanupInterval == 0 {
 		cfg.CacheConfig.CleanupInterval = 5 * time.Minute
 	}
@@ -238,6 +309,9 @@ func initConfig() {
 	if cfg.MemoryConfig.ConsolidationRate == 0 {
 		cfg.MemoryConfig.ConsolidationRate = 0.1
 	}
+	if !viper.IsSet("memory.retrieval_top_k") {
+		cfg.MemoryConfig.RetrievalTopK = 5
+	}
 	if cfg.PersonalityConfig.MaxDriftRate == 0 {
 		cfg.PersonalityConfig.MaxDriftRate = 0.02
 	}
@@ -265,6 +339,21 @@ func initConfig() {
 	}
 }
 
+// providerEnvAPIKey returns the API key from the provider-specific environment variable
+func providerEnvAPIKey(profileName string) string {
+	switch profileName {
+	case "openai":
+		return os.Getenv("OPENAI_API_KEY")
+	case "anthropic", "anthropic_compatible":
+		return os.Getenv("ANTHROPIC_API_KEY")
+	case "gemini", "gemini_compatible":
+		return os.Getenv("GEMINI_API_KEY")
+	case "groq":
+		return os.Getenv("GROQ_API_KEY")
+	}
+	return ""
+}
+
 func GetConfig() *config.Config {
 	return cfg
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -51,6 +52,11 @@ var scenarioCreateCmd = &cobra.Command{
 			prompt = string(data)
 		}
 
+		generation, err := applyGenerationFlags(cmd, nil)
+		if err != nil {
+			return err
+		}
+
 		scenario := &models.Scenario{
 			ID:          id,
 			Name:        name,
@@ -60,6 +66,7 @@ var scenarioCreateCmd = &cobra.Command{
 			Tags:        tags,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
+			Generation:  generation,
 		}
 
 		repo := repository.NewScenarioRepository(getConfigPath())
@@ -135,6 +142,10 @@ var scenarioShowCmd = &cobra.Command{
 		if !scenario.LastUsed.IsZero() {
 			fmt.Printf("Last Used: %s\n", scenario.LastUsed.Format("2006-01-02 15:04:05"))
 		}
+		if scenario.Generation != nil {
+			data, _ := json.Marshal(scenario.Generation)
+			fmt.Printf("Generation: %s\n", data)
+		}
 		fmt.Printf("\n--- Prompt ---\n%s\n", scenario.Prompt)
 
 		return nil
@@ -187,6 +198,10 @@ var scenarioUpdateCmd = &cobra.Command{
 			scenario.Tags = tags
 		}
 
+		if scenario.Generation, err = applyGenerationFlags(cmd, scenario.Generation); err != nil {
+			return err
+		}
+
 		if err := repo.SaveScenario(scenario); err != nil {
 			return fmt.Errorf("failed to save scenario: %w", err)
 		}
@@ -252,12 +267,14 @@ func init() {
 	scenarioCreateCmd.Flags().String("prompt-file", "", "Path to file containing the scenario prompt")
 	scenarioCreateCmd.Flags().String("prompt", "", "Inline scenario prompt")
 	scenarioCreateCmd.Flags().StringSlice("tags", []string{}, "Tags for categorizing the scenario")
+	addGenerationFlags(scenarioCreateCmd)
 
 	scenarioUpdateCmd.Flags().String("name", "", "Update the scenario name")
 	scenarioUpdateCmd.Flags().String("description", "", "Update the scenario description")
 	scenarioUpdateCmd.Flags().String("prompt-file", "", "Path to file containing the updated prompt")
 	scenarioUpdateCmd.Flags().String("prompt", "", "Inline updated prompt")
 	scenarioUpdateCmd.Flags().StringSlice("tags", []string{}, "Update the scenario tags")
+	addGenerationFlags(scenarioUpdateCmd)
 
 	// Add subcommands
 	scenarioCmd.AddCommand(scenarioCreateCmd)
@@ -276,3 +293,59 @@ func getConfigPath() string {
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".config", "roleplay")
 }
+
+// addGenerationFlags registers the sampler setting flags on a command
+func addGenerationFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64("temperature", 0, "Sampling temperature (0-2)")
+	cmd.Flags().Float64("top-p", 0, "Nucleus sampling probability mass (0-1)")
+	cmd.Flags().Int("max-tokens", 0, "Maximum tokens in a reply")
+	cmd.Flags().Float64("presence-penalty", 0, "Presence penalty (-2 to 2)")
+	cmd.Flags().Float64("frequency-penalty", 0, "Frequency penalty (-2 to 2)")
+	cmd.Flags().StringSlice("stop", nil, "Stop sequences")
+	cmd.Flags().Int("seed", 0, "Sampling seed for reproducible replies")
+}
+
+// applyGenerationFlags overlays the sampler flags that were set onto params.
+// It returns nil when neither params nor any flag carries a setting.
+func applyGenerationFlags(cmd *cobra.Command, params *models.GenerationParams) (*models.GenerationParams, error) {
+	var gen models.GenerationParams
+	if params != nil {
+		gen = *params
+	}
+
+	flags := cmd.Flags()
+	changed := false
+	floatFlag := func(name string, target **float64) {
+		if flags.Changed(name) {
+			v, _ := flags.GetFloat64(name)
+			*target = &v
+			changed = true
+		}
+	}
+	intFlag := func(name string, target **int) {
+		if flags.Changed(name) {
+			v, _ := flags.GetInt(name)
+			*target = &v
+			changed = true
+		}
+	}
+
+	floatFlag("temperature", &gen.Temperature)
+	floatFlag("top-p", &gen.TopP)
+	intFlag("max-tokens", &gen.MaxTokens)
+	floatFlag("presence-penalty", &gen.PresencePenalty)
+	floatFlag("frequency-penalty", &gen.FrequencyPenalty)
+	intFlag("seed", &gen.Seed)
+	if flags.Changed("stop") {
+		gen.Stop, _ = flags.GetStringSlice("stop")
+		changed = true
+	}
+
+	if !changed {
+		return params, nil
+	}
+	if err := gen.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid generation settings: %w", err)
+	}
+	return &gen, nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/server"
+	"github.com/dotcommander/roleplay/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve characters, scenarios, sessions and chat over an HTTP API",
+	Long: `Start an HTTP server exposing the character engine as a JSON API.
+
+Endpoints:
+  GET    /characters                           List characters
+  POST   /characters                           Create a character
+  GET    /characters/{id}                      Show a character
+  PUT    /characters/{id}                      Replace a character
+  DELETE /characters/{id}                      Delete a character
+  GET    /characters/{id}/sessions             List a character's sessions
+  GET    /characters/{id}/sessions/{session}   Show a session
+  GET    /scenarios                            List scenarios
+  POST   /scenarios                            Create a scenario
+  GET    /scenarios/{id}                       Show a scenario
+  PUT    /scenarios/{id}                       Replace a scenario
+  DELETE /scenarios/{id}                       Delete a scenario
+  GET    /users/{user}/profiles                List a user's profiles
+  GET    /users/{user}/profiles/{character}    Show a profile
+  DELETE /users/{user}/profiles/{character}    Delete a profile
+  POST   /chat                                 Send a message to a character
+  GET    /events                               Subscribe to events as server-sent events
+  GET    /events/ws                            Subscribe to events over a WebSocket
+  GET    /v1/models                            List characters as OpenAI models
+  GET    /v1/models/{model}                    Show a character as an OpenAI model
+  POST   /v1/chat/completions                  OpenAI-compatible chat, optionally streamed
+  GET    /health                               Health check
+
+Errors are returned as {"error": {"code": "...", "message": "..."}}.
+Event subscriptions take ?character_id= and optionally &session_id= and
+&user_id=; they carry reply tokens, replies, mood changes, user profile
+updates and memory consolidations. Set "stream": true on /chat to get the
+reply as token events. WebSocket clients subscribed to a session can send
+{"type": "chat", "message": "..."} to chat in it.
+
+The /v1 routes follow the OpenAI chat API: the model is a character ID and
+an optional "scenario" field names a scenario.
+The server shuts down gracefully on SIGINT or SIGTERM.
+
+Examples:
+  roleplay serve
+  roleplay serve --addr :9000
+  curl -X POST localhost:8080/chat -d '{"character_id":"rick-c137","user_id":"alice","message":"Hi"}'
+  curl -X POST localhost:8080/v1/chat/completions -d '{"model":"rick-c137","messages":[{"role":"user","content":"Hi"}]}'`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().String("rate-limit", "wait", "When a provider's rate limit is reached: wait for a slot or fail")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	config := GetConfig()
+	addr, _ := cmd.Flags().GetString("addr")
+	mode, _ := cmd.Flags().GetString("rate-limit")
+
+	rateLimitMode, err := services.ParseRateLimitMode(mode)
+	if err != nil {
+		return err
+	}
+
+	// The provider is initialized on the first chat, so managing characters
+	// works without an API key
+	mgr, err := manager.NewCharacterManagerWithoutProvider(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize manager: %w", err)
+	}
+	defer mgr.GetBot().Stop()
+	mgr.GetBot().SetRateLimitMode(rateLimitMode)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Serving on http://%s (Ctrl+C to stop)\n", addr)
+	if err := server.New(mgr).Run(ctx, addr); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Server stopped")
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"text/tabwriter"
 	"time"
 
@@ -26,8 +27,13 @@ var sessionListCmd = &cobra.Command{
 
 var sessionStatsCmd = &cobra.Command{
 	Use:   "stats",
-	Short: "Show caching statistics across all sessions",
-	RunE:  runSessionStats,
+	Short: "Show caching and spend statistics across all sessions",
+	Long: `Show response and prompt cache performance per character, followed by
+what the replies cost per character, per model and per day.
+
+Costs are recorded on each reply from the pricing table, which can be
+extended or overridden under pricing in config.yaml.`,
+	RunE: runSessionStats,
 }
 
 func init() {
@@ -109,6 +115,7 @@ func runSessionStats(cmd *cobra.Command, args []string) error {
 	var totalRequests, totalHits, totalTokensSaved, totalCachedTokens int
 	var totalCostSaved, totalPromptCacheRate float64
 	var sessionCount int
+	byCharacter, byModel, byDay := spendTotals{}, spendTotals{}, spendTotals{}
 
 	fmt.Println("Cache Performance Statistics")
 	fmt.Println("===========================")
@@ -120,7 +127,8 @@ func runSessionStats(cmd *cobra.Command, args []string) error {
 		}
 
 		char, _ := charRepo.LoadCharacter(charID)
-		fmt.Printf("\n%s (%s):\n", char.Name, charID)
+		label := fmt.Sprintf("%s (%s)", char.Name, charID)
+		fmt.Printf("\n%s:\n", label)
 
 		var charRequests, charHits, charTokensSaved, charCachedTokens int
 		var charCostSaved, charPromptCacheRate float64
@@ -137,7 +145,16 @@ func runSessionStats(cmd *cobra.Command, args []string) error {
 			charTokensSaved += session.CacheMetrics.TokensSaved
 			charCachedTokens += session.CacheMetrics.CachedTokensTotal
 			charCostSaved += session.CacheMetrics.CostSaved
-			
+
+			for _, msg := range session.Messages {
+				if msg.Usage == nil {
+					continue
+				}
+				byCharacter.add(label, msg.Usage)
+				byModel.add(msg.Usage.Provider+"/"+msg.Usage.Model, msg.Usage)
+				byDay.add(msg.Timestamp.Local().Format("2006-01-02"), msg.Usage)
+			}
+
 			if session.CacheMetrics.TotalRequests > 0 {
 				charPromptCacheRate += session.CacheMetrics.PromptCacheHitRate
 				charSessionCount++
@@ -161,7 +178,10 @@ func runSessionStats(cmd *cobra.Command, args []string) error {
 			}
 			
 			fmt.Printf("  Tokens Saved: %d\n", charTokensSaved)
-			fmt.Printf("  Cost Saved: $%.2f\n", charCostSaved)
+			fmt.Printf("  Cost Saved: $%.4f\n", charCostSaved)
+			if spent, ok := byCharacter[label]; ok {
+				fmt.Printf("  Spend: $%.4f\n", spent.cost)
+			}
 		}
 
 		totalRequests += charRequests
@@ -191,12 +211,66 @@ func runSessionStats(cmd *cobra.Command, args []string) error {
 		}
 		
 		fmt.Printf("  Total Tokens Saved: %d\n", totalTokensSaved)
-		fmt.Printf("  Total Cost Saved: $%.2f\n", totalCostSaved)
+		fmt.Printf("  Total Cost Saved: $%.4f\n", totalCostSaved)
+		fmt.Printf("  Total Spend: $%.4f\n", byCharacter.total())
+	}
+
+	if len(byCharacter) > 0 {
+		printSpend("Spend by Character", "CHARACTER", byCharacter)
+		printSpend("Spend by Model", "MODEL", byModel)
+		printSpend("Spend by Day", "DAY", byDay)
 	}
 
 	return nil
 }
 
+// spend sums the replies recorded under one key
+type spend struct {
+	replies int
+	tokens  int
+	cost    float64
+}
+
+// spendTotals groups reply costs by character, model or day
+type spendTotals map[string]*spend
+
+func (t spendTotals) add(key string, usage *repository.MessageUsage) {
+	s, ok := t[key]
+	if !ok {
+		s = &spend{}
+		t[key] = s
+	}
+	s.replies++
+	s.tokens += usage.PromptTokens + usage.CompletionTokens
+	s.cost += usage.Cost
+}
+
+func (t spendTotals) total() float64 {
+	var total float64
+	for _, s := range t {
+		total += s.cost
+	}
+	return total
+}
+
+// printSpend prints one spend table, sorted by key
+func printSpend(title, column string, totals spendTotals) {
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("\n%s:\n", title)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  %s\tREPLIES\tTOKENS\tCOST\n", column)
+	for _, key := range keys {
+		s := totals[key]
+		fmt.Fprintf(w, "  %s\t%d\t%d\t$%.4f\n", key, s.replies, s.tokens, s.cost)
+	}
+	w.Flush()
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return "just now"
@@ -50,7 +50,7 @@ var providerPresets = []providerPreset{
 		RequiresKey:  true,
 		LocalModel:   false,
 		DefaultModel: "claude-3-haiku-20240307",
-		Description:  "Anthropic Claude (OpenAI-Compatible)",
+		Description:  "Anthropic Claude (Messages API)",
 	},
 	{
 		Name:         "gemini",
@@ -0,0 +1,45 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "state.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := Write(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %q, got %q (%v)", content, data, err)
+		}
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected no temp files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteMissingDirectory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing", "state.json")
+	if err := Write(filename, []byte("data"), 0644); err == nil {
+		t.Error("Expected an error writing into a missing directory")
+	}
+}
@@ -1,21 +1,34 @@
 package cache
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 )
 
 // PromptCache manages cached prompts with TTL
 type PromptCache struct {
-	entries map[string]*CacheEntry
-	mu      sync.RWMutex
-	ttl     TTLManager
+	entries     map[string]*CacheEntry
+	mu          sync.RWMutex
+	ttl         TTLManager
+	countTokens func(string) int
+	store       *DiskStore // Shares entries between processes; nil keeps them in memory
+	logs        io.Writer  // Where store failures are reported
+	lru         *lru
+	hits        int
+	misses      int
+	evictions   int
 }
 
 // NewPromptCache creates a new cache with the given TTL configuration
 func NewPromptCache(baseTTL, minTTL, maxTTL time.Duration) *PromptCache {
 	return &PromptCache{
-		entries: make(map[string]*CacheEntry),
+		entries:     make(map[string]*CacheEntry),
+		countTokens: EstimateTokens,
+		lru:         newLRU(),
+		logs:        os.Stderr,
 		ttl: TTLManager{
 			BaseTTL:         baseTTL,
 			ActiveBonus:     0.5,
@@ -26,6 +39,127 @@ func NewPromptCache(baseTTL, minTTL, maxTTL time.Duration) *PromptCache {
 	}
 }
 
+// SetTokenCounter sets how breakpoint token counts are measured
+func (pc *PromptCache) SetTokenCounter(count func(string) int) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.countTokens = count
+}
+
+// SetLimits bounds the cache to maxEntries entries and maxBytes bytes of
+// breakpoint content, evicting the least recently used entries beyond them;
+// zero means unlimited
+func (pc *PromptCache) SetLimits(maxEntries int, maxBytes int64) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.lru.maxEntries = maxEntries
+	pc.lru.maxBytes = maxBytes
+	pc.evict()
+}
+
+// GetStats returns the size of the cache and its hits, misses and evictions
+func (pc *PromptCache) GetStats() Stats {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	return Stats{
+		Entries:   len(pc.entries),
+		Bytes:     pc.lru.bytes,
+		Hits:      pc.hits,
+		Misses:    pc.misses,
+		Evictions: pc.evictions,
+	}
+}
+
+// put records an entry as the most recently used and evicts beyond the
+// limits; the caller holds pc.mu
+func (pc *PromptCache) put(key string, entry *CacheEntry) {
+	pc.entries[key] = entry
+	pc.lru.touch(key, entry.Size())
+	pc.evict()
+}
+
+// remove drops an entry; the caller holds pc.mu
+func (pc *PromptCache) remove(key string) {
+	delete(pc.entries, key)
+	pc.lru.remove(key)
+}
+
+// evict drops least recently used entries until the cache is within its
+// limits. Shared copies stay in the store for other processes. The caller
+// holds pc.mu.
+func (pc *PromptCache) evict() {
+	for pc.lru.over() {
+		pc.remove(pc.lru.oldest())
+		pc.evictions++
+	}
+}
+
+// SetStore shares the cache with other processes through store. Entries
+// missing from memory are looked up there, and new entries are written back.
+func (pc *PromptCache) SetStore(store *DiskStore) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.store = store
+}
+
+// SetLogOutput sets where store failures are reported
+func (pc *PromptCache) SetLogOutput(w io.Writer) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.logs = w
+}
+
+// promptDocument names the shared copy of an entry
+func promptDocument(key string) string {
+	return documentName("prompts", key)
+}
+
+// share writes an entry to the store; the caller holds pc.mu
+func (pc *PromptCache) share(entry *CacheEntry) {
+	if pc.store == nil {
+		return
+	}
+	if err := pc.store.Save(promptDocument(entry.Key), entry); err != nil {
+		fmt.Fprintf(pc.logs, "Warning: Failed to share prompt cache entry: %v\n", err)
+	}
+}
+
+// load reads an entry another process cached; the caller holds pc.mu
+func (pc *PromptCache) load(key string) (*CacheEntry, bool) {
+	var entry CacheEntry
+	found, err := pc.store.Load(promptDocument(key), &entry)
+	if err != nil {
+		fmt.Fprintf(pc.logs, "Warning: Failed to read shared prompt cache: %v\n", err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	if expired(&entry, time.Now()) {
+		_ = pc.store.Delete(promptDocument(key))
+		return nil, false
+	}
+	pc.put(key, &entry)
+	return &entry, true
+}
+
+// recordHit counts a hit in the shared copy, so hit counts add up across
+// processes; the caller holds pc.mu
+func (pc *PromptCache) recordHit(key string) {
+	var shared CacheEntry
+	err := pc.store.Update(promptDocument(key), &shared, func() error {
+		if shared.Key == "" {
+			return errNotShared
+		}
+		shared.HitCount++
+		shared.LastAccess = time.Now()
+		return nil
+	})
+	if err != nil && err != errNotShared {
+		fmt.Fprintf(pc.logs, "Warning: Failed to update shared prompt cache: %v\n", err)
+	}
+}
+
 // Store adds a new cache entry for a specific layer
 func (pc *PromptCache) Store(key string, layer CacheLayer, content string, ttl time.Duration) {
 	pc.mu.Lock()
@@ -34,52 +168,89 @@ func (pc *PromptCache) Store(key string, layer CacheLayer, content string, ttl t
 	entry, exists := pc.entries[key]
 	if !exists {
 		entry = &CacheEntry{
+			Key:         key,
 			CreatedAt:   time.Now(),
 			Breakpoints: make([]CacheBreakpoint, 0),
 		}
-		pc.entries[key] = entry
 	}
 
 	breakpoint := CacheBreakpoint{
 		Layer:      layer,
 		Content:    content,
-		TokenCount: EstimateTokens(content),
+		TokenCount: pc.countTokens(content),
 		TTL:        ttl,
 		LastUsed:   time.Now(),
 	}
 
 	entry.Breakpoints = append(entry.Breakpoints, breakpoint)
 	entry.LastAccess = time.Now()
+	pc.put(key, entry)
+	pc.share(entry)
 }
 
 // StoreWithTTL stores a complete cache entry with breakpoints
 func (pc *PromptCache) StoreWithTTL(key string, breakpoints []CacheBreakpoint, ttl time.Duration) {
+	pc.StoreEntry(&CacheEntry{Key: key, Breakpoints: breakpoints}, ttl)
+}
+
+// StoreEntry stores an entry under entry.Key, replacing any previous one.
+// A positive ttl sets when it expires; otherwise its breakpoints' TTLs apply.
+// Breakpoints without a token count are measured.
+func (pc *PromptCache) StoreEntry(entry *CacheEntry, ttl time.Duration) {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
 
-	entry := &CacheEntry{
-		Breakpoints: breakpoints,
-		CreatedAt:   time.Now(),
-		LastAccess:  time.Now(),
-		HitCount:    0,
+	for i := range entry.Breakpoints {
+		if entry.Breakpoints[i].TokenCount == 0 {
+			entry.Breakpoints[i].TokenCount = pc.countTokens(entry.Breakpoints[i].Content)
+		}
+	}
+	entry.CreatedAt = time.Now()
+	entry.LastAccess = entry.CreatedAt
+	entry.HitCount = 0
+	if ttl > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(ttl)
 	}
 
-	pc.entries[key] = entry
+	pc.put(entry.Key, entry)
+	pc.share(entry)
+}
+
+// Delete removes an entry, including its shared copy
+func (pc *PromptCache) Delete(key string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.remove(key)
+	if pc.store != nil {
+		if err := pc.store.Delete(promptDocument(key)); err != nil {
+			fmt.Fprintf(pc.logs, "Warning: Failed to delete shared prompt cache entry: %v\n", err)
+		}
+	}
 }
 
 // Get retrieves a cache entry if it exists and is not expired
 func (pc *PromptCache) Get(key string) (*CacheEntry, bool) {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
 
 	entry, exists := pc.entries[key]
+	if !exists && pc.store != nil {
+		entry, exists = pc.load(key)
+	}
 	if !exists {
+		pc.misses++
 		return nil, false
 	}
 
 	// Update access time and hit count
 	entry.LastAccess = time.Now()
 	entry.HitCount++
+	pc.hits++
+	pc.lru.touch(key, entry.Size())
+	if pc.store != nil {
+		pc.recordHit(key)
+	}
 
 	return entry, true
 }
@@ -109,39 +280,72 @@ func (pc *PromptCache) CalculateAdaptiveTTL(cached *CacheEntry, hasComplexCharac
 	return baseTTL
 }
 
-// CleanupWorker runs periodic cleanup of expired entries
-func (pc *PromptCache) CleanupWorker(interval time.Duration) {
+// CleanupWorker runs periodic cleanup of expired entries until stop is closed
+func (pc *PromptCache) CleanupWorker(interval time.Duration, stop <-chan struct{}) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		pc.cleanup()
+	for {
+		select {
+		case <-ticker.C:
+			pc.cleanup()
+		case <-stop:
+			return
+		}
 	}
 }
 
-// cleanup removes expired cache entries
+// cleanup removes expired cache entries, in memory and in the store
 func (pc *PromptCache) cleanup() {
 	pc.mu.Lock()
-	defer pc.mu.Unlock()
-
 	now := time.Now()
 	for key, entry := range pc.entries {
-		// Check if any breakpoint has expired
-		expired := false
-		for _, bp := range entry.Breakpoints {
-			if now.Sub(bp.LastUsed) > bp.TTL {
-				expired = true
-				break
+		if expired(entry, now) {
+			pc.remove(key)
+			if pc.store != nil {
+				_ = pc.store.Delete(promptDocument(key))
 			}
 		}
+	}
+	pc.mu.Unlock()
 
-		if expired {
-			delete(pc.entries, key)
+	pc.SweepStore()
+}
+
+// SweepStore removes expired entries from the store, including those this
+// process never loaded, so the shared cache does not grow without bound
+func (pc *PromptCache) SweepStore() {
+	pc.mu.RLock()
+	store, logs := pc.store, pc.logs
+	pc.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	entries, err := store.sharedPrompts(logs)
+	if err != nil {
+		fmt.Fprintf(logs, "Warning: Failed to sweep shared prompt cache: %v\n", err)
+		return
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		if expired(entry, now) {
+			if err := store.DeleteSharedPrompt(entry.Key); err != nil {
+				fmt.Fprintf(logs, "Warning: Failed to sweep shared prompt cache: %v\n", err)
+			}
 		}
 	}
 }
 
-// EstimateTokens provides a rough estimation of token count
+// expired reports whether an entry has outlived its TTL or, without one,
+// whether any of its breakpoints has
+func expired(entry *CacheEntry, now time.Time) bool {
+	expiry := entry.Expiry()
+	return !expiry.IsZero() && now.After(expiry)
+}
+
+// EstimateTokens provides a rough estimation of token count, used when no
+// tokenizer is available
 func EstimateTokens(text string) int {
 	// Rough estimation: ~4 chars per token
 	return len(text) / 4
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dotcommander/roleplay/internal/atomicfile"
+	"github.com/dotcommander/roleplay/internal/filelock"
+)
+
+// errNotShared aborts an update of a document another process removed
+var errNotShared = errors.New("document not shared")
+
+// DiskStore keeps cache state as JSON documents under a directory shared by
+// every roleplay process, so separate CLI invocations see the same cache
+// entries and rate limits. Updates hold a lock file across processes.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates a store rooted at dir, e.g. ~/.config/roleplay/cache
+func NewDiskStore(dir string) *DiskStore {
+	return &DiskStore{dir: dir}
+}
+
+// Dir returns the directory the store writes to
+func (s *DiskStore) Dir() string {
+	return s.dir
+}
+
+// filename returns the path of a document; names may contain a "/" to group
+// documents in a subdirectory, e.g. "responses/<key>"
+func (s *DiskStore) filename(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name)+".json")
+}
+
+// Lock holds the store's lock across processes until the returned function
+// is called
+func (s *DiskStore) Lock() (func(), error) {
+	l, err := filelock.Acquire(filepath.Join(s.dir, ".lock"))
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = l.Unlock() }, nil
+}
+
+// Load reads a document into v, reporting whether it exists. Writes replace
+// documents atomically, so loading needs no lock.
+func (s *DiskStore) Load(name string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(s.filename(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Save writes v as a document
+func (s *DiskStore) Save(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	filename := s.filename(name)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return atomicfile.Write(filename, data, 0644)
+}
+
+// Update loads a document into v, applies update and saves the result while
+// holding the store's lock, so concurrent processes never lose each other's
+// changes. v is left untouched when the document does not exist yet. An
+// error from update aborts without saving.
+func (s *DiskStore) Update(name string, v interface{}, update func() error) error {
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.Load(name, v); err != nil {
+		return err
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	return s.Save(name, v)
+}
+
+// Delete removes a document; a missing document is not an error
+func (s *DiskStore) Delete(name string) error {
+	if err := os.Remove(s.filename(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+	return nil
+}
+
+// List returns the names of the documents in a group, e.g. "responses"
+func (s *DiskStore) List(group string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, filepath.FromSlash(group)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", group, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		names = append(names, group+"/"+strings.TrimSuffix(name, ".json"))
+	}
+	return names, nil
+}
+
+// documentName maps an arbitrary cache key to a document name in a group
+func documentName(group, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return group + "/" + hex.EncodeToString(sum[:])
+}
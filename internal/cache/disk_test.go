@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheSharedThroughDiskStore(t *testing.T) {
+	store := NewDiskStore(t.TempDir())
+
+	// Two caches stand in for two CLI invocations
+	first := NewResponseCache(time.Hour)
+	first.SetStore(store)
+	second := NewResponseCache(time.Hour)
+	second.SetStore(store)
+
+	key := first.GenerateKey(ResponseKey{CharacterID: "narrator", UserID: "alice", Message: "Hello"})
+	first.Store(key, "Greetings, traveller.", TokenUsage{Prompt: 100, Completion: 20, Total: 120})
+
+	resp, found := second.Get(key)
+	if !found {
+		t.Fatal("Expected the response cached by another process")
+	}
+	if resp.Content != "Greetings, traveller." || resp.TokensUsed.Total != 120 {
+		t.Errorf("Unexpected shared response: %+v", resp)
+	}
+
+	first.Get(key)
+	var shared CachedResponse
+	if _, err := store.Load(responseDocument(key), &shared); err != nil {
+		t.Fatalf("Failed to load shared response: %v", err)
+	}
+	if shared.HitCount != 2 {
+		t.Errorf("Expected hits from both processes to add up to 2, got %d", shared.HitCount)
+	}
+
+	// Expired responses are not served and leave the store
+	expired := NewResponseCache(-time.Minute)
+	expired.SetStore(store)
+	expired.Store("stale", "Old news", TokenUsage{})
+	fresh := NewResponseCache(time.Hour)
+	fresh.SetStore(store)
+	if _, found := fresh.Get("stale"); found {
+		t.Error("Expected an expired response to be a miss")
+	}
+	if names, _ := store.List("responses"); len(names) != 1 {
+		t.Errorf("Expected only the live response to remain, got %v", names)
+	}
+}
+
+func TestPromptCacheSharedThroughDiskStore(t *testing.T) {
+	store := NewDiskStore(t.TempDir())
+	first := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	first.SetStore(store)
+	second := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	second.SetStore(store)
+
+	breakpoints := []CacheBreakpoint{
+		{Layer: CorePersonalityLayer, Content: "You are the narrator.", TTL: time.Hour, LastUsed: time.Now()},
+		{Layer: ConversationLayer, Content: "Hello", TTL: 0, LastUsed: time.Now()},
+	}
+	first.StoreWithTTL("char::narrator::alice", breakpoints, 5*time.Minute)
+
+	entry, found := second.Get("char::narrator::alice")
+	if !found {
+		t.Fatal("Expected the prompt cached by another process")
+	}
+	if entry.Key != "char::narrator::alice" || len(entry.Breakpoints) != 2 {
+		t.Errorf("Unexpected shared entry: %+v", entry)
+	}
+}
+
+func TestDiskStoreUpdateIsAtomic(t *testing.T) {
+	store := NewDiskStore(t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var counter struct{ N int }
+			if err := store.Update("counter", &counter, func() error {
+				counter.N++
+				return nil
+			}); err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var counter struct{ N int }
+	if _, err := store.Load("counter", &counter); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if counter.N != 20 {
+		t.Errorf("Expected 20 increments, got %d", counter.N)
+	}
+}
+
+func TestDiskStoreSharedEntries(t *testing.T) {
+	store := NewDiskStore(t.TempDir())
+	prompts := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	prompts.SetStore(store)
+	responses := NewResponseCache(time.Hour)
+	responses.SetStore(store)
+
+	prompts.StoreEntry(&CacheEntry{
+		Key:         "char_system_prompt::narrator",
+		CharacterID: "narrator",
+		Breakpoints: []CacheBreakpoint{{Layer: CorePersonalityLayer, Content: "You are the narrator.", TTL: time.Hour, LastUsed: time.Now()}},
+	}, 0)
+	prompts.Get("char_system_prompt::narrator")
+	responses.StoreResponse("abc123", &CachedResponse{CharacterID: "narrator", Content: "Greetings."})
+
+	entries, err := store.SharedPrompts()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one shared prompt, got %v (%v)", entries, err)
+	}
+	entry := entries[0]
+	if entry.CharacterID != "narrator" || entry.HitCount != 1 {
+		t.Errorf("Expected the narrator's prompt with one hit, got %+v", entry)
+	}
+	if entry.TokenCount() == 0 {
+		t.Error("Expected the stored prompt to be measured")
+	}
+	if remaining := time.Until(entry.Expiry()); remaining <= 59*time.Minute || remaining > time.Hour {
+		t.Errorf("Expected the prompt to expire in about an hour, got %v", remaining)
+	}
+
+	shared, err := store.SharedResponses()
+	if err != nil || len(shared) != 1 || shared[0].Key != "abc123" || shared[0].CharacterID != "narrator" {
+		t.Fatalf("Expected the narrator's response under its key, got %v (%v)", shared, err)
+	}
+
+	if err := store.DeleteSharedPrompt(entry.Key); err != nil {
+		t.Fatalf("Failed to delete shared prompt: %v", err)
+	}
+	if err := store.DeleteSharedResponse("abc123"); err != nil {
+		t.Fatalf("Failed to delete shared response: %v", err)
+	}
+	entries, _ = store.SharedPrompts()
+	shared, _ = store.SharedResponses()
+	if len(entries) != 0 || len(shared) != 0 {
+		t.Errorf("Expected the store to be empty, got %d prompts and %d responses", len(entries), len(shared))
+	}
+}
+
+func TestSweepStoreRemovesEntriesOtherProcessesLeft(t *testing.T) {
+	store := NewDiskStore(t.TempDir())
+
+	// An earlier process left expired and live entries behind
+	stale := NewResponseCache(-time.Minute)
+	stale.SetStore(store)
+	stale.Store("stale", "Old news", TokenUsage{})
+	live := NewResponseCache(time.Hour)
+	live.SetStore(store)
+	live.Store("live", "Fresh news", TokenUsage{})
+
+	prompts := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	prompts.SetStore(store)
+	prompts.StoreEntry(&CacheEntry{
+		Key:         "char_system_prompt::expired",
+		Breakpoints: []CacheBreakpoint{{Layer: CorePersonalityLayer, Content: "Gone", TTL: time.Minute, LastUsed: time.Now().Add(-time.Hour)}},
+	}, time.Nanosecond)
+	prompts.StoreEntry(&CacheEntry{
+		Key:         "char_system_prompt::live",
+		Breakpoints: []CacheBreakpoint{{Layer: CorePersonalityLayer, Content: "Here", TTL: time.Hour, LastUsed: time.Now()}},
+	}, 0)
+	time.Sleep(time.Millisecond)
+
+	// A new process sweeps without ever loading them
+	responses := NewResponseCache(time.Hour)
+	responses.SetStore(store)
+	responses.SweepStore()
+	fresh := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	fresh.SetStore(store)
+	fresh.SweepStore()
+
+	if shared, _ := store.SharedResponses(); len(shared) != 1 || shared[0].Key != "live" {
+		t.Errorf("Expected only the live response to remain, got %+v", shared)
+	}
+	if entries, _ := store.SharedPrompts(); len(entries) != 1 || entries[0].Key != "char_system_prompt::live" {
+		t.Errorf("Expected only the live prompt to remain, got %+v", entries)
+	}
+}
+
+func TestCachesReportStoreFailuresToLogOutput(t *testing.T) {
+	dir := t.TempDir()
+	store := NewDiskStore(dir)
+	for _, group := range []string{"prompts", "responses"} {
+		if err := os.MkdirAll(filepath.Join(dir, group), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", group, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, group, "broken.json"), []byte("{not json"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", group, err)
+		}
+	}
+
+	var logs bytes.Buffer
+	prompts := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	prompts.SetStore(store)
+	prompts.SetLogOutput(&logs)
+	prompts.SweepStore()
+	responses := NewResponseCache(time.Hour)
+	responses.SetStore(store)
+	responses.SetLogOutput(&logs)
+	responses.SweepStore()
+
+	for _, name := range []string{"prompts/broken", "responses/broken"} {
+		if !strings.Contains(logs.String(), "Warning: Skipping "+name) {
+			t.Errorf("Expected a warning about %s, got %q", name, logs.String())
+		}
+	}
+}
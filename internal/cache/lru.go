@@ -0,0 +1,77 @@
+package cache
+
+import "container/list"
+
+// Stats summarises what a cache holds and how it has been used since the
+// process started
+type Stats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"` // Size of the cached content
+	Hits      int   `json:"hits"`
+	Misses    int   `json:"misses"`
+	Evictions int   `json:"evictions"` // Entries dropped to stay within the limits
+}
+
+// HitRate returns the share of lookups that were hits
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// lru tracks the size of a cache's entries and orders their keys from most
+// to least recently used, so the cache can evict the coldest entries once it
+// holds more than maxEntries entries or maxBytes bytes; zero means unlimited
+type lru struct {
+	order      *list.List
+	elems      map[string]*list.Element
+	sizes      map[string]int64
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+}
+
+func newLRU() *lru {
+	return &lru{
+		order: list.New(),
+		elems: make(map[string]*list.Element),
+		sizes: make(map[string]int64),
+	}
+}
+
+// touch marks key as the most recently used, recording its current size
+func (l *lru) touch(key string, size int64) {
+	if elem, ok := l.elems[key]; ok {
+		l.order.MoveToFront(elem)
+	} else {
+		l.elems[key] = l.order.PushFront(key)
+	}
+	l.bytes += size - l.sizes[key]
+	l.sizes[key] = size
+}
+
+// remove forgets key
+func (l *lru) remove(key string) {
+	if elem, ok := l.elems[key]; ok {
+		l.order.Remove(elem)
+		delete(l.elems, key)
+		l.bytes -= l.sizes[key]
+		delete(l.sizes, key)
+	}
+}
+
+// over reports whether the cache is past one of its limits. The most recent
+// entry is always kept, even when it alone exceeds maxBytes.
+func (l *lru) over() bool {
+	if l.order.Len() <= 1 {
+		return false
+	}
+	return (l.maxEntries > 0 && l.order.Len() > l.maxEntries) ||
+		(l.maxBytes > 0 && l.bytes > l.maxBytes)
+}
+
+// oldest returns the least recently used key
+func (l *lru) oldest() string {
+	return l.order.Back().Value.(string)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromptCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	pc := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	pc.SetLimits(2, 0)
+
+	pc.Store("a", CorePersonalityLayer, "alpha", time.Hour)
+	pc.Store("b", CorePersonalityLayer, "beta", time.Hour)
+	pc.Get("a") // b is now the coldest
+	pc.Store("c", CorePersonalityLayer, "gamma", time.Hour)
+
+	if _, found := pc.Get("b"); found {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, found := pc.Get(key); !found {
+			t.Errorf("Expected %s to stay cached", key)
+		}
+	}
+
+	stats := pc.GetStats()
+	if stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("Expected 2 entries after 1 eviction, got %+v", stats)
+	}
+	if stats.Bytes != int64(len("alpha")+len("gamma")) {
+		t.Errorf("Expected the bytes of the remaining content, got %d", stats.Bytes)
+	}
+	if stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("Expected 3 hits and 1 miss, got %+v", stats)
+	}
+}
+
+func TestPromptCacheEvictsBeyondMaxBytes(t *testing.T) {
+	pc := NewPromptCache(5*time.Minute, time.Minute, 10*time.Minute)
+	pc.SetLimits(0, 100)
+
+	content := strings.Repeat("x", 40)
+	for _, key := range []string{"a", "b", "c"} {
+		pc.StoreWithTTL(key, []CacheBreakpoint{{Layer: CorePersonalityLayer, Content: content, TTL: time.Hour}}, time.Hour)
+	}
+	if stats := pc.GetStats(); stats.Entries != 2 || stats.Bytes != 80 || stats.Evictions != 1 {
+		t.Errorf("Expected two 40-byte entries to fit in 100 bytes, got %+v", stats)
+	}
+
+	// Growing an entry counts its new size
+	pc.Store("c", UserMemoryLayer, content, time.Hour)
+	if stats := pc.GetStats(); stats.Entries != 1 || stats.Bytes != 80 {
+		t.Errorf("Expected only the grown entry to remain, got %+v", stats)
+	}
+
+	// The newest entry is kept even when it alone is over the limit
+	pc.StoreWithTTL("huge", []CacheBreakpoint{{Content: strings.Repeat("x", 500), TTL: time.Hour}}, time.Hour)
+	if _, found := pc.Get("huge"); !found {
+		t.Error("Expected the newest entry to be kept")
+	}
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	rc := NewResponseCache(time.Hour)
+	rc.SetLimits(2, 0)
+
+	rc.Store("a", "alpha", TokenUsage{})
+	rc.Store("b", "beta", TokenUsage{})
+	rc.Get("a")
+	rc.Store("c", "gamma", TokenUsage{})
+
+	if _, found := rc.Get("b"); found {
+		t.Error("Expected the least recently used response to be evicted")
+	}
+	stats := rc.GetStats()
+	if stats.Entries != 2 || stats.Evictions != 1 || stats.Bytes != int64(len("alpha")+len("gamma")) {
+		t.Errorf("Unexpected stats after eviction: %+v", stats)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || stats.HitRate() != 0.5 {
+		t.Errorf("Expected 1 hit and 1 miss, got %+v", stats)
+	}
+}
@@ -3,6 +3,9 @@ package cache
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 )
@@ -12,105 +15,298 @@ type ResponseCache struct {
 	responses map[string]*CachedResponse
 	mu        sync.RWMutex
 	ttl       time.Duration
+	store     *DiskStore // Shares responses between processes; nil keeps them in memory
+	logs      io.Writer  // Where store failures are reported
+	lru       *lru
+	hits      int
+	misses    int
+	evictions int
 }
 
 // CachedResponse represents a cached API response
 type CachedResponse struct {
-	Content    string
-	TokensUsed TokenUsage
-	CachedAt   time.Time
-	ExpiresAt  time.Time
-	HitCount   int
+	Key         string     `json:"key"`
+	CharacterID string     `json:"character_id,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+	Content     string     `json:"content"`
+	TokensUsed  TokenUsage `json:"tokens_used"`
+	CachedAt    time.Time  `json:"cached_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	HitCount    int        `json:"hit_count"`
 }
 
 // TokenUsage represents token usage stats
 type TokenUsage struct {
-	Prompt       int
-	Completion   int
-	CachedPrompt int
-	Total        int
+	Prompt       int `json:"prompt"`
+	Completion   int `json:"completion"`
+	CachedPrompt int `json:"cached_prompt"`
+	Total        int `json:"total"`
 }
 
 // NewResponseCache creates a new response cache
 func NewResponseCache(ttl time.Duration) *ResponseCache {
-	cache := &ResponseCache{
+	return &ResponseCache{
 		responses: make(map[string]*CachedResponse),
 		ttl:       ttl,
+		lru:       newLRU(),
+		logs:      os.Stderr,
 	}
+}
 
-	// Start cleanup worker
-	go cache.cleanupWorker()
-
-	return cache
+// ResponseKey is what a cached reply depends on. The same message only gets
+// the same reply in the same scenario, after the same recent turns, from the
+// same version of the character.
+type ResponseKey struct {
+	CharacterID      string
+	CharacterVersion string // Hash of the character's definition
+	UserID           string
+	Scenario         string // Hash of the scenario prompt; empty without one
+	History          string // Hash of the recent turns
+	Message          string
 }
 
 // GenerateKey creates a cache key from request parameters
-func (rc *ResponseCache) GenerateKey(characterID, userID, message string) string {
+func (rc *ResponseCache) GenerateKey(key ResponseKey) string {
+	return hashParts(key.CharacterID, key.CharacterVersion, key.UserID, key.Scenario, key.History, key.Message)
+}
+
+// HashText returns a short stable hash of text for use in a ResponseKey
+func HashText(parts ...string) string {
+	return hashParts(parts...)[:16]
+}
+
+// hashParts hashes length-prefixed parts, so no two sequences run together
+func hashParts(parts ...string) string {
 	h := sha256.New()
-	h.Write([]byte(characterID + "|" + userID + "|" + message))
+	for _, part := range parts {
+		fmt.Fprintf(h, "%d:%s|", len(part), part)
+	}
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// SetStore shares the cache with other processes through store. Responses
+// missing from memory are looked up there, and new responses and hits are
+// written back.
+func (rc *ResponseCache) SetStore(store *DiskStore) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.store = store
+}
+
+// SetLogOutput sets where store failures are reported
+func (rc *ResponseCache) SetLogOutput(w io.Writer) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.logs = w
+}
+
+// SetLimits bounds the cache to maxEntries responses and maxBytes bytes of
+// content, evicting the least recently used responses beyond them; zero
+// means unlimited
+func (rc *ResponseCache) SetLimits(maxEntries int, maxBytes int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.lru.maxEntries = maxEntries
+	rc.lru.maxBytes = maxBytes
+	rc.evict()
+}
+
+// put records a response as the most recently used and evicts beyond the
+// limits; the caller holds rc.mu
+func (rc *ResponseCache) put(key string, resp *CachedResponse) {
+	rc.responses[key] = resp
+	rc.lru.touch(key, int64(len(resp.Content)))
+	rc.evict()
+}
+
+// remove drops a response; the caller holds rc.mu
+func (rc *ResponseCache) remove(key string) {
+	delete(rc.responses, key)
+	rc.lru.remove(key)
+}
+
+// evict drops least recently used responses until the cache is within its
+// limits; the caller holds rc.mu
+func (rc *ResponseCache) evict() {
+	for rc.lru.over() {
+		rc.remove(rc.lru.oldest())
+		rc.evictions++
+	}
+}
+
 // Get retrieves a cached response if available
 func (rc *ResponseCache) Get(key string) (*CachedResponse, bool) {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
 
 	resp, exists := rc.responses[key]
+	if !exists && rc.store != nil {
+		resp, exists = rc.load(key)
+	}
 	if !exists {
+		rc.misses++
 		return nil, false
 	}
 
 	// Check if expired
 	if time.Now().After(resp.ExpiresAt) {
+		rc.misses++
 		return nil, false
 	}
 
 	// Update hit count
 	resp.HitCount++
+	rc.hits++
+	rc.lru.touch(key, int64(len(resp.Content)))
+	if rc.store != nil {
+		rc.recordHit(key)
+	}
 
 	return resp, true
 }
 
 // Store adds a response to the cache
 func (rc *ResponseCache) Store(key, content string, tokens TokenUsage) {
+	rc.StoreResponse(key, &CachedResponse{Content: content, TokensUsed: tokens})
+}
+
+// StoreResponse adds a response to the cache, recording who it is for
+func (rc *ResponseCache) StoreResponse(key string, resp *CachedResponse) {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
-	rc.responses[key] = &CachedResponse{
-		Content:    content,
-		TokensUsed: tokens,
-		CachedAt:   time.Now(),
-		ExpiresAt:  time.Now().Add(rc.ttl),
-		HitCount:   0,
+	resp.Key = key
+	resp.CachedAt = time.Now()
+	resp.ExpiresAt = resp.CachedAt.Add(rc.ttl)
+	resp.HitCount = 0
+	rc.put(key, resp)
+
+	if rc.store != nil {
+		if err := rc.store.Save(responseDocument(key), resp); err != nil {
+			fmt.Fprintf(rc.logs, "Warning: Failed to share cached response: %v\n", err)
+		}
 	}
 }
 
-// cleanupWorker removes expired entries
-func (rc *ResponseCache) cleanupWorker() {
-	ticker := time.NewTicker(5 * time.Minute)
+// Delete removes a response, including its shared copy
+func (rc *ResponseCache) Delete(key string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.remove(key)
+	if rc.store != nil {
+		if err := rc.store.Delete(responseDocument(key)); err != nil {
+			fmt.Fprintf(rc.logs, "Warning: Failed to delete shared response: %v\n", err)
+		}
+	}
+}
+
+// responseDocument names the shared copy of a response
+func responseDocument(key string) string {
+	return "responses/" + key
+}
+
+// load reads a response another process cached; the caller holds rc.mu
+func (rc *ResponseCache) load(key string) (*CachedResponse, bool) {
+	var resp CachedResponse
+	found, err := rc.store.Load(responseDocument(key), &resp)
+	if err != nil {
+		fmt.Fprintf(rc.logs, "Warning: Failed to read shared response cache: %v\n", err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	if time.Now().After(resp.ExpiresAt) {
+		_ = rc.store.Delete(responseDocument(key))
+		return nil, false
+	}
+	rc.put(key, &resp)
+	return &resp, true
+}
+
+// recordHit counts a hit in the shared copy, so hit counts add up across
+// processes; the caller holds rc.mu
+func (rc *ResponseCache) recordHit(key string) {
+	var shared CachedResponse
+	err := rc.store.Update(responseDocument(key), &shared, func() error {
+		if shared.ExpiresAt.IsZero() {
+			return errNotShared
+		}
+		shared.HitCount++
+		return nil
+	})
+	if err != nil && err != errNotShared {
+		fmt.Fprintf(rc.logs, "Warning: Failed to update shared response cache: %v\n", err)
+	}
+}
+
+// CleanupWorker runs periodic cleanup of expired entries until stop is closed
+func (rc *ResponseCache) CleanupWorker(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		rc.mu.Lock()
-		now := time.Now()
-		for key, resp := range rc.responses {
-			if now.After(resp.ExpiresAt) {
-				delete(rc.responses, key)
+	for {
+		select {
+		case <-ticker.C:
+			rc.cleanup()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// cleanup removes expired entries, in memory and in the store
+func (rc *ResponseCache) cleanup() {
+	rc.mu.Lock()
+	now := time.Now()
+	for key, resp := range rc.responses {
+		if now.After(resp.ExpiresAt) {
+			rc.remove(key)
+			if rc.store != nil {
+				_ = rc.store.Delete(responseDocument(key))
 			}
 		}
-		rc.mu.Unlock()
 	}
+	rc.mu.Unlock()
+
+	rc.SweepStore()
 }
 
-// GetStats returns cache statistics
-func (rc *ResponseCache) GetStats() (hits, misses int) {
+// SweepStore removes expired responses from the store, including those this
+// process never loaded, so the shared cache does not grow without bound
+func (rc *ResponseCache) SweepStore() {
 	rc.mu.RLock()
-	defer rc.mu.RUnlock()
+	store, logs := rc.store, rc.logs
+	rc.mu.RUnlock()
+	if store == nil {
+		return
+	}
 
-	for _, resp := range rc.responses {
-		hits += resp.HitCount
+	responses, err := store.sharedResponses(logs)
+	if err != nil {
+		fmt.Fprintf(logs, "Warning: Failed to sweep shared response cache: %v\n", err)
+		return
+	}
+	now := time.Now()
+	for _, resp := range responses {
+		if now.After(resp.ExpiresAt) {
+			if err := store.DeleteSharedResponse(resp.Key); err != nil {
+				fmt.Fprintf(logs, "Warning: Failed to sweep shared response cache: %v\n", err)
+			}
+		}
 	}
+}
 
-	return hits, 0 // Misses would need to be tracked separately
+// GetStats returns the size of the cache and its hits, misses and evictions
+func (rc *ResponseCache) GetStats() Stats {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return Stats{
+		Entries:   len(rc.responses),
+		Bytes:     rc.lru.bytes,
+		Hits:      rc.hits,
+		Misses:    rc.misses,
+		Evictions: rc.evictions,
+	}
 }
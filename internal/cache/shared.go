@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SharedPrompts returns the prompt cache entries in the store, including
+// expired ones not yet cleaned up, ordered by key
+func (s *DiskStore) SharedPrompts() ([]*CacheEntry, error) {
+	return s.sharedPrompts(os.Stderr)
+}
+
+// sharedPrompts is SharedPrompts, reporting unreadable entries to logs
+func (s *DiskStore) sharedPrompts(logs io.Writer) ([]*CacheEntry, error) {
+	names, err := s.List("prompts")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*CacheEntry, 0, len(names))
+	for _, name := range names {
+		var entry CacheEntry
+		found, err := s.Load(name, &entry)
+		if err != nil {
+			fmt.Fprintf(logs, "Warning: Skipping %s: %v\n", name, err)
+			continue
+		}
+		if found {
+			entries = append(entries, &entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// SharedResponses returns the cached responses in the store, including
+// expired ones not yet cleaned up, ordered by key
+func (s *DiskStore) SharedResponses() ([]*CachedResponse, error) {
+	return s.sharedResponses(os.Stderr)
+}
+
+// sharedResponses is SharedResponses, reporting unreadable entries to logs
+func (s *DiskStore) sharedResponses(logs io.Writer) ([]*CachedResponse, error) {
+	names, err := s.List("responses")
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*CachedResponse, 0, len(names))
+	for _, name := range names {
+		var resp CachedResponse
+		found, err := s.Load(name, &resp)
+		if err != nil {
+			fmt.Fprintf(logs, "Warning: Skipping %s: %v\n", name, err)
+			continue
+		}
+		if found {
+			resp.Key = strings.TrimPrefix(name, "responses/")
+			responses = append(responses, &resp)
+		}
+	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].Key < responses[j].Key })
+	return responses, nil
+}
+
+// DeleteSharedPrompt removes a prompt cache entry from the store
+func (s *DiskStore) DeleteSharedPrompt(key string) error {
+	return s.Delete(promptDocument(key))
+}
+
+// DeleteSharedResponse removes a cached response from the store
+func (s *DiskStore) DeleteSharedResponse(key string) error {
+	return s.Delete(responseDocument(key))
+}
@@ -8,6 +8,7 @@ import (
 type CacheLayer string
 
 const (
+	SystemAdminLayer     CacheLayer = "system_admin"     // Global instructions, ahead of every other layer
 	ScenarioContextLayer CacheLayer = "scenario_context" // Highest layer - meta-prompts
 	CorePersonalityLayer CacheLayer = "core_personality"
 	LearnedBehaviorLayer CacheLayer = "learned_behavior"
@@ -27,12 +28,49 @@ type CacheBreakpoint struct {
 
 // CacheEntry represents a cached prompt entry
 type CacheEntry struct {
-	Breakpoints []CacheBreakpoint
-	Hash        string
-	CreatedAt   time.Time
-	LastAccess  time.Time
-	HitCount    int
-	UserID      string
+	Key         string            `json:"key"`
+	CharacterID string            `json:"character_id,omitempty"`
+	Breakpoints []CacheBreakpoint `json:"breakpoints"`
+	Hash        string            `json:"hash,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	LastAccess  time.Time         `json:"last_access"`
+	ExpiresAt   time.Time         `json:"expires_at,omitempty"` // Set by StoreWithTTL; otherwise breakpoint TTLs apply
+	HitCount    int               `json:"hit_count"`
+	UserID      string            `json:"user_id,omitempty"`
+}
+
+// Expiry returns when the entry expires: its own TTL if it was stored with
+// one, otherwise the first of its breakpoints to run out. The zero time
+// means it never expires.
+func (e *CacheEntry) Expiry() time.Time {
+	if !e.ExpiresAt.IsZero() {
+		return e.ExpiresAt
+	}
+	var expiry time.Time
+	for _, bp := range e.Breakpoints {
+		if at := bp.LastUsed.Add(bp.TTL); expiry.IsZero() || at.Before(expiry) {
+			expiry = at
+		}
+	}
+	return expiry
+}
+
+// TokenCount returns the tokens of all breakpoints
+func (e *CacheEntry) TokenCount() int {
+	total := 0
+	for _, bp := range e.Breakpoints {
+		total += bp.TokenCount
+	}
+	return total
+}
+
+// Size returns the bytes of breakpoint content an entry holds
+func (e *CacheEntry) Size() int64 {
+	var size int64
+	for _, bp := range e.Breakpoints {
+		size += int64(len(bp.Content))
+	}
+	return size
 }
 
 // TTLManager handles dynamic TTL calculations
@@ -1,6 +1,13 @@
 package config
 
-import "time"
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/pricing"
+)
 
 // Config holds all application configuration
 type Config struct {
@@ -9,15 +16,102 @@ type Config struct {
 	APIKey            string
 	BaseURL           string            // OpenAI-compatible endpoint
 	ModelAliases      map[string]string // Aliases for models
+	FallbackProviders []ProviderProfile // Tried in order when the default provider fails
+	CircuitBreaker    CircuitBreakerConfig
+	Retry             RetryConfig
+	Generation        models.GenerationParams // Default sampler settings for every reply
 	CacheConfig       CacheConfig
 	MemoryConfig      MemoryConfig
 	PersonalityConfig PersonalityConfig
 	UserProfileConfig UserProfileConfig
+	Emotion           EmotionConfig
+	Persistence       PersistenceConfig
+	Context           ContextConfig
+	Tokenizer         TokenizerConfig
+	Pricing           []pricing.Entry // Per-model rates, taking precedence over the built-in table
+	Budgets           BudgetConfig
+	RateLimits        map[string]RateLimitPolicy // Keyed by provider profile; "*" applies to unlisted profiles
+	DataDir           string                     // Where characters, sessions and state live; see StorageDir
+}
+
+// StorageDir returns the directory characters, sessions and state are kept
+// in: DataDir when set, otherwise ~/.config/roleplay
+func (c *Config) StorageDir() string {
+	if c.DataDir != "" {
+		return c.DataDir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "roleplay")
+}
+
+// ProviderProfile describes one provider in the failover chain
+type ProviderProfile struct {
+	Name    string `mapstructure:"name"` // Profile name, e.g. "groq" or "ollama"
+	APIKey  string `mapstructure:"api_key"`
+	Model   string `mapstructure:"model"`
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// CircuitBreakerConfig controls when a failing provider is taken out of rotation
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"` // Consecutive errors before the circuit opens
+	Cooldown         time.Duration `mapstructure:"cooldown"`          // How long to wait before a half-open probe
+}
+
+// RetryConfig controls retries of transient provider failures (429 and 5xx)
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"` // Total attempts including the first
+	BaseDelay   time.Duration `mapstructure:"base_delay"`   // First backoff delay, doubled per attempt
+	MaxDelay    time.Duration `mapstructure:"max_delay"`    // Longest single wait, including Retry-After
+}
+
+// EmotionConfig selects how reply emotions are scored
+type EmotionConfig struct {
+	Analyzer string `mapstructure:"analyzer"` // "lexicon" (default), "llm" or "none"
+}
+
+// PersistenceConfig controls how evolved character state is written back to disk
+type PersistenceConfig struct {
+	SaveDebounce time.Duration `mapstructure:"save_debounce"` // Quiet period before pending changes are saved
+}
+
+// ContextConfig controls how much conversation history fits in a request
+type ContextConfig struct {
+	Window        int `mapstructure:"window"`         // Context size in tokens; 0 uses the model's known size
+	ReserveTokens int `mapstructure:"reserve_tokens"` // Kept free for the reply when max_tokens is unset
+}
+
+// TokenizerConfig locates the BPE vocabularies used to count tokens
+type TokenizerConfig struct {
+	Dir string `mapstructure:"dir"` // Holds cl100k_base.tiktoken and o200k_base.tiktoken
+}
+
+// RateLimitPolicy caps the throughput sent to one provider profile; zero means unlimited
+type RateLimitPolicy struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	TokensPerMinute   int `mapstructure:"tokens_per_minute"` // Estimated prompt plus max_tokens
+}
+
+// BudgetConfig caps token use and spend per user ID and per character.
+// The "*" entry applies to every user or character without its own entry.
+type BudgetConfig struct {
+	Users      map[string]BudgetLimits `mapstructure:"users"`
+	Characters map[string]BudgetLimits `mapstructure:"characters"`
+	WarnAt     float64                 `mapstructure:"warn_at"` // Share of a limit that triggers a warning, default 0.8
+}
+
+// BudgetLimits are the caps of one user or character; zero means unlimited
+type BudgetLimits struct {
+	DailyTokens   int     `mapstructure:"daily_tokens"`
+	MonthlyTokens int     `mapstructure:"monthly_tokens"`
+	DailyCost     float64 `mapstructure:"daily_cost"` // USD
+	MonthlyCost   float64 `mapstructure:"monthly_cost"`
 }
 
 // CacheConfig holds cache-related configuration
 type CacheConfig struct {
 	MaxEntries                    int
+	MaxBytes                      int64 // Cached prompt and reply content per cache; 0 means unlimited
 	CleanupInterval               time.Duration
 	DefaultTTL                    time.Duration
 	EnableAdaptiveTTL             bool
@@ -28,7 +122,9 @@ type CacheConfig struct {
 type MemoryConfig struct {
 	ShortTermWindow    int           // Number of messages
 	MediumTermDuration time.Duration // How long to keep
-	ConsolidationRate  float64       // Learning rate for personality evolution
+	ConsolidationRate  float64       // Share of short-term memories summarised per consolidation pass
+	RetrievalTopK      int           // Memories recalled per message by semantic search; 0 disables
+	EmbeddingModel     string        // Embedding model for the memory index
 }
 
 // PersonalityConfig holds personality evolution configuration
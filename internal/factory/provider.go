@@ -2,6 +2,7 @@ package factory
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/dotcommander/roleplay/internal/config"
@@ -43,8 +44,10 @@ func CreateProvider(cfg *config.Config) (providers.AIProvider, error) {
 		return nil, fmt.Errorf("API key required for %s. Set api_key in config or environment variable", profileName)
 	}
 
-	// Always create the unified OpenAI-compatible provider
-	return providers.NewOpenAIProviderWithBaseURL(apiKey, model, baseURL), nil
+	provider := newProvider(profileName, apiKey, model, baseURL)
+	applyRetryPolicy(provider, cfg)
+	applyEmbeddingModel(provider, cfg)
+	return provider, nil
 }
 
 // InitializeAndRegisterProvider creates and registers a provider with the bot
@@ -56,13 +59,45 @@ func InitializeAndRegisterProvider(bot *services.CharacterBot, cfg *config.Confi
 
 	// Register using the profile name as the key
 	bot.RegisterProvider(cfg.DefaultProvider, provider)
+	RegisterFallbackProviders(bot, cfg)
 
 	// Initialize user profile agent after provider is registered
 	bot.InitializeUserProfileAgent()
+	bot.InitializeEmotionAnalyzer()
+	bot.InitializeEmbedder()
 
 	return nil
 }
 
+// RegisterFallbackProviders creates and registers the configured failover chain.
+// A profile that cannot be created is skipped with a warning so a bad fallback
+// entry never blocks the default provider.
+func RegisterFallbackProviders(bot *services.CharacterBot, cfg *config.Config) {
+	if cfg.DefaultProvider == "mock" {
+		return
+	}
+
+	for _, profile := range cfg.FallbackProviders {
+		if profile.Name == "" || profile.Name == cfg.DefaultProvider {
+			continue
+		}
+
+		baseURL := profile.BaseURL
+		if baseURL == "" {
+			baseURL = GetDefaultBaseURL(profile.Name)
+		}
+
+		provider, err := CreateProviderWithFallback(profile.Name, profile.APIKey, profile.Model, baseURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping fallback provider %s: %v\n", profile.Name, err)
+			continue
+		}
+		applyRetryPolicy(provider, cfg)
+		applyEmbeddingModel(provider, cfg)
+		bot.RegisterProvider(profile.Name, provider)
+	}
+}
+
 // CreateProviderWithFallback creates a provider with sensible defaults
 // This is useful for commands that don't use the full config structure
 func CreateProviderWithFallback(profileName, apiKey, model, baseURL string) (providers.AIProvider, error) {
@@ -84,8 +119,35 @@ To fix this:
 For more info: roleplay config where`, profileName, getProviderEnvVar(profileName))
 	}
 
-	// Always create the unified OpenAI-compatible provider
-	return providers.NewOpenAIProviderWithBaseURL(apiKey, model, baseURL), nil
+	return newProvider(profileName, apiKey, model, baseURL), nil
+}
+
+// newProvider creates the concrete provider for a profile.
+// The "anthropic" profile uses the native Messages API so cache breakpoints are
+// sent as explicit cache_control markers; everything else is OpenAI-compatible.
+func newProvider(profileName, apiKey, model, baseURL string) providers.AIProvider {
+	if strings.ToLower(profileName) == "anthropic" {
+		return providers.NewAnthropicProviderWithBaseURL(apiKey, model, baseURL)
+	}
+	return providers.NewOpenAIProviderWithBaseURL(apiKey, model, baseURL)
+}
+
+// applyRetryPolicy passes the configured retry policy to providers that retry
+func applyRetryPolicy(provider providers.AIProvider, cfg *config.Config) {
+	if rc, ok := provider.(providers.RetryConfigurable); ok {
+		rc.SetRetryPolicy(providers.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			BaseDelay:   cfg.Retry.BaseDelay,
+			MaxDelay:    cfg.Retry.MaxDelay,
+		})
+	}
+}
+
+// applyEmbeddingModel passes the configured embedding model to providers that embed
+func applyEmbeddingModel(provider providers.AIProvider, cfg *config.Config) {
+	if ec, ok := provider.(providers.EmbeddingConfigurable); ok {
+		ec.SetEmbeddingModel(cfg.MemoryConfig.EmbeddingModel)
+	}
 }
 
 // GetDefaultModel returns the default model for a profile
@@ -105,6 +167,25 @@ func GetDefaultModel(profileName string) string {
 	}
 }
 
+// GetDefaultBaseURL returns the well-known endpoint for a profile, or "" to use
+// the provider's own default
+func GetDefaultBaseURL(profileName string) string {
+	switch strings.ToLower(profileName) {
+	case "ollama":
+		return "http://localhost:11434/v1"
+	case "lmstudio", "lm_studio":
+		return "http://localhost:1234/v1"
+	case "groq":
+		return "https://api.groq.com/openai/v1"
+	case "gemini", "gemini_compatible":
+		return "https://generativelanguage.googleapis.com/v1beta/openai"
+	case "openrouter":
+		return "https://openrouter.ai/api/v1"
+	default:
+		return ""
+	}
+}
+
 // isLocalEndpoint determines if an endpoint is local and doesn't require API key
 func isLocalEndpoint(profileName, baseURL string) bool {
 	profileName = strings.ToLower(profileName)
@@ -17,6 +17,7 @@ func TestCreateProvider(t *testing.T) {
 		envCleanup  func()
 		wantErr     bool
 		errContains string
+		wantName    string
 	}{
 		{
 			name: "anthropic provider with API key in config",
@@ -24,7 +25,8 @@ func TestCreateProvider(t *testing.T) {
 				DefaultProvider: "anthropic",
 				APIKey:          "test-anthropic-key",
 			},
-			wantErr: false,
+			wantErr:  false,
+			wantName: "anthropic",
 		},
 		{
 			name: "openai provider with API key and model in config",
@@ -73,8 +75,12 @@ func TestCreateProvider(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, provider)
-				// All providers now return "openai_compatible"
-				assert.Equal(t, "openai_compatible", provider.Name())
+				// Anthropic uses the native Messages API, everything else is OpenAI-compatible
+				wantName := tt.wantName
+				if wantName == "" {
+					wantName = "openai_compatible"
+				}
+				assert.Equal(t, wantName, provider.Name())
 			}
 		})
 	}
@@ -173,3 +179,35 @@ func TestGetDefaultModel(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterFallbackProviders(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "openai",
+		APIKey:          "test-key",
+		FallbackProviders: []config.ProviderProfile{
+			{Name: "ollama"},
+			{Name: "groq"},   // No API key, skipped
+			{Name: "openai"}, // Same as default, skipped
+		},
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      5 * time.Minute,
+			CleanupInterval: 1 * time.Minute,
+		},
+	}
+
+	bot := services.NewCharacterBot(cfg)
+
+	err := InitializeAndRegisterProvider(bot, cfg)
+	assert.NoError(t, err)
+
+	_, ok := bot.ProviderCircuitState("ollama")
+	assert.True(t, ok, "ollama should be registered as a fallback")
+	_, ok = bot.ProviderCircuitState("groq")
+	assert.False(t, ok, "groq without an API key should be skipped")
+}
+
+func TestGetDefaultBaseURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:11434/v1", GetDefaultBaseURL("ollama"))
+	assert.Equal(t, "https://api.groq.com/openai/v1", GetDefaultBaseURL("groq"))
+	assert.Equal(t, "", GetDefaultBaseURL("openai"))
+}
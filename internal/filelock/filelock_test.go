@@ -0,0 +1,41 @@
+package filelock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireExcludesOtherHolders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", ".lock")
+
+	first, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	acquired := make(chan *Lock)
+	go func() {
+		second, err := Acquire(path)
+		if err != nil {
+			t.Errorf("Failed to acquire lock: %v", err)
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second holder to wait for the first")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	select {
+	case second := <-acquired:
+		second.Unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the lock to pass to the second holder")
+	}
+}
@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/utils"
+	"github.com/gosimple/slug"
+)
+
+// GenerateFromDescription asks provider to flesh out a one-line description
+// into a full character. The ID is left for the caller to set.
+func GenerateFromDescription(ctx context.Context, provider providers.AIProvider, description string) (*models.Character, error) {
+	// Load the prompt template
+	promptPath := filepath.Join(os.Getenv("HOME"), "go", "src", "roleplay", "prompts", "character-quickgen.md")
+	promptTemplate, err := os.ReadFile(promptPath)
+	if err != nil {
+		// Use embedded prompt if file not found
+		promptTemplate = []byte(defaultQuickgenPrompt)
+	}
+
+	// Build the full prompt
+	prompt := strings.ReplaceAll(string(promptTemplate), "{{.Description}}", description)
+
+	// Create AI request
+	request := providers.PromptRequest{
+		SystemPrompt: prompt,
+		Message:      "Generate a character based on the description provided. Output only the JSON object, no additional text.",
+	}
+
+	// Send request to AI
+	response, err := provider.SendRequest(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("AI request failed: %w", err)
+	}
+
+	// Extract JSON from response
+	jsonStr, err := utils.ExtractValidJSON(response.Content)
+	if err != nil {
+		// Try to find JSON by looking for the character structure
+		content := response.Content
+		if os.Getenv("DEBUG") == "true" {
+			fmt.Printf("Debug: AI Response:\n%s\n", content)
+		}
+		startIdx := strings.Index(content, "{")
+		if startIdx >= 0 {
+			endIdx := strings.LastIndex(content, "}")
+			if endIdx > startIdx {
+				jsonStr = content[startIdx : endIdx+1]
+			} else {
+				return nil, fmt.Errorf("failed to extract JSON from response (no closing brace): %w", err)
+			}
+		} else {
+			// If no JSON found, try creating a basic character from the description
+			// This is a fallback for when the AI doesn't return proper JSON
+			return createBasicCharacter(description), nil
+		}
+	}
+
+	// Parse the character
+	var character models.Character
+	if err := json.Unmarshal([]byte(jsonStr), &character); err != nil {
+		return nil, fmt.Errorf("failed to parse character JSON: %w", err)
+	}
+
+	// Set metadata
+	character.LastModified = time.Now()
+
+	return &character, nil
+}
+
+// GeneratedID derives a character ID from a generated character's name
+func GeneratedID(name string) string {
+	return slug.Make(name) + "-" + time.Now().Format("20060102")
+}
+
+const defaultQuickgenPrompt = `You are a character creation specialist. Generate a complete character profile based on the user's description.
+
+User Description: {{.Description}}
+
+Create a rich, detailed character that matches this description. The character should feel authentic and three-dimensional.
+
+IMPORTANT: 
+- Generate appropriate OCEAN personality values (0.0-1.0) that match the description
+- Create a compelling backstory that explains their current situation
+- Include specific quirks and speech patterns that make them memorable
+- Add depth with fears, goals, relationships, and internal conflicts
+- Ensure all fields are filled with meaningful content (no empty arrays)
+
+Output the character as a JSON object matching this structure exactly:
+
+{
+  "name": "Character's Full Name",
+  "age": "Age or age range",
+  "gender": "Gender identity",
+  "occupation": "Their job or role",
+  "education": "Educational background",
+  "nationality": "Country of origin",
+  "ethnicity": "Ethnic background",
+  "backstory": "Detailed background story explaining who they are and how they got here",
+  "personality": {
+    "openness": 0.7,
+    "conscientiousness": 0.6,
+    "extraversion": 0.5,
+    "agreeableness": 0.8,
+    "neuroticism": 0.3
+  },
+  "current_mood": {
+    "joy": 0.5,
+    "surprise": 0.1,
+    "anger": 0.1,
+    "fear": 0.1,
+    "sadness": 0.1,
+    "disgust": 0.1
+  },
+  "physical_traits": [
+    "Notable physical characteristic 1",
+    "Notable physical characteristic 2"
+  ],
+  "skills": [
+    "Relevant skill 1",
+    "Relevant skill 2",
+    "Relevant skill 3"
+  ],
+  "interests": [
+    "Interest or hobby 1",
+    "Interest or hobby 2"
+  ],
+  "fears": [
+    "Deep fear 1",
+    "Deep fear 2"
+  ],
+  "goals": [
+    "Major life goal 1",
+    "Major life goal 2"
+  ],
+  "relationships": {
+    "key_person": "Relationship description"
+  },
+  "core_beliefs": [
+    "Fundamental belief 1",
+    "Fundamental belief 2",
+    "Fundamental belief 3"
+  ],
+  "moral_code": [
+    "Ethical principle 1",
+    "Ethical principle 2"
+  ],
+  "flaws": [
+    "Character flaw 1",
+    "Character flaw 2"
+  ],
+  "strengths": [
+    "Key strength 1",
+    "Key strength 2"
+  ],
+  "catch_phrases": [
+    "Signature phrase 1",
+    "Signature phrase 2"
+  ],
+  "dialogue_examples": [
+    "Example of how they speak in a typical situation",
+    "Example showing their personality through dialogue"
+  ],
+  "behavior_patterns": [
+    "Typical behavior 1",
+    "Typical behavior 2"
+  ],
+  "emotional_triggers": {
+    "trigger_situation": "Emotional response"
+  },
+  "decision_making": "How they approach decisions",
+  "conflict_style": "How they handle conflict",
+  "world_view": "Their perspective on life and existence",
+  "life_philosophy": "Core philosophy or motto",
+  "daily_routines": [
+    "Daily habit 1",
+    "Daily habit 2"
+  ],
+  "hobbies": [
+    "Hobby 1",
+    "Hobby 2"
+  ],
+  "pet_peeves": [
+    "Pet peeve 1",
+    "Pet peeve 2"
+  ],
+  "secrets": [
+    "Hidden secret 1"
+  ],
+  "regrets": [
+    "Major regret"
+  ],
+  "achievements": [
+    "Notable achievement"
+  ],
+  "quirks": [
+    "Unique mannerism 1",
+    "Unique mannerism 2",
+    "Unique mannerism 3"
+  ],
+  "speech_style": "Detailed description of how they speak, including tone, vocabulary, and patterns"
+}
+
+Generate a complete, nuanced character that would be compelling to interact with. Make them feel real and three-dimensional.`
+
+// createBasicCharacter creates a simple character when AI generation fails
+func createBasicCharacter(description string) *models.Character {
+	// Extract a simple name from the description
+	words := strings.Fields(description)
+	name := "Generated Character"
+	if len(words) > 0 {
+		// Try to find a name-like word
+		for _, word := range words {
+			if len(word) > 2 && word == strings.ToUpper(word[:1])+strings.ToLower(word[1:]) {
+				name = word
+				break
+			}
+		}
+	}
+
+	return &models.Character{
+		Name:      name,
+		Backstory: description,
+		Personality: models.PersonalityTraits{
+			Openness:          0.7,
+			Conscientiousness: 0.6,
+			Extraversion:      0.5,
+			Agreeableness:     0.6,
+			Neuroticism:       0.4,
+		},
+		CurrentMood: models.EmotionalState{
+			Joy:     0.5,
+			Anger:   0.1,
+			Fear:    0.1,
+			Sadness: 0.1,
+			Disgust: 0.1,
+		},
+		Quirks:      []string{"Unique mannerisms"},
+		SpeechStyle: "Natural conversational style",
+	}
+}
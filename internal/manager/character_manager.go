@@ -2,10 +2,10 @@ package manager
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"sync"
 
+	"github.com/dotcommander/roleplay/internal/cache"
 	"github.com/dotcommander/roleplay/internal/config"
 	"github.com/dotcommander/roleplay/internal/factory"
 	"github.com/dotcommander/roleplay/internal/models"
@@ -19,7 +19,11 @@ type CharacterManager struct {
 	bot                *services.CharacterBot
 	repo               *repository.CharacterRepository
 	sessions           *repository.SessionRepository
+	scenarios          *repository.ScenarioRepository
+	profiles           *repository.UserProfileRepository
 	mu                 sync.RWMutex
+	turnsMu            sync.Mutex
+	turns              map[string]*turnLock // Serialises turns per session
 	dataDir            string
 	cfg                *config.Config
 	providerInitialized bool
@@ -27,7 +31,7 @@ type CharacterManager struct {
 
 // NewCharacterManagerWithoutProvider creates a new character manager without initializing providers
 func NewCharacterManagerWithoutProvider(cfg *config.Config) (*CharacterManager, error) {
-	dataDir := filepath.Join(os.Getenv("HOME"), ".config", "roleplay")
+	dataDir := cfg.StorageDir()
 
 	repo, err := repository.NewCharacterRepository(dataDir)
 	if err != nil {
@@ -36,11 +40,17 @@ func NewCharacterManagerWithoutProvider(cfg *config.Config) (*CharacterManager,
 
 	sessions := repository.NewSessionRepository(dataDir)
 	bot := services.NewCharacterBot(cfg)
+	bot.SetStateStore(repository.NewCharacterStateRepository(filepath.Join(dataDir, "character_states")))
+	bot.SetMemoryIndex(repository.NewMemoryIndex(filepath.Join(dataDir, "memory_index")))
+	bot.SetCacheStore(cache.NewDiskStore(filepath.Join(dataDir, "cache")))
 
 	return &CharacterManager{
 		bot:                bot,
 		repo:               repo,
 		sessions:           sessions,
+		scenarios:          repository.NewScenarioRepository(dataDir),
+		profiles:           repository.NewUserProfileRepository(filepath.Join(dataDir, "user_profiles")),
+		turns:              make(map[string]*turnLock),
 		dataDir:            dataDir,
 		cfg:                cfg,
 		providerInitialized: false,
@@ -59,10 +69,7 @@ func NewCharacterManager(cfg *config.Config) (*CharacterManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize provider: %w", err)
 	}
-
-	mgr.bot.RegisterProvider(cfg.DefaultProvider, provider)
-	mgr.bot.InitializeUserProfileAgent()
-	mgr.providerInitialized = true
+	mgr.initializeProvider(provider)
 
 	return mgr, nil
 }
@@ -80,12 +87,28 @@ func (m *CharacterManager) EnsureProviderInitialized() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize provider: %w", err)
 	}
+	m.initializeProvider(provider)
 
+	return nil
+}
+
+// UseProvider makes provider the default provider in place of the one the
+// config describes; it must be called before the first chat
+func (m *CharacterManager) UseProvider(provider providers.AIProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initializeProvider(provider)
+}
+
+// initializeProvider registers provider as the default along with the
+// configured fallbacks, and starts the helpers that need one
+func (m *CharacterManager) initializeProvider(provider providers.AIProvider) {
 	m.bot.RegisterProvider(m.cfg.DefaultProvider, provider)
+	factory.RegisterFallbackProviders(m.bot, m.cfg)
 	m.bot.InitializeUserProfileAgent()
+	m.bot.InitializeEmotionAnalyzer()
+	m.bot.InitializeEmbedder()
 	m.providerInitialized = true
-
-	return nil
 }
 
 // LoadAllCharacters loads all persisted characters into memory
@@ -145,6 +168,33 @@ func (m *CharacterManager) CreateCharacter(char *models.Character) error {
 	return m.repo.SaveCharacter(char)
 }
 
+// UpdateCharacter replaces a persisted character
+func (m *CharacterManager) UpdateCharacter(char *models.Character) error {
+	if _, err := m.GetOrLoadCharacter(char.ID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.bot.UpdateCharacter(char); err != nil {
+		return err
+	}
+	return m.repo.SaveCharacter(char)
+}
+
+// DeleteCharacter removes a character from disk and memory
+func (m *CharacterManager) DeleteCharacter(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.repo.DeleteCharacter(id); err != nil {
+		return err
+	}
+	m.bot.RemoveCharacter(id)
+	return nil
+}
+
 // GetOrLoadCharacter ensures a character is loaded
 func (m *CharacterManager) GetOrLoadCharacter(id string) (*models.Character, error) {
 	// First try to get from memory
@@ -176,6 +226,16 @@ func (m *CharacterManager) GetSessionRepository() *repository.SessionRepository
 	return m.sessions
 }
 
+// GetScenarioRepository returns the scenario repository
+func (m *CharacterManager) GetScenarioRepository() *repository.ScenarioRepository {
+	return m.scenarios
+}
+
+// GetUserProfileRepository returns the user profile repository
+func (m *CharacterManager) GetUserProfileRepository() *repository.UserProfileRepository {
+	return m.profiles
+}
+
 // createProvider creates an AI provider based on the configuration
 func createProvider(cfg *config.Config) (providers.AIProvider, error) {
 	// Use the factory to create the provider
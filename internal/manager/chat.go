@@ -0,0 +1,247 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// ErrSessionOwner is returned by Chat when the session was started by another user
+var ErrSessionOwner = errors.New("session belongs to another user")
+
+// ChatRequest is one user message in a persisted session
+type ChatRequest struct {
+	CharacterID string
+	UserID      string
+	SessionID   string // A new session is started when empty
+	ScenarioID  string
+	Message     string
+	CachePolicy models.CachePolicy
+
+	// History replaces the session's turns as the conversation so far, for
+	// clients that keep their own; the new turn is still recorded
+	History []models.Message
+
+	// Stream receives the reply as it is generated when set; Chat closes it
+	Stream chan<- providers.PartialAIResponse
+}
+
+// ChatResult is the character's reply and the session it was recorded in
+type ChatResult struct {
+	Response *providers.AIResponse
+	Session  *repository.Session
+}
+
+// Chat sends a message to a character, continuing the session's history,
+// and records both turns and their cache metrics in the session
+func (m *CharacterManager) Chat(ctx context.Context, req ChatRequest) (*ChatResult, error) {
+	if err := m.prepareChat(req.CharacterID); err != nil {
+		if req.Stream != nil {
+			close(req.Stream)
+		}
+		return nil, err
+	}
+
+	sessionID := req.SessionID
+	if sessionID == "" {
+		sessionID = fmt.Sprintf("session-%d", time.Now().UnixNano()) // Unique even under a fixed clock
+	}
+
+	// Turns in one session must not interleave
+	unlock := m.lockTurn(req.CharacterID, sessionID)
+	defer unlock()
+
+	now := m.bot.Now()
+	var session *repository.Session
+	if req.SessionID != "" {
+		loaded, err := m.sessions.LoadSession(req.CharacterID, sessionID)
+		switch {
+		case errors.Is(err, repository.ErrSessionNotFound):
+		case err != nil:
+			// An unreadable session is kept for inspection, not replaced
+			if req.Stream != nil {
+				close(req.Stream)
+			}
+			return nil, err
+		case loaded.UserID != req.UserID:
+			if req.Stream != nil {
+				close(req.Stream)
+			}
+			return nil, fmt.Errorf("session %s: %w", sessionID, ErrSessionOwner)
+		default:
+			session = loaded
+		}
+	}
+	if session == nil {
+		session = &repository.Session{
+			ID:           sessionID,
+			CharacterID:  req.CharacterID,
+			UserID:       req.UserID,
+			StartTime:    now,
+			LastActivity: now,
+			Messages:     []repository.SessionMessage{},
+			CacheMetrics: repository.CacheMetrics{},
+		}
+	}
+
+	// Pass every turn not yet summarised; the bot trims them to the
+	// model's context window and folds older turns into the summary
+	summary := session.Summary
+	summarized := session.SummarizedCount
+	if summarized > len(session.Messages) {
+		summarized = len(session.Messages)
+	}
+	var recentMessages []models.Message
+	for _, msg := range session.Messages[summarized:] {
+		// Map "character" role to "assistant" for API compatibility
+		role := msg.Role
+		if role == "character" {
+			role = "assistant"
+		}
+		recentMessages = append(recentMessages, models.Message{
+			Role:      role,
+			Content:   msg.Content,
+			Timestamp: msg.Timestamp,
+		})
+	}
+	if req.History != nil {
+		recentMessages, summary, summarized = req.History, "", 0
+	}
+
+	convReq := &models.ConversationRequest{
+		CharacterID: req.CharacterID,
+		UserID:      req.UserID,
+		Message:     req.Message,
+		ScenarioID:  req.ScenarioID,
+		CachePolicy: req.CachePolicy,
+		Context: models.ConversationContext{
+			SessionID:       sessionID,
+			StartTime:       session.StartTime,
+			RecentMessages:  recentMessages,
+			Summary:         summary,
+			SummarizedCount: summarized,
+			// A client's own history would be resent in full next turn,
+			// so a summary of it could never be reused
+			DropOverflow: req.History != nil,
+		},
+	}
+
+	var resp *providers.AIResponse
+	var err error
+	if req.Stream != nil {
+		resp, err = m.bot.ProcessStreamRequest(ctx, convReq, req.Stream)
+	} else {
+		resp, err = m.bot.ProcessRequest(ctx, convReq)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the rolling summary of turns that no longer fit
+	if req.History == nil {
+		session.Summary = convReq.Context.Summary
+		session.SummarizedCount = convReq.Context.SummarizedCount
+	}
+
+	session.Messages = append(session.Messages, repository.SessionMessage{
+		Timestamp: now,
+		Role:      "user",
+		Content:   req.Message,
+	})
+
+	cacheHits := 0
+	cacheMisses := 0
+	if resp.CacheMetrics.Hit {
+		cacheHits = 1
+	} else {
+		cacheMisses = 1
+	}
+
+	session.Messages = append(session.Messages, repository.SessionMessage{
+		Timestamp:    m.bot.Now(),
+		Role:         "character",
+		Content:      resp.Content,
+		TokensUsed:   resp.TokensUsed.Total,
+		CachedTokens: resp.TokensUsed.CachedPrompt,
+		CacheHits:    cacheHits,
+		CacheMisses:  cacheMisses,
+		Usage:        ReplyUsage(resp),
+	})
+
+	session.CacheMetrics.TotalRequests++
+	if resp.CacheMetrics.Hit {
+		session.CacheMetrics.CacheHits++
+	} else {
+		session.CacheMetrics.CacheMisses++
+	}
+	session.CacheMetrics.TokensSaved += resp.CacheMetrics.SavedTokens
+	session.CacheMetrics.HitRate = float64(session.CacheMetrics.CacheHits) / float64(session.CacheMetrics.TotalRequests)
+	session.CacheMetrics.CostSaved += resp.CostSaved
+	session.LastActivity = m.bot.Now()
+
+	// The reply stands even if the session cannot be written
+	if err := m.sessions.SaveSession(session); err != nil {
+		fmt.Fprintf(m.bot.LogOutput(), "Warning: Failed to save session: %v\n", err)
+	}
+
+	return &ChatResult{Response: resp, Session: session}, nil
+}
+
+// prepareChat loads the character and the provider a chat needs
+func (m *CharacterManager) prepareChat(characterID string) error {
+	if _, err := m.GetOrLoadCharacter(characterID); err != nil {
+		return err
+	}
+	return m.EnsureProviderInitialized()
+}
+
+// turnLock is a session's turn lock and the number of turns holding or
+// waiting for it, so idle sessions don't keep an entry
+type turnLock struct {
+	mu      sync.Mutex
+	holders int
+}
+
+// lockTurn holds a session's turn lock until the returned function is called
+func (m *CharacterManager) lockTurn(characterID, sessionID string) func() {
+	key := characterID + "/" + sessionID
+
+	m.turnsMu.Lock()
+	turn, ok := m.turns[key]
+	if !ok {
+		turn = &turnLock{}
+		m.turns[key] = turn
+	}
+	turn.holders++
+	m.turnsMu.Unlock()
+
+	turn.mu.Lock()
+	return func() {
+		turn.mu.Unlock()
+
+		m.turnsMu.Lock()
+		turn.holders--
+		if turn.holders == 0 {
+			delete(m.turns, key)
+		}
+		m.turnsMu.Unlock()
+	}
+}
+
+// ReplyUsage records the model and cost of a character reply for its session
+func ReplyUsage(resp *providers.AIResponse) *repository.MessageUsage {
+	return &repository.MessageUsage{
+		Provider:         resp.Provider,
+		Model:            resp.Model,
+		PromptTokens:     resp.TokensUsed.Prompt,
+		CompletionTokens: resp.TokensUsed.Completion,
+		Cost:             resp.Cost,
+		CostSaved:        resp.CostSaved,
+	}
+}
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// newChatManager returns a manager backed by the mock provider with one
+// character, "narrator"
+func newChatManager(t *testing.T) *CharacterManager {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	providers.ResetGlobalMock()
+
+	cfg := &config.Config{
+		CacheConfig: config.CacheConfig{
+			CleanupInterval: 5 * time.Minute,
+			DefaultTTL:      10 * time.Minute,
+		},
+		DefaultProvider: "mock",
+		Model:           "mock-model",
+		APIKey:          "mock-key",
+	}
+	mgr, err := NewCharacterManagerWithoutProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	t.Cleanup(mgr.GetBot().Stop)
+
+	if err := mgr.CreateCharacter(&models.Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	return mgr
+}
+
+func TestChatReleasesTurnLocks(t *testing.T) {
+	mgr := newChatManager(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := mgr.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "alice", SessionID: "shared", Message: "Hello"}); err != nil {
+				t.Errorf("Chat failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := mgr.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "alice", Message: "Hello"}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	mgr.turnsMu.Lock()
+	defer mgr.turnsMu.Unlock()
+	if len(mgr.turns) != 0 {
+		t.Errorf("Expected no turn locks once every turn ended, got %d", len(mgr.turns))
+	}
+}
+
+func TestChatKeepsUnreadableSession(t *testing.T) {
+	mgr := newChatManager(t)
+
+	path := filepath.Join(mgr.dataDir, "sessions", "narrator", "broken.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create session directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write session: %v", err)
+	}
+
+	if _, err := mgr.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "alice", SessionID: "broken", Message: "Hello"}); err == nil {
+		t.Fatal("Expected an unreadable session to fail the chat")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read session: %v", err)
+	}
+	if string(data) != "{not json" {
+		t.Errorf("Expected the unreadable session to be left alone, got %q", data)
+	}
+}
+
+func TestChatRejectsOtherUsersSession(t *testing.T) {
+	mgr := newChatManager(t)
+
+	first, err := mgr.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "alice", Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	_, err = mgr.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "mallory", SessionID: first.Session.ID, Message: "Hello"})
+	if !errors.Is(err, ErrSessionOwner) {
+		t.Fatalf("Expected ErrSessionOwner, got %v", err)
+	}
+
+	session, err := mgr.GetSessionRepository().LoadSession("narrator", first.Session.ID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if len(session.Messages) != 2 {
+		t.Errorf("Expected the rejected turn not to be recorded, got %d messages", len(session.Messages))
+	}
+}
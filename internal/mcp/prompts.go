@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+type prompt struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description"`
+}
+
+type promptMessage struct {
+	Role    string      `json:"role"`
+	Content textContent `json:"content"`
+}
+
+// listPrompts offers each character's persona as a prompt named by its ID
+func (s *Server) listPrompts() (interface{}, error) {
+	infos, err := s.mgr.ListAvailableCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list characters: %w", err)
+	}
+
+	prompts := make([]prompt, 0, len(infos))
+	for _, info := range infos {
+		prompts = append(prompts, prompt{
+			Name:        info.ID,
+			Title:       info.Name,
+			Description: fmt.Sprintf("Adopt the persona of %s", info.Name),
+		})
+	}
+	return map[string]interface{}{"prompts": prompts}, nil
+}
+
+// getPrompt returns a character's core system prompt, so another agent can
+// take on the persona
+func (s *Server) getPrompt(params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+	if repository.ValidateID(req.Name) != nil {
+		return nil, invalidParams("invalid prompt name %q", req.Name)
+	}
+	char, err := s.mgr.GetOrLoadCharacter(req.Name)
+	if err != nil {
+		return nil, invalidParams("prompt %q not found", req.Name)
+	}
+	text, err := s.mgr.GetBot().CharacterSystemPrompt(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	char.RLock()
+	name := char.Name
+	char.RUnlock()
+	return map[string]interface{}{
+		"description": fmt.Sprintf("Persona of %s", name),
+		"messages":    []promptMessage{{Role: "user", Content: textContent{Type: "text", Text: text}}},
+	}, nil
+}
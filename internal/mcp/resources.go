@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+const (
+	characterURIPrefix = "roleplay://characters/"
+	scenarioURIPrefix  = "roleplay://scenarios/"
+)
+
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// listResources lists every character and scenario as a JSON resource
+func (s *Server) listResources() (interface{}, error) {
+	infos, err := s.mgr.ListAvailableCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list characters: %w", err)
+	}
+	scenarios, err := s.mgr.GetScenarioRepository().ListScenarios()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios: %w", err)
+	}
+
+	resources := make([]resource, 0, len(infos)+len(scenarios))
+	for _, info := range infos {
+		resources = append(resources, resource{
+			URI:         characterURIPrefix + info.ID,
+			Name:        info.Name,
+			Description: info.Description,
+			MimeType:    "application/json",
+		})
+	}
+	for _, scenario := range scenarios {
+		resources = append(resources, resource{
+			URI:         scenarioURIPrefix + scenario.ID,
+			Name:        scenario.Name,
+			Description: scenario.Description,
+			MimeType:    "application/json",
+		})
+	}
+	return map[string]interface{}{"resources": resources}, nil
+}
+
+// readResource returns a character or scenario as JSON
+func (s *Server) readResource(params json.RawMessage) (interface{}, error) {
+	var req struct {
+		URI string `json:"uri"`
+	}
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	switch {
+	case strings.HasPrefix(req.URI, characterURIPrefix):
+		id := strings.TrimPrefix(req.URI, characterURIPrefix)
+		if repository.ValidateID(id) != nil {
+			return nil, invalidParams("invalid character ID %q", id)
+		}
+		char, err := s.mgr.GetOrLoadCharacter(id)
+		if err != nil {
+			return nil, invalidParams("resource %s not found", req.URI)
+		}
+		char.RLock()
+		defer char.RUnlock()
+		v = char
+	case strings.HasPrefix(req.URI, scenarioURIPrefix):
+		id := strings.TrimPrefix(req.URI, scenarioURIPrefix)
+		if repository.ValidateID(id) != nil {
+			return nil, invalidParams("invalid scenario ID %q", id)
+		}
+		scenario, err := s.mgr.GetScenarioRepository().LoadScenario(id)
+		if err != nil {
+			return nil, invalidParams("resource %s not found", req.URI)
+		}
+		v = scenario
+	default:
+		return nil, invalidParams("unknown resource %s", req.URI)
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+	return map[string]interface{}{
+		"contents": []resourceContents{{URI: req.URI, MimeType: "application/json", Text: string(data)}},
+	}, nil
+}
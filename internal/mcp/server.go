@@ -0,0 +1,268 @@
+// Package mcp serves characters, scenarios, sessions and chat to Model
+// Context Protocol clients as resources, tools and prompts. Messages are
+// newline-delimited JSON-RPC 2.0 over a pair of streams, normally stdio.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+)
+
+// supportedVersions are the protocol revisions the server speaks, newest first
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// maxMessageBytes bounds a single message from the client
+const maxMessageBytes = 4 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent on notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// invalidParams reports a request whose params can't be used
+func invalidParams(format string, args ...interface{}) *rpcError {
+	return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// Server answers MCP requests from a CharacterManager
+type Server struct {
+	mgr     *manager.CharacterManager
+	version string
+
+	writeMu sync.Mutex
+	out     *json.Encoder
+
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelFunc // Running requests by ID, for cancellation
+}
+
+// New creates a server for mgr; version is reported to clients
+func New(mgr *manager.CharacterManager, version string) *Server {
+	return &Server{
+		mgr:      mgr,
+		version:  version,
+		inflight: make(map[string]context.CancelFunc),
+	}
+}
+
+// Serve answers requests read from in on out until in ends or ctx is
+// cancelled. Requests run concurrently, so a long chat doesn't hold up pings;
+// Serve waits for them before returning.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = json.NewEncoder(out)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+		for scanner.Scan() {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var running sync.WaitGroup
+	defer running.Wait()
+	for {
+		select {
+		case line := <-lines:
+			var req rpcRequest
+			if err := json.Unmarshal(line, &req); err != nil {
+				s.reply(rpcResponse{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: fmt.Sprintf("invalid JSON: %v", err)}})
+				continue
+			}
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				s.handle(ctx, req)
+			}()
+		case err := <-readErr:
+			if err != nil {
+				return fmt.Errorf("failed to read request: %w", err)
+			}
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// handle dispatches one request or notification
+func (s *Server) handle(ctx context.Context, req rpcRequest) {
+	notification := len(req.ID) == 0
+	if notification {
+		s.handleNotification(req)
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		s.reply(rpcResponse{ID: req.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	key := string(req.ID)
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+	defer func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+	}()
+
+	result, err := s.dispatch(ctx, req)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		s.reply(rpcResponse{ID: req.ID, Error: rpcErr})
+		return
+	}
+	s.reply(rpcResponse{ID: req.ID, Result: result})
+}
+
+func (s *Server) dispatch(ctx context.Context, req rpcRequest) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "resources/list":
+		return s.listResources()
+	case "resources/read":
+		return s.readResource(req.Params)
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "prompts/list":
+		return s.listPrompts()
+	case "prompts/get":
+		return s.getPrompt(req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// handleNotification acts on client notifications; only cancellation matters
+func (s *Server) handleNotification(req rpcRequest) {
+	if req.Method != "notifications/cancelled" {
+		return
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return
+	}
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[string(params.RequestID)]
+	s.inflightMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	// Answer in the client's revision when we speak it, else our newest
+	version := supportedVersions[0]
+	for _, v := range supportedVersions {
+		if v == req.ProtocolVersion {
+			version = v
+		}
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"resources": map[string]interface{}{},
+			"tools":     map[string]interface{}{},
+			"prompts":   map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    "roleplay",
+			"version": s.version,
+		},
+		"instructions": "Characters are personas with memory, mood and per-user profiles. " +
+			"Read roleplay://characters/{id} to learn about one, chat with it using chat_with_character, " +
+			"or borrow its persona through the prompt of the same name.",
+	}, nil
+}
+
+// decodeParams unmarshals request params into v, treating absent params as empty
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+// reply writes one response; responses to concurrent requests never interleave
+func (s *Server) reply(resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.out.Encode(resp); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to write response: %v\n", err)
+	}
+}
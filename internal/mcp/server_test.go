@@ -0,0 +1,261 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// testClient talks to a server over pipes
+type testClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Scanner
+	nextID int
+}
+
+// newTestClient serves a manager backed by the mock provider and a
+// temporary data directory
+func newTestClient(t *testing.T) (*testClient, *manager.CharacterManager) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	providers.ResetGlobalMock()
+
+	cfg := &config.Config{
+		CacheConfig: config.CacheConfig{
+			CleanupInterval: 5 * time.Minute,
+			DefaultTTL:      10 * time.Minute,
+		},
+		DefaultProvider: "mock",
+		Model:           "mock-model",
+		APIKey:          "mock-key",
+	}
+	mgr, err := manager.NewCharacterManagerWithoutProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	t.Cleanup(mgr.GetBot().Stop)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- New(mgr, "test").Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	})
+
+	return &testClient{t: t, in: inW, out: bufio.NewScanner(outR)}, mgr
+}
+
+// call sends a request and decodes its result into out, returning the error
+// the server answered with, if any
+func (c *testClient) call(method string, params, out interface{}) *rpcError {
+	c.t.Helper()
+	c.nextID++
+	req := map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	data, _ := json.Marshal(req)
+	if _, err := fmt.Fprintf(c.in, "%s\n", data); err != nil {
+		c.t.Fatalf("Failed to send %s: %v", method, err)
+	}
+
+	if !c.out.Scan() {
+		c.t.Fatalf("No response to %s: %v", method, c.out.Err())
+	}
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+		c.t.Fatalf("Failed to decode response to %s: %v", method, err)
+	}
+	if resp.ID != c.nextID {
+		c.t.Fatalf("Expected a response to request %d, got %d", c.nextID, resp.ID)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.Result, out); err != nil {
+			c.t.Fatalf("Failed to decode %s result: %v", method, err)
+		}
+	}
+	return nil
+}
+
+func TestInitialize(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	var result struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+		ServerInfo      struct{ Name string }      `json:"serverInfo"`
+	}
+	if err := client.call("initialize", map[string]interface{}{"protocolVersion": "2024-11-05"}, &result); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if result.ProtocolVersion != "2024-11-05" || result.ServerInfo.Name != "roleplay" {
+		t.Errorf("Unexpected initialize result: %+v", result)
+	}
+	for _, capability := range []string{"resources", "tools", "prompts"} {
+		if _, ok := result.Capabilities[capability]; !ok {
+			t.Errorf("Expected the %s capability", capability)
+		}
+	}
+
+	client.call("initialize", map[string]interface{}{"protocolVersion": "1999-01-01"}, &result)
+	if result.ProtocolVersion != supportedVersions[0] {
+		t.Errorf("Expected an unknown revision to get the newest, got %s", result.ProtocolVersion)
+	}
+
+	if err := client.call("nonsense", nil, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("Expected method not found, got %v", err)
+	}
+}
+
+func TestResourcesAndPrompts(t *testing.T) {
+	client, mgr := newTestClient(t)
+	if err := mgr.CreateCharacter(&models.Character{ID: "narrator", Name: "The Narrator", Backstory: "Tells tales"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	if err := mgr.GetScenarioRepository().SaveScenario(&models.Scenario{ID: "tavern", Name: "Tavern", Prompt: "A busy tavern."}); err != nil {
+		t.Fatalf("Failed to save scenario: %v", err)
+	}
+
+	var list struct {
+		Resources []resource `json:"resources"`
+	}
+	client.call("resources/list", nil, &list)
+	if len(list.Resources) != 2 || list.Resources[0].URI != "roleplay://characters/narrator" || list.Resources[1].URI != "roleplay://scenarios/tavern" {
+		t.Fatalf("Expected the character and scenario as resources, got %+v", list.Resources)
+	}
+
+	var read struct {
+		Contents []resourceContents `json:"contents"`
+	}
+	client.call("resources/read", map[string]string{"uri": "roleplay://characters/narrator"}, &read)
+	var char models.Character
+	if len(read.Contents) != 1 || json.Unmarshal([]byte(read.Contents[0].Text), &char) != nil || char.Backstory != "Tells tales" {
+		t.Errorf("Expected the character as JSON, got %+v", read.Contents)
+	}
+	if err := client.call("resources/read", map[string]string{"uri": "roleplay://characters/nobody"}, nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("Expected an unknown resource to be invalid, got %v", err)
+	}
+
+	var prompts struct {
+		Prompts []prompt `json:"prompts"`
+	}
+	client.call("prompts/list", nil, &prompts)
+	if len(prompts.Prompts) != 1 || prompts.Prompts[0].Name != "narrator" {
+		t.Fatalf("Expected the narrator's persona as a prompt, got %+v", prompts.Prompts)
+	}
+
+	var got struct {
+		Messages []promptMessage `json:"messages"`
+	}
+	client.call("prompts/get", map[string]string{"name": "narrator"}, &got)
+	want, _ := mgr.GetBot().CharacterSystemPrompt("narrator")
+	if len(got.Messages) != 1 || got.Messages[0].Content.Text != want {
+		t.Errorf("Expected the core system prompt, got %+v", got.Messages)
+	}
+}
+
+func TestTools(t *testing.T) {
+	client, mgr := newTestClient(t)
+	if err := mgr.CreateCharacter(&models.Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	var tools struct {
+		Tools []tool `json:"tools"`
+	}
+	client.call("tools/list", nil, &tools)
+	if len(tools.Tools) != 3 {
+		t.Fatalf("Expected three tools, got %+v", tools.Tools)
+	}
+
+	providers.SetGlobalMockResponses([]string{
+		"Once upon a time...",
+		`{"name": "Grumpy Wizard", "backstory": "Loves cats", "speech_style": "Mutters"}`,
+	})
+
+	var chat toolResult
+	client.call("tools/call", map[string]interface{}{
+		"name":      "chat_with_character",
+		"arguments": map[string]string{"character_id": "narrator", "user_id": "alice", "message": "Tell me a story"},
+	}, &chat)
+	if chat.IsError || len(chat.Content) != 1 || chat.Content[0].Text != "Once upon a time..." {
+		t.Fatalf("Unexpected chat result: %+v", chat)
+	}
+	if _, err := mgr.GetSessionRepository().LoadSession("narrator", "mcp-alice"); err != nil {
+		t.Errorf("Expected the turn to be recorded in alice's session: %v", err)
+	}
+
+	var created toolResult
+	client.call("tools/call", map[string]interface{}{
+		"name":      "create_character_from_description",
+		"arguments": map[string]string{"description": "A grumpy old wizard who loves cats", "id": "wizard"},
+	}, &created)
+	if created.IsError {
+		t.Fatalf("Failed to create character: %+v", created)
+	}
+	if wizard, err := mgr.GetOrLoadCharacter("wizard"); err != nil || wizard.Name != "Grumpy Wizard" {
+		t.Errorf("Expected the generated character to be saved, got %v", err)
+	}
+
+	var taken toolResult
+	client.call("tools/call", map[string]interface{}{
+		"name":      "create_character_from_description",
+		"arguments": map[string]string{"description": "A cheerful bard", "id": "narrator"},
+	}, &taken)
+	if !taken.IsError || !strings.Contains(taken.Content[0].Text, "already exists") {
+		t.Errorf("Expected creating over an existing character to fail, got %+v", taken)
+	}
+	if narrator, err := mgr.GetOrLoadCharacter("narrator"); err != nil || narrator.Name != "The Narrator" {
+		t.Errorf("Expected the existing character to be kept, got %+v %v", narrator, err)
+	}
+
+	var search toolResult
+	client.call("tools/call", map[string]interface{}{
+		"name":      "search_sessions",
+		"arguments": map[string]string{"query": "upon a time"},
+	}, &search)
+	if search.IsError || !strings.Contains(search.Content[0].Text, "narrator/mcp-alice") {
+		t.Errorf("Expected the reply to be found, got %+v", search)
+	}
+
+	var failed toolResult
+	client.call("tools/call", map[string]interface{}{
+		"name":      "chat_with_character",
+		"arguments": map[string]string{"character_id": "nobody", "message": "Hi"},
+	}, &failed)
+	if !failed.IsError {
+		t.Errorf("Expected chatting with an unknown character to fail, got %+v", failed)
+	}
+
+	if err := client.call("tools/call", map[string]interface{}{
+		"name":      "chat_with_character",
+		"arguments": map[string]string{"character_id": "../etc", "message": "Hi"},
+	}, nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("Expected an invalid ID to be rejected, got %v", err)
+	}
+}
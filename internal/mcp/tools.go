@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dotcommander/roleplay/internal/importer"
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+const (
+	// defaultMCPUser is the user ID of chats that name no user
+	defaultMCPUser = "mcp"
+	// defaultSearchLimit is how many matches search_sessions returns by default
+	defaultSearchLimit = 20
+)
+
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+type textContent struct {
+	Type string `json:"type"` // Always "text"
+	Text string `json:"text"`
+}
+
+// toolResult is the result of tools/call. Failures of the tool itself are
+// results with IsError set, so the calling model can see and react to them.
+type toolResult struct {
+	Content           []textContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
+}
+
+func textResult(text string, structured interface{}) *toolResult {
+	return &toolResult{Content: []textContent{{Type: "text", Text: text}}, StructuredContent: structured}
+}
+
+func errorResult(format string, args ...interface{}) *toolResult {
+	return &toolResult{Content: []textContent{{Type: "text", Text: fmt.Sprintf(format, args...)}}, IsError: true}
+}
+
+// stringProperty describes a string tool argument
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func (s *Server) listTools() interface{} {
+	return map[string]interface{}{"tools": []tool{
+		{
+			Name: "chat_with_character",
+			Description: "Send a message to a roleplay character and get its in-character reply. " +
+				"The character remembers the conversation, tracks its mood and learns about the user across calls.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"character_id": stringProperty("ID of the character to talk to"),
+					"message":      stringProperty("What to say to the character"),
+					"user_id":      stringProperty("Who is talking; defaults to \"mcp\""),
+					"session_id":   stringProperty("Conversation to continue; defaults to one running session per user"),
+					"scenario_id":  stringProperty("Optional scenario setting the scene"),
+				},
+				"required": []string{"character_id", "message"},
+			},
+		},
+		{
+			Name:        "create_character_from_description",
+			Description: "Generate a complete character (personality, backstory, speech style, quirks) from a short description and save it.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"description": stringProperty("A one-line description, e.g. \"A grumpy old wizard who loves cats\""),
+					"id":          stringProperty("Character ID; derived from the generated name when omitted"),
+				},
+				"required": []string{"description"},
+			},
+		},
+		{
+			Name:        "search_sessions",
+			Description: "Search past conversations with characters for messages containing some text, newest first.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query":        stringProperty("Text to look for, case-insensitively"),
+					"character_id": stringProperty("Only search conversations with this character"),
+					"user_id":      stringProperty("Only search this user's conversations"),
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Most matches to return; defaults to %d", defaultSearchLimit),
+						"minimum":     1,
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+
+	switch req.Name {
+	case "chat_with_character":
+		return s.chatWithCharacter(ctx, req.Arguments)
+	case "create_character_from_description":
+		return s.createCharacterFromDescription(ctx, req.Arguments)
+	case "search_sessions":
+		return s.searchSessions(req.Arguments)
+	default:
+		return nil, invalidParams("unknown tool %q", req.Name)
+	}
+}
+
+// checkIDs rejects arguments that aren't valid IDs; empty ones are allowed
+func checkIDs(fields map[string]string) error {
+	for name, value := range fields {
+		if value != "" && repository.ValidateID(value) != nil {
+			return invalidParams("invalid %s %q", name, value)
+		}
+	}
+	return nil
+}
+
+func (s *Server) chatWithCharacter(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		CharacterID string `json:"character_id"`
+		Message     string `json:"message"`
+		UserID      string `json:"user_id"`
+		SessionID   string `json:"session_id"`
+		ScenarioID  string `json:"scenario_id"`
+	}
+	if err := decodeParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.CharacterID == "" || args.Message == "" {
+		return nil, invalidParams("character_id and message are required")
+	}
+	if err := checkIDs(map[string]string{
+		"character_id": args.CharacterID,
+		"user_id":      args.UserID,
+		"session_id":   args.SessionID,
+		"scenario_id":  args.ScenarioID,
+	}); err != nil {
+		return nil, err
+	}
+	if args.UserID == "" {
+		args.UserID = defaultMCPUser
+	}
+	// Each user keeps one running session per character unless told otherwise
+	if args.SessionID == "" {
+		args.SessionID = "mcp-" + args.UserID
+	}
+
+	if _, err := s.mgr.GetOrLoadCharacter(args.CharacterID); err != nil {
+		return errorResult("%v", err), nil
+	}
+	if args.ScenarioID != "" {
+		if _, err := s.mgr.GetScenarioRepository().LoadScenario(args.ScenarioID); err != nil {
+			return errorResult("%v", err), nil
+		}
+	}
+
+	result, err := s.mgr.Chat(ctx, manager.ChatRequest{
+		CharacterID: args.CharacterID,
+		UserID:      args.UserID,
+		SessionID:   args.SessionID,
+		ScenarioID:  args.ScenarioID,
+		Message:     args.Message,
+	})
+	if err != nil {
+		return errorResult("Chat failed: %v", err), nil
+	}
+	return textResult(result.Response.Content, map[string]string{
+		"character_id": args.CharacterID,
+		"session_id":   result.Session.ID,
+		"response":     result.Response.Content,
+	}), nil
+}
+
+func (s *Server) createCharacterFromDescription(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Description string `json:"description"`
+		ID          string `json:"id"`
+	}
+	if err := decodeParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Description) == "" {
+		return nil, invalidParams("description is required")
+	}
+	if err := checkIDs(map[string]string{"id": args.ID}); err != nil {
+		return nil, err
+	}
+	if args.ID != "" && s.characterExists(args.ID) {
+		return errorResult("Character %s already exists", args.ID), nil
+	}
+
+	// Generation goes through the bot, so it shares its failover chain, rate
+	// limits and pricing with chat
+	if err := s.mgr.EnsureProviderInitialized(); err != nil {
+		return errorResult("Failed to initialize AI provider: %v", err), nil
+	}
+	character, err := importer.GenerateFromDescription(ctx, s.mgr.GetBot().MeteredProvider(), args.Description)
+	if err != nil {
+		return errorResult("Failed to generate character: %v", err), nil
+	}
+	if args.ID != "" {
+		character.ID = args.ID
+	} else {
+		character.ID = importer.GeneratedID(character.Name)
+		if s.characterExists(character.ID) {
+			return errorResult("Character %s already exists; pass an id to create %s under another", character.ID, character.Name), nil
+		}
+	}
+
+	if err := s.mgr.CreateCharacter(character); err != nil {
+		return errorResult("Failed to save character: %v", err), nil
+	}
+
+	data, err := json.MarshalIndent(character, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode character: %w", err)
+	}
+	return textResult(fmt.Sprintf("Created character %s (%s):\n%s", character.Name, character.ID, data), nil), nil
+}
+
+// characterExists reports whether id names a stored character, which
+// creating a character must not overwrite
+func (s *Server) characterExists(id string) bool {
+	_, err := s.mgr.GetOrLoadCharacter(id)
+	return err == nil
+}
+
+func (s *Server) searchSessions(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Query       string `json:"query"`
+		CharacterID string `json:"character_id"`
+		UserID      string `json:"user_id"`
+		Limit       int    `json:"limit"`
+	}
+	if err := decodeParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Query == "" {
+		return nil, invalidParams("query is required")
+	}
+	if err := checkIDs(map[string]string{"character_id": args.CharacterID, "user_id": args.UserID}); err != nil {
+		return nil, err
+	}
+	if args.Limit <= 0 {
+		args.Limit = defaultSearchLimit
+	}
+
+	matches, err := s.mgr.GetSessionRepository().SearchSessions(repository.SessionQuery{
+		Text:        args.Query,
+		CharacterID: args.CharacterID,
+		UserID:      args.UserID,
+		Limit:       args.Limit,
+	})
+	if err != nil {
+		return errorResult("Search failed: %v", err), nil
+	}
+
+	if len(matches) == 0 {
+		return textResult(fmt.Sprintf("No messages match %q.", args.Query), map[string]interface{}{"matches": matches}), nil
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "Found %d matches for %q:\n", len(matches), args.Query)
+	for _, m := range matches {
+		fmt.Fprintf(&text, "\n[%s] %s/%s, %s (%s): %s", m.Timestamp.Format("2006-01-02 15:04"), m.CharacterID, m.SessionID, m.Role, m.UserID, m.Content)
+	}
+	return textResult(text.String(), map[string]interface{}{"matches": matches}), nil
+}
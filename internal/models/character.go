@@ -35,10 +35,12 @@ const (
 
 // Memory represents different memory types
 type Memory struct {
-	Type      MemoryType `json:"type"`
-	Content   string     `json:"content"`
-	Timestamp time.Time  `json:"timestamp"`
-	Emotional float64    `json:"emotional_weight"`
+	ID          string     `json:"id,omitempty"`
+	Type        MemoryType `json:"type"`
+	Content     string     `json:"content"`
+	Timestamp   time.Time  `json:"timestamp"`
+	Emotional   float64    `json:"emotional_weight"`
+	RecallCount int        `json:"recall_count,omitempty"` // Times retrieved into a prompt
 }
 
 // Character represents a complete character profile
@@ -84,6 +86,9 @@ type Character struct {
 	Secrets          []string               `json:"secrets,omitempty"`
 	Regrets          []string               `json:"regrets,omitempty"`
 	Achievements     []string               `json:"achievements,omitempty"`
+
+	// Sampler settings for this character's replies (optional)
+	Generation *GenerationParams `json:"generation,omitempty"`
 	
 	mu           sync.RWMutex
 }
@@ -110,12 +115,17 @@ func (c *Character) RUnlock() {
 
 // NormalizePersonality ensures all personality traits are within [0, 1] range
 func NormalizePersonality(p PersonalityTraits) PersonalityTraits {
+	return ClampPersonality(p, 0, 1)
+}
+
+// ClampPersonality limits every trait to [min, max]
+func ClampPersonality(p PersonalityTraits, min, max float64) PersonalityTraits {
 	return PersonalityTraits{
-		Openness:          clamp(p.Openness, 0, 1),
-		Conscientiousness: clamp(p.Conscientiousness, 0, 1),
-		Extraversion:      clamp(p.Extraversion, 0, 1),
-		Agreeableness:     clamp(p.Agreeableness, 0, 1),
-		Neuroticism:       clamp(p.Neuroticism, 0, 1),
+		Openness:          clamp(p.Openness, min, max),
+		Conscientiousness: clamp(p.Conscientiousness, min, max),
+		Extraversion:      clamp(p.Extraversion, min, max),
+		Agreeableness:     clamp(p.Agreeableness, min, max),
+		Neuroticism:       clamp(p.Neuroticism, min, max),
 	}
 }
 
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RelationshipMetrics tracks how a character feels about one user, each in [0, 1]
+type RelationshipMetrics struct {
+	Trust       float64 `json:"trust"`
+	Affection   float64 `json:"affection"`
+	Familiarity float64 `json:"familiarity"`
+}
+
+// CharacterState is a character's evolving state toward one user. It sits on
+// top of the immutable base definition in Character, so one user's
+// conversation never colours the character's mood or memories for another.
+type CharacterState struct {
+	CharacterID      string              `json:"character_id"`
+	UserID           string              `json:"user_id"`
+	CurrentMood      EmotionalState      `json:"current_mood"`
+	Memories         []Memory            `json:"memories"`
+	Relationship     RelationshipMetrics `json:"relationship"`
+	PersonalityDrift PersonalityTraits   `json:"personality_drift"` // Offset applied to the base personality
+	InteractionCount int                 `json:"interaction_count"`
+	CreatedAt        time.Time           `json:"created_at"`
+	LastModified     time.Time           `json:"last_modified"`
+
+	mu sync.RWMutex
+}
+
+// NewCharacterState creates the initial state of char toward userID at now,
+// starting from the character's base mood
+func NewCharacterState(char *Character, userID string, now time.Time) *CharacterState {
+	return &CharacterState{
+		CharacterID: char.ID,
+		UserID:      userID,
+		CurrentMood: char.CurrentMood,
+		Memories:    []Memory{},
+		Relationship: RelationshipMetrics{
+			Trust:     0.5,
+			Affection: 0.5,
+		},
+		CreatedAt:    now,
+		LastModified: now,
+	}
+}
+
+// Lock acquires write lock
+func (s *CharacterState) Lock() {
+	s.mu.Lock()
+}
+
+// Unlock releases write lock
+func (s *CharacterState) Unlock() {
+	s.mu.Unlock()
+}
+
+// RLock acquires read lock
+func (s *CharacterState) RLock() {
+	s.mu.RLock()
+}
+
+// RUnlock releases read lock
+func (s *CharacterState) RUnlock() {
+	s.mu.RUnlock()
+}
+
+// EffectivePersonality returns base with this user's drift applied
+func (s *CharacterState) EffectivePersonality(base PersonalityTraits) PersonalityTraits {
+	return NormalizePersonality(PersonalityTraits{
+		Openness:          base.Openness + s.PersonalityDrift.Openness,
+		Conscientiousness: base.Conscientiousness + s.PersonalityDrift.Conscientiousness,
+		Extraversion:      base.Extraversion + s.PersonalityDrift.Extraversion,
+		Agreeableness:     base.Agreeableness + s.PersonalityDrift.Agreeableness,
+		Neuroticism:       base.Neuroticism + s.PersonalityDrift.Neuroticism,
+	})
+}
+
+// UpdateRelationship nudges the relationship after an exchange with the given
+// emotional tone. Familiarity grows with every interaction; trust and affection
+// move toward positive replies and away from hostile ones.
+func (s *CharacterState) UpdateRelationship(emotions EmotionalState) {
+	s.InteractionCount++
+	s.Relationship.Familiarity = 1 - math.Exp(-float64(s.InteractionCount)/20)
+
+	warmth := emotions.Joy - (emotions.Anger+emotions.Disgust)/2
+	s.Relationship.Affection = clamp(s.Relationship.Affection+0.05*warmth, 0, 1)
+
+	safety := (emotions.Joy+emotions.Surprise)/2 - (emotions.Anger+emotions.Fear+emotions.Disgust)/3
+	s.Relationship.Trust = clamp(s.Relationship.Trust+0.03*safety, 0, 1)
+}
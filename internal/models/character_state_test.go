@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCharacterStateRelationship(t *testing.T) {
+	char := &Character{ID: "test", CurrentMood: EmotionalState{Joy: 0.3}}
+	state := NewCharacterState(char, "user-1", time.Now())
+
+	if state.CurrentMood != char.CurrentMood {
+		t.Errorf("Expected state to start from the base mood, got %+v", state.CurrentMood)
+	}
+
+	start := state.Relationship
+	state.UpdateRelationship(EmotionalState{Joy: 1})
+	if state.Relationship.Affection <= start.Affection || state.Relationship.Trust <= start.Trust {
+		t.Errorf("Expected a joyful exchange to warm the relationship, got %+v", state.Relationship)
+	}
+	if state.Relationship.Familiarity <= 0 || state.InteractionCount != 1 {
+		t.Errorf("Expected familiarity to grow, got %+v", state.Relationship)
+	}
+
+	warm := state.Relationship
+	state.UpdateRelationship(EmotionalState{Anger: 1, Disgust: 1})
+	if state.Relationship.Affection >= warm.Affection || state.Relationship.Trust >= warm.Trust {
+		t.Errorf("Expected a hostile exchange to cool the relationship, got %+v", state.Relationship)
+	}
+}
+
+func TestEffectivePersonality(t *testing.T) {
+	state := &CharacterState{PersonalityDrift: PersonalityTraits{Openness: 0.2, Neuroticism: -0.8}}
+	base := PersonalityTraits{Openness: 0.9, Neuroticism: 0.5}
+
+	p := state.EffectivePersonality(base)
+	if p.Openness != 1 {
+		t.Errorf("Expected openness clamped to 1, got %v", p.Openness)
+	}
+	if p.Neuroticism != 0 {
+		t.Errorf("Expected neuroticism clamped to 0, got %v", p.Neuroticism)
+	}
+}
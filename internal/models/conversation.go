@@ -11,16 +11,32 @@ type Message struct {
 
 // ConversationContext holds the current conversation state
 type ConversationContext struct {
-	RecentMessages []Message
-	SessionID      string
-	StartTime      time.Time
+	RecentMessages  []Message // Turns not yet summarised, oldest first
+	Summary         string    // Rolling summary of earlier turns
+	SummarizedCount int       // Turns folded into Summary since the session started
+	SessionID       string
+	StartTime       time.Time
+
+	// DropOverflow drops turns that don't fit the context window instead of
+	// summarising them, for histories the caller keeps and resends
+	DropOverflow bool
 }
 
+// CachePolicy controls how a request uses the response cache
+type CachePolicy string
+
+const (
+	CacheUse     CachePolicy = ""        // Serve a cached reply if there is one, and cache new replies
+	CacheRefresh CachePolicy = "refresh" // Always ask the provider, and cache its reply
+	CacheBypass  CachePolicy = "bypass"  // Neither read nor write the cache
+)
+
 // ConversationRequest represents a user request to the character bot
 type ConversationRequest struct {
 	CharacterID string
 	UserID      string
 	Message     string
 	Context     ConversationContext
-	ScenarioID  string // Optional scenario context
+	ScenarioID  string      // Optional scenario context
+	CachePolicy CachePolicy // Zero value uses the cache
 }
@@ -0,0 +1,65 @@
+package models
+
+import "fmt"
+
+// GenerationParams holds optional sampler settings for a reply.
+// Nil fields are unset and fall back to the next layer: scenario, then
+// character, then config defaults, then the provider's built-in values.
+type GenerationParams struct {
+	Temperature      *float64 `json:"temperature,omitempty" mapstructure:"temperature"`
+	TopP             *float64 `json:"top_p,omitempty" mapstructure:"top_p"`
+	MaxTokens        *int     `json:"max_tokens,omitempty" mapstructure:"max_tokens"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty" mapstructure:"presence_penalty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty" mapstructure:"frequency_penalty"`
+	Stop             []string `json:"stop,omitempty" mapstructure:"stop"`
+	Seed             *int     `json:"seed,omitempty" mapstructure:"seed"`
+}
+
+// Merge returns p with any unset fields taken from base
+func (p GenerationParams) Merge(base GenerationParams) GenerationParams {
+	if p.Temperature == nil {
+		p.Temperature = base.Temperature
+	}
+	if p.TopP == nil {
+		p.TopP = base.TopP
+	}
+	if p.MaxTokens == nil {
+		p.MaxTokens = base.MaxTokens
+	}
+	if p.PresencePenalty == nil {
+		p.PresencePenalty = base.PresencePenalty
+	}
+	if p.FrequencyPenalty == nil {
+		p.FrequencyPenalty = base.FrequencyPenalty
+	}
+	if p.Stop == nil {
+		p.Stop = base.Stop
+	}
+	if p.Seed == nil {
+		p.Seed = base.Seed
+	}
+	return p
+}
+
+// Validate checks that every set field is within the range providers accept
+func (p GenerationParams) Validate() error {
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2, got %v", *p.Temperature)
+	}
+	if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+		return fmt.Errorf("top_p must be between 0 and 1, got %v", *p.TopP)
+	}
+	if p.MaxTokens != nil && *p.MaxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be positive, got %d", *p.MaxTokens)
+	}
+	if p.PresencePenalty != nil && (*p.PresencePenalty < -2 || *p.PresencePenalty > 2) {
+		return fmt.Errorf("presence_penalty must be between -2 and 2, got %v", *p.PresencePenalty)
+	}
+	if p.FrequencyPenalty != nil && (*p.FrequencyPenalty < -2 || *p.FrequencyPenalty > 2) {
+		return fmt.Errorf("frequency_penalty must be between -2 and 2, got %v", *p.FrequencyPenalty)
+	}
+	if len(p.Stop) > 4 {
+		return fmt.Errorf("at most 4 stop sequences are supported, got %d", len(p.Stop))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestGenerationParamsMerge(t *testing.T) {
+	temp := 0.2
+	baseTemp := 0.9
+	maxTokens := 120
+
+	scenario := GenerationParams{Temperature: &temp}
+	base := GenerationParams{Temperature: &baseTemp, MaxTokens: &maxTokens, Stop: []string{"\n\n"}}
+
+	merged := scenario.Merge(base)
+
+	if *merged.Temperature != 0.2 {
+		t.Errorf("Expected more specific temperature to win, got %v", *merged.Temperature)
+	}
+	if merged.MaxTokens == nil || *merged.MaxTokens != 120 {
+		t.Error("Expected unset max tokens to come from base")
+	}
+	if len(merged.Stop) != 1 {
+		t.Error("Expected stop sequences to come from base")
+	}
+	if merged.Seed != nil {
+		t.Error("Expected seed to stay unset")
+	}
+}
+
+func TestGenerationParamsValidate(t *testing.T) {
+	tooHot := 2.5
+	badTopP := 1.5
+	zero := 0
+	ok := 1.0
+
+	tests := []struct {
+		name    string
+		params  GenerationParams
+		wantErr bool
+	}{
+		{"empty", GenerationParams{}, false},
+		{"valid", GenerationParams{Temperature: &ok, TopP: &ok}, false},
+		{"temperature out of range", GenerationParams{Temperature: &tooHot}, true},
+		{"top_p out of range", GenerationParams{TopP: &badTopP}, true},
+		{"zero max tokens", GenerationParams{MaxTokens: &zero}, true},
+		{"too many stop sequences", GenerationParams{Stop: []string{"a", "b", "c", "d", "e"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
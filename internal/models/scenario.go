@@ -15,6 +15,9 @@ type Scenario struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	LastUsed    time.Time `json:"last_used"`
+
+	// Sampler settings that override the character's while this scenario is active
+	Generation *GenerationParams `json:"generation,omitempty"`
 }
 
 // ScenarioRequest represents a request that includes scenario context
@@ -0,0 +1,175 @@
+// Package pricing converts token usage into US dollars.
+//
+// Rates are per million tokens and looked up by provider profile and model
+// name prefix. A built-in table covers common hosted models; entries from the
+// configuration take precedence over it.
+package pricing
+
+import (
+	"strings"
+)
+
+// Price holds the USD rates of a model per million tokens
+type Price struct {
+	Input       float64 `mapstructure:"input" json:"input"`
+	CachedInput float64 `mapstructure:"cached_input" json:"cached_input"` // Prompt tokens read from the provider cache; 0 bills them as input
+	CacheWrite  float64 `mapstructure:"cache_write" json:"cache_write"`   // Prompt tokens written to the provider cache; 0 bills them as input
+	Output      float64 `mapstructure:"output" json:"output"`
+}
+
+// Entry prices the models of a provider whose names start with Model.
+// An empty Provider matches every provider and an empty Model every model.
+type Entry struct {
+	Provider string `mapstructure:"provider" json:"provider,omitempty"`
+	Model    string `mapstructure:"model" json:"model,omitempty"`
+	Price    `mapstructure:",squash"`
+}
+
+// Usage is the token consumption of one request. Prompt includes the cached
+// and cache-written tokens.
+type Usage struct {
+	Prompt        int
+	CachedPrompt  int
+	CacheCreation int
+	Completion    int
+}
+
+const perMillion = 1_000_000
+
+// Cost returns the USD billed for usage
+func (p Price) Cost(u Usage) float64 {
+	uncached := u.Prompt - u.CachedPrompt - u.CacheCreation
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*p.Input +
+		float64(u.CachedPrompt)*p.cachedInput() +
+		float64(u.CacheCreation)*p.cacheWrite() +
+		float64(u.Completion)*p.Output) / perMillion
+}
+
+// Saved returns the USD the provider cache saved on usage compared with
+// billing every cached prompt token as input
+func (p Price) Saved(u Usage) float64 {
+	return float64(u.CachedPrompt) * (p.Input - p.cachedInput()) / perMillion
+}
+
+func (p Price) cachedInput() float64 {
+	if p.CachedInput == 0 {
+		return p.Input
+	}
+	return p.CachedInput
+}
+
+func (p Price) cacheWrite() float64 {
+	if p.CacheWrite == 0 {
+		return p.Input
+	}
+	return p.CacheWrite
+}
+
+// Defaults are list prices of common models in USD per million tokens
+var Defaults = []Entry{
+	// Local and test providers are free whatever the model
+	{Provider: "ollama"},
+	{Provider: "lmstudio"},
+	{Provider: "lm_studio"},
+	{Provider: "local"},
+	{Provider: "mock"},
+
+	{Model: "gpt-4o", Price: Price{Input: 2.50, CachedInput: 1.25, Output: 10}},
+	{Model: "gpt-4o-mini", Price: Price{Input: 0.15, CachedInput: 0.075, Output: 0.60}},
+	{Model: "gpt-4.1", Price: Price{Input: 2, CachedInput: 0.50, Output: 8}},
+	{Model: "gpt-4.1-mini", Price: Price{Input: 0.40, CachedInput: 0.10, Output: 1.60}},
+	{Model: "gpt-4.1-nano", Price: Price{Input: 0.10, CachedInput: 0.025, Output: 0.40}},
+	{Model: "gpt-4-turbo", Price: Price{Input: 10, Output: 30}},
+	{Model: "gpt-4", Price: Price{Input: 30, Output: 60}},
+	{Model: "gpt-3.5-turbo", Price: Price{Input: 0.50, Output: 1.50}},
+	{Model: "o1", Price: Price{Input: 15, CachedInput: 7.50, Output: 60}},
+	{Model: "o1-mini", Price: Price{Input: 1.10, CachedInput: 0.55, Output: 4.40}},
+	{Model: "o3", Price: Price{Input: 2, CachedInput: 0.50, Output: 8}},
+	{Model: "o3-mini", Price: Price{Input: 1.10, CachedInput: 0.55, Output: 4.40}},
+	{Model: "o4-mini", Price: Price{Input: 1.10, CachedInput: 0.275, Output: 4.40}},
+
+	{Model: "claude-3-haiku", Price: Price{Input: 0.25, CachedInput: 0.03, CacheWrite: 0.30, Output: 1.25}},
+	{Model: "claude-3-5-haiku", Price: Price{Input: 0.80, CachedInput: 0.08, CacheWrite: 1, Output: 4}},
+	{Model: "claude-3-5-sonnet", Price: Price{Input: 3, CachedInput: 0.30, CacheWrite: 3.75, Output: 15}},
+	{Model: "claude-3-7-sonnet", Price: Price{Input: 3, CachedInput: 0.30, CacheWrite: 3.75, Output: 15}},
+	{Model: "claude-sonnet-4", Price: Price{Input: 3, CachedInput: 0.30, CacheWrite: 3.75, Output: 15}},
+	{Model: "claude-3-opus", Price: Price{Input: 15, CachedInput: 1.50, CacheWrite: 18.75, Output: 75}},
+	{Model: "claude-opus-4", Price: Price{Input: 15, CachedInput: 1.50, CacheWrite: 18.75, Output: 75}},
+
+	{Model: "gemini-1.5-flash", Price: Price{Input: 0.075, CachedInput: 0.01875, Output: 0.30}},
+	{Model: "gemini-1.5-pro", Price: Price{Input: 1.25, CachedInput: 0.3125, Output: 5}},
+	{Model: "gemini-2.0-flash", Price: Price{Input: 0.10, CachedInput: 0.025, Output: 0.40}},
+	{Model: "gemini-2.5-flash", Price: Price{Input: 0.30, CachedInput: 0.075, Output: 2.50}},
+	{Model: "gemini-2.5-pro", Price: Price{Input: 1.25, CachedInput: 0.31, Output: 10}},
+
+	{Model: "deepseek-chat", Price: Price{Input: 0.27, CachedInput: 0.07, Output: 1.10}},
+	{Model: "deepseek-reasoner", Price: Price{Input: 0.55, CachedInput: 0.14, Output: 2.19}},
+
+	{Provider: "groq", Model: "llama-3.1-8b", Price: Price{Input: 0.05, Output: 0.08}},
+	{Provider: "groq", Model: "llama-3.3-70b", Price: Price{Input: 0.59, Output: 0.79}},
+}
+
+// Registry looks up prices, preferring configured entries over the defaults
+type Registry struct {
+	overrides []Entry
+	defaults  []Entry
+}
+
+// NewRegistry returns a registry in which overrides take precedence over Defaults
+func NewRegistry(overrides []Entry) *Registry {
+	return &Registry{overrides: overrides, defaults: Defaults}
+}
+
+// Lookup returns the price of model served by provider. Within each table the
+// entry naming the provider wins over a wildcard, then the longest model
+// prefix. Routing prefixes such as "openai/" are ignored when matching.
+func (r *Registry) Lookup(provider, model string) (Price, bool) {
+	if price, ok := match(r.overrides, provider, model); ok {
+		return price, true
+	}
+	return match(r.defaults, provider, model)
+}
+
+// Cost returns the USD billed for usage, and false when the model is unpriced
+func (r *Registry) Cost(provider, model string, u Usage) (float64, bool) {
+	price, ok := r.Lookup(provider, model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(u), true
+}
+
+// match returns the best entry of table for provider and model
+func match(table []Entry, provider, model string) (Price, bool) {
+	provider = strings.ToLower(provider)
+	model = strings.ToLower(model)
+	bare := model
+	if i := strings.LastIndex(bare, "/"); i >= 0 {
+		bare = bare[i+1:]
+	}
+
+	best, bestScore := Price{}, -1
+	for _, e := range table {
+		p := strings.ToLower(e.Provider)
+		if p != "" && p != provider {
+			continue
+		}
+		m := strings.ToLower(e.Model)
+		if !strings.HasPrefix(model, m) && !strings.HasPrefix(bare, m) {
+			continue
+		}
+
+		// Provider-specific entries outrank any wildcard
+		score := len(m)
+		if p != "" {
+			score += 1 << 16
+		}
+		if score > bestScore {
+			best, bestScore = e.Price, score
+		}
+	}
+	return best, bestScore >= 0
+}
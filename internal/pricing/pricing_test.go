@@ -0,0 +1,67 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
+
+func TestLookup(t *testing.T) {
+	registry := NewRegistry([]Entry{
+		{Model: "gpt-4o-mini", Price: Price{Input: 1, Output: 2}},
+		{Provider: "openrouter", Model: "mistral", Price: Price{Input: 3, Output: 4}},
+	})
+
+	tests := []struct {
+		provider, model string
+		want            Price
+		found           bool
+	}{
+		// Configured entries win over the built-in table
+		{"openai", "gpt-4o-mini", Price{Input: 1, Output: 2}, true},
+		{"openai", "gpt-4o-2024-08-06", Price{Input: 2.50, CachedInput: 1.25, Output: 10}, true},
+		// The longest model prefix wins
+		{"openai", "gpt-4.1-nano", Price{Input: 0.10, CachedInput: 0.025, Output: 0.40}, true},
+		// Routing prefixes are ignored
+		{"openrouter", "anthropic/claude-3-5-sonnet", Price{Input: 3, CachedInput: 0.30, CacheWrite: 3.75, Output: 15}, true},
+		{"openrouter", "mistralai/mistral-large", Price{Input: 3, Output: 4}, true},
+		// Local providers are free whatever the model
+		{"ollama", "llama3", Price{}, true},
+		{"ollama", "gpt-4o", Price{}, true},
+		{"groq", "llama-3.3-70b-versatile", Price{Input: 0.59, Output: 0.79}, true},
+		{"openai", "some-unknown-model", Price{}, false},
+	}
+
+	for _, tt := range tests {
+		got, found := registry.Lookup(tt.provider, tt.model)
+		if found != tt.found || got != tt.want {
+			t.Errorf("Lookup(%q, %q) = %+v, %v; want %+v, %v", tt.provider, tt.model, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestPriceCost(t *testing.T) {
+	price := Price{Input: 3, CachedInput: 0.30, CacheWrite: 3.75, Output: 15}
+	usage := Usage{Prompt: 1_000_000, CachedPrompt: 500_000, CacheCreation: 100_000, Completion: 200_000}
+
+	// 400k uncached + 500k cached + 100k written + 200k output
+	want := 0.4*3 + 0.5*0.30 + 0.1*3.75 + 0.2*15
+	if got := price.Cost(usage); !almostEqual(got, want) {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+	if got, want := price.Saved(usage), 0.5*(3-0.30); !almostEqual(got, want) {
+		t.Errorf("Saved = %v, want %v", got, want)
+	}
+
+	// Without cache rates, cached tokens are billed as input and save nothing
+	flat := Price{Input: 2, Output: 4}
+	if got, want := flat.Cost(usage), 1.0*2+0.2*4; !almostEqual(got, want) {
+		t.Errorf("Cost without cache rates = %v, want %v", got, want)
+	}
+	if got := flat.Saved(usage); got != 0 {
+		t.Errorf("Saved without cache rates = %v, want 0", got)
+	}
+}
@@ -0,0 +1,436 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/dotcommander/roleplay/internal/cache"
+)
+
+const (
+	// DefaultAnthropicBaseURL is the public Anthropic API endpoint
+	DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	// anthropicVersion is the Messages API version header value
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxBreakpoints is the maximum number of cache_control markers per request
+	anthropicMaxBreakpoints = 4
+)
+
+// cachedLayers are the prompt layers that receive an explicit cache_control marker.
+// All of them precede the emotional state and user memory layers, which are
+// rebuilt every turn, so each marked prefix can be read back on the next turn.
+var cachedLayers = map[cache.CacheLayer]bool{
+	cache.SystemAdminLayer:     true,
+	cache.ScenarioContextLayer: true,
+	cache.CorePersonalityLayer: true,
+	cache.LearnedBehaviorLayer: true,
+}
+
+// AnthropicProvider implements the AIProvider interface using the native Anthropic Messages API
+type AnthropicProvider struct {
+	client  *http.Client
+	apiKey  string
+	model   string
+	baseURL string
+	retry   RetryPolicy
+}
+
+// NewAnthropicProvider creates a new Anthropic provider instance
+func NewAnthropicProvider(apiKey, model string) *AnthropicProvider {
+	return NewAnthropicProviderWithBaseURL(apiKey, model, "")
+}
+
+// NewAnthropicProviderWithBaseURL creates a new Anthropic provider with a custom base URL
+func NewAnthropicProviderWithBaseURL(apiKey, model, baseURL string) *AnthropicProvider {
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+
+	client := &http.Client{}
+	if os.Getenv("DEBUG_HTTP") == "true" {
+		fmt.Printf("🔧 Debug: Anthropic provider configured with base URL: %s\n", baseURL)
+		client.Transport = &debugTransport{RoundTripper: http.DefaultTransport}
+	}
+
+	return &AnthropicProvider{
+		client:  client,
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		retry:   DefaultRetryPolicy(),
+	}
+}
+
+// anthropicCacheControl marks a content block as a cache breakpoint
+type anthropicCacheControl struct {
+	Type string `json:"type"`
+}
+
+// anthropicTextBlock is a text content block used for system prompts and messages
+type anthropicTextBlock struct {
+	Type         string                 `json:"type"`
+	Text         string                 `json:"text"`
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// anthropicMessage is a single conversation turn
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// anthropicRequest is the Messages API request body
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	System      []anthropicTextBlock `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	Temperature float64              `json:"temperature"`
+	TopP        *float64             `json:"top_p,omitempty"`
+	Stop        []string             `json:"stop_sequences,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+	Metadata    *anthropicMetadata   `json:"metadata,omitempty"`
+}
+
+// anthropicMetadata carries the end-user identifier
+type anthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// anthropicUsage reports token consumption including prompt cache activity
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// anthropicResponse is the Messages API response body
+type anthropicResponse struct {
+	ID      string               `json:"id"`
+	Type    string               `json:"type"`
+	Role    string               `json:"role"`
+	Model   string               `json:"model"`
+	Content []anthropicTextBlock `json:"content"`
+	Usage   anthropicUsage       `json:"usage"`
+}
+
+// anthropicError is the error envelope returned by the Messages API
+type anthropicError struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicStreamEvent covers the subset of streaming events we consume
+type anthropicStreamEvent struct {
+	Type    string             `json:"type"`
+	Message *anthropicResponse `json:"message,omitempty"`
+	Delta   struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
+}
+
+// SendRequest sends a request to the Anthropic Messages API
+func (a *AnthropicProvider) SendRequest(ctx context.Context, req *PromptRequest) (*AIResponse, error) {
+	apiReq, markedLayers := a.buildRequest(req)
+
+	httpResp, err := a.doWithRetry(ctx, apiReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp anthropicResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Debug response if enabled
+	DebugResponse(resp)
+
+	var content strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = a.model
+	}
+
+	return &AIResponse{
+		Content:      content.String(),
+		TokensUsed:   a.tokenUsage(resp.Usage),
+		CacheMetrics: a.cacheMetrics(resp.Usage, markedLayers),
+		Model:        model,
+	}, nil
+}
+
+// SendStreamRequest sends a streaming request to the Anthropic Messages API
+func (a *AnthropicProvider) SendStreamRequest(ctx context.Context, req *PromptRequest, out chan<- PartialAIResponse) error {
+	defer close(out)
+
+	apiReq, _ := a.buildRequest(req)
+	apiReq.Stream = true
+
+	// Only opening the stream is retried; a reply that has started is not replayed
+	httpResp, err := a.doWithRetry(ctx, apiReq)
+	if err != nil {
+		return fmt.Errorf("failed to create stream: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return fmt.Errorf("stream error: %w", err)
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				select {
+				case out <- PartialAIResponse{Content: event.Delta.Text}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		case "message_stop":
+			out <- PartialAIResponse{Done: true}
+			return nil
+		case "error":
+			return fmt.Errorf("stream error: %s", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream error: %w", err)
+	}
+
+	// Stream ended without an explicit message_stop
+	out <- PartialAIResponse{Done: true}
+	return nil
+}
+
+// buildRequest converts a PromptRequest into a Messages API request.
+// Each cacheable prompt layer becomes its own system block so Anthropic can cache
+// the stable prefix (system, scenario, character, user memory) independently.
+func (a *AnthropicProvider) buildRequest(req *PromptRequest) (*anthropicRequest, []cache.CacheLayer) {
+	apiReq := &anthropicRequest{
+		Model:       a.model,
+		MaxTokens:   2000,
+		Temperature: 0.7,
+	}
+
+	// Use more tokens for user profile updates which can be lengthy JSON
+	if req.CharacterID == "system-user-profiler" {
+		apiReq.MaxTokens = 4000
+	}
+
+	// Penalties and seed have no Messages API equivalent and are ignored
+	gen := req.Generation
+	if gen.Temperature != nil {
+		// Anthropic caps temperature at 1
+		apiReq.Temperature = math.Min(*gen.Temperature, 1)
+	}
+	if gen.MaxTokens != nil {
+		apiReq.MaxTokens = *gen.MaxTokens
+	}
+	apiReq.TopP = gen.TopP
+	apiReq.Stop = gen.Stop
+
+	if req.UserID != "" {
+		apiReq.Metadata = &anthropicMetadata{UserID: req.UserID}
+	}
+
+	system, markedLayers := a.buildSystemBlocks(req)
+	apiReq.System = system
+	apiReq.Messages = a.buildMessages(req)
+
+	return apiReq, markedLayers
+}
+
+// buildSystemBlocks maps cache breakpoints to system blocks with cache_control markers
+func (a *AnthropicProvider) buildSystemBlocks(req *PromptRequest) ([]anthropicTextBlock, []cache.CacheLayer) {
+	var blocks []anthropicTextBlock
+	var markedLayers []cache.CacheLayer
+
+	// The conversation layer carries the rolling summary of turns that no
+	// longer fit; it comes last and is left unmarked, while the turns
+	// themselves are sent as messages
+	for _, bp := range req.CacheBreakpoints {
+		content := strings.TrimPrefix(bp.Content, "<!-- cached:true -->\n")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		block := anthropicTextBlock{Type: "text", Text: content}
+		if cachedLayers[bp.Layer] && len(markedLayers) < anthropicMaxBreakpoints {
+			block.CacheControl = &anthropicCacheControl{Type: "ephemeral"}
+			markedLayers = append(markedLayers, bp.Layer)
+		}
+		blocks = append(blocks, block)
+	}
+
+	// Fallback: use the flat system prompt when no breakpoints were supplied
+	if len(blocks) == 0 && req.SystemPrompt != "" {
+		blocks = append(blocks, anthropicTextBlock{Type: "text", Text: req.SystemPrompt})
+	}
+
+	return blocks, markedLayers
+}
+
+// buildMessages converts conversation history and the current message into
+// alternating user/assistant turns as required by the Messages API
+func (a *AnthropicProvider) buildMessages(req *PromptRequest) []anthropicMessage {
+	var messages []anthropicMessage
+
+	appendTurn := func(role, content string) {
+		if content == "" {
+			return
+		}
+		// Merge consecutive turns from the same role
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content += "\n\n" + content
+			return
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: content})
+	}
+
+	for _, msg := range req.Context.RecentMessages {
+		role := "user"
+		if msg.Role == "assistant" || msg.Role == "character" {
+			role = "assistant"
+		}
+		// The first turn must come from the user
+		if len(messages) == 0 && role == "assistant" {
+			continue
+		}
+		appendTurn(role, msg.Content)
+	}
+
+	appendTurn("user", req.Message)
+
+	return messages
+}
+
+// do sends the request and returns the raw HTTP response on success
+func (a *AnthropicProvider) do(ctx context.Context, apiReq *anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", a.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	if os.Getenv("DEBUG_HTTP") == "true" {
+		fmt.Printf("🔧 Debug: Sending messages request with model: %s\n", a.model)
+	}
+
+	httpResp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(httpResp.Body, 64*1024))
+		retryAfter := parseRetryAfter(httpResp.Header.Get("Retry-After"))
+
+		var apiErr anthropicError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			err := fmt.Errorf("status %d: %s: %s", httpResp.StatusCode, apiErr.Error.Type, apiErr.Error.Message)
+			return nil, classifyHTTPError(a.Name(), httpResp.StatusCode, apiErr.Error.Type, apiErr.Error.Message, retryAfter, err)
+		}
+		message := strings.TrimSpace(string(data))
+		err := fmt.Errorf("status %d: %s", httpResp.StatusCode, message)
+		return nil, classifyHTTPError(a.Name(), httpResp.StatusCode, "", message, retryAfter, err)
+	}
+
+	return httpResp, nil
+}
+
+// doWithRetry sends the request under the provider's retry policy
+func (a *AnthropicProvider) doWithRetry(ctx context.Context, apiReq *anthropicRequest) (*http.Response, error) {
+	var httpResp *http.Response
+	err := withRetry(ctx, a.retry, a.Name(), func(ctx context.Context) error {
+		var err error
+		httpResp, err = a.do(ctx, apiReq)
+		return err
+	})
+	return httpResp, err
+}
+
+// SetRetryPolicy replaces the retry policy; zero fields keep their defaults
+func (a *AnthropicProvider) SetRetryPolicy(policy RetryPolicy) {
+	a.retry = policy.withDefaults()
+}
+
+// tokenUsage converts Anthropic usage into TokenUsage.
+// Anthropic reports uncached, cache-written and cache-read input tokens separately;
+// Prompt is their sum so it matches the OpenAI notion of total prompt tokens.
+func (a *AnthropicProvider) tokenUsage(usage anthropicUsage) TokenUsage {
+	prompt := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+
+	// Debug cached tokens if enabled
+	DebugCachedTokens(prompt, usage.CacheReadInputTokens)
+
+	return TokenUsage{
+		Prompt:        prompt,
+		Completion:    usage.OutputTokens,
+		CachedPrompt:  usage.CacheReadInputTokens,
+		CacheCreation: usage.CacheCreationInputTokens,
+		Total:         prompt + usage.OutputTokens,
+	}
+}
+
+// cacheMetrics derives cache metrics from usage and the layers that carried markers
+func (a *AnthropicProvider) cacheMetrics(usage anthropicUsage, markedLayers []cache.CacheLayer) cache.CacheMetrics {
+	metrics := cache.CacheMetrics{
+		Hit: usage.CacheReadInputTokens > 0,
+	}
+
+	if metrics.Hit {
+		metrics.Layers = markedLayers
+		// The discount on cache reads is priced by the caller
+		metrics.SavedTokens = usage.CacheReadInputTokens
+	}
+
+	return metrics
+}
+
+// SupportsBreakpoints indicates that Anthropic honours explicit cache_control markers
+func (a *AnthropicProvider) SupportsBreakpoints() bool { return true }
+
+// MaxBreakpoints returns the maximum number of cache_control markers per request
+func (a *AnthropicProvider) MaxBreakpoints() int { return anthropicMaxBreakpoints }
+
+// Name returns the provider name
+func (a *AnthropicProvider) Name() string { return "anthropic" }
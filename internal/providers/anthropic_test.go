@@ -0,0 +1,281 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dotcommander/roleplay/internal/cache"
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+func testAnthropicBreakpoints() []cache.CacheBreakpoint {
+	return []cache.CacheBreakpoint{
+		{Layer: cache.SystemAdminLayer, Content: "System instructions"},
+		{Layer: cache.ScenarioContextLayer, Content: "Scenario prompt"},
+		{Layer: cache.CorePersonalityLayer, Content: "<!-- cached:true -->\nCore personality"},
+		{Layer: cache.LearnedBehaviorLayer, Content: "Learned patterns"},
+		{Layer: cache.EmotionalStateLayer, Content: "Emotional state"},
+		{Layer: cache.UserMemoryLayer, Content: "User memory"},
+		{Layer: cache.ConversationLayer, Content: "[CONVERSATION SO FAR]\nAlice arrived in town."},
+	}
+}
+
+func TestAnthropicProvider(t *testing.T) {
+	provider := NewAnthropicProvider("test-key", "claude-3-haiku-20240307")
+
+	if provider.Name() != "anthropic" {
+		t.Errorf("Expected name 'anthropic', got %s", provider.Name())
+	}
+	if !provider.SupportsBreakpoints() {
+		t.Error("Expected Anthropic to support explicit breakpoints")
+	}
+	if provider.MaxBreakpoints() != 4 {
+		t.Errorf("Expected 4 breakpoints, got %d", provider.MaxBreakpoints())
+	}
+	if provider.baseURL != DefaultAnthropicBaseURL {
+		t.Errorf("Expected default base URL, got %s", provider.baseURL)
+	}
+}
+
+func TestAnthropicProviderRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected path /v1/messages, got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Error("Missing or incorrect x-api-key header")
+		}
+		if r.Header.Get("anthropic-version") == "" {
+			t.Error("Missing anthropic-version header")
+		}
+
+		var reqBody anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+			return
+		}
+
+		if reqBody.Model != "claude-3-haiku-20240307" {
+			t.Errorf("Expected model claude-3-haiku-20240307, got %s", reqBody.Model)
+		}
+
+		// Every layer is its own block, the conversation summary last
+		if len(reqBody.System) != 7 {
+			t.Errorf("Expected 7 system blocks, got %d", len(reqBody.System))
+			return
+		}
+
+		marked := 0
+		for _, block := range reqBody.System {
+			if strings.Contains(block.Text, "<!-- cached:true -->") {
+				t.Error("Cache tracking comment leaked into system block")
+			}
+			if block.CacheControl != nil {
+				marked++
+				if block.CacheControl.Type != "ephemeral" {
+					t.Errorf("Expected ephemeral cache_control, got %s", block.CacheControl.Type)
+				}
+			}
+		}
+		if marked != 4 {
+			t.Errorf("Expected 4 cache_control markers, got %d", marked)
+		}
+		// The layers rebuilt every turn are never marked
+		for _, idx := range []int{4, 5, 6} {
+			if reqBody.System[idx].CacheControl != nil {
+				t.Errorf("Did not expect cache_control on block %q", reqBody.System[idx].Text)
+			}
+		}
+
+		// History + current message, with leading assistant turn dropped
+		if len(reqBody.Messages) != 3 {
+			t.Errorf("Expected 3 messages, got %d", len(reqBody.Messages))
+			return
+		}
+		if reqBody.Messages[0].Role != "user" || reqBody.Messages[2].Content != "Hello" {
+			t.Errorf("Unexpected messages: %+v", reqBody.Messages)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"id": "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"content": [{"type": "text", "text": "Test response"}],
+			"usage": {
+				"input_tokens": 20,
+				"output_tokens": 50,
+				"cache_creation_input_tokens": 100,
+				"cache_read_input_tokens": 1500
+			}
+		}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProviderWithBaseURL("test-key", "claude-3-haiku-20240307", server.URL+"/v1")
+
+	req := &PromptRequest{
+		CharacterID: "test-char",
+		UserID:      "test-user",
+		Message:     "Hello",
+		Context: models.ConversationContext{
+			RecentMessages: []models.Message{
+				{Role: "assistant", Content: "Greeting before the user spoke"},
+				{Role: "user", Content: "Previous message"},
+				{Role: "character", Content: "Previous reply"},
+			},
+		},
+		SystemPrompt:     "flattened prompt that should not be used",
+		CacheBreakpoints: testAnthropicBreakpoints(),
+	}
+
+	resp, err := provider.SendRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+
+	if resp.Content != "Test response" {
+		t.Errorf("Expected 'Test response', got %s", resp.Content)
+	}
+	if resp.TokensUsed.Prompt != 1620 {
+		t.Errorf("Expected 1620 prompt tokens, got %d", resp.TokensUsed.Prompt)
+	}
+	if resp.TokensUsed.CachedPrompt != 1500 {
+		t.Errorf("Expected 1500 cached tokens, got %d", resp.TokensUsed.CachedPrompt)
+	}
+	if resp.TokensUsed.CacheCreation != 100 {
+		t.Errorf("Expected 100 cache creation tokens, got %d", resp.TokensUsed.CacheCreation)
+	}
+	if resp.TokensUsed.Total != 1670 {
+		t.Errorf("Expected 1670 total tokens, got %d", resp.TokensUsed.Total)
+	}
+	if !resp.CacheMetrics.Hit {
+		t.Error("Expected cache hit")
+	}
+	if len(resp.CacheMetrics.Layers) != 4 {
+		t.Errorf("Expected 4 cached layers, got %v", resp.CacheMetrics.Layers)
+	}
+	if resp.CacheMetrics.SavedTokens != 1500 {
+		t.Errorf("Expected 1500 saved tokens, got %d", resp.CacheMetrics.SavedTokens)
+	}
+}
+
+func TestAnthropicProviderSystemPromptFallback(t *testing.T) {
+	provider := NewAnthropicProvider("test-key", "claude-3-haiku-20240307")
+
+	apiReq, marked := provider.buildRequest(&PromptRequest{
+		CharacterID:  "system-user-profiler",
+		Message:      "Extract the profile",
+		SystemPrompt: "You are an analytical AI.",
+	})
+
+	if len(apiReq.System) != 1 || apiReq.System[0].Text != "You are an analytical AI." {
+		t.Errorf("Expected flat system prompt block, got %+v", apiReq.System)
+	}
+	if len(marked) != 0 {
+		t.Errorf("Expected no cache markers, got %v", marked)
+	}
+	if apiReq.MaxTokens != 4000 {
+		t.Errorf("Expected 4000 max tokens for profiler, got %d", apiReq.MaxTokens)
+	}
+}
+
+func TestAnthropicProviderSendsConversationSummary(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Welcome back."}],"usage":{"input_tokens":20,"output_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProviderWithBaseURL("test-key", "claude-3-haiku-20240307", server.URL+"/v1")
+	_, err := provider.SendRequest(context.Background(), &PromptRequest{
+		CharacterID: "test-char",
+		Message:     "Where were we?",
+		CacheBreakpoints: []cache.CacheBreakpoint{
+			{Layer: cache.CorePersonalityLayer, Content: "Core personality"},
+			{Layer: cache.ConversationLayer, Content: "[CONVERSATION SO FAR]\nThe dragon was sealed beneath the keep."},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+
+	if !strings.Contains(string(body), "The dragon was sealed beneath the keep.") {
+		t.Errorf("Expected the conversation summary in the request body, got %s", body)
+	}
+}
+
+func TestAnthropicProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens is required"}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProviderWithBaseURL("test-key", "claude-3-haiku-20240307", server.URL)
+
+	_, err := provider.SendRequest(context.Background(), &PromptRequest{Message: "Hello"})
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if !strings.Contains(err.Error(), "max_tokens is required") {
+		t.Errorf("Expected API error message, got %v", err)
+	}
+}
+
+func TestAnthropicProviderStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+			return
+		}
+		if !reqBody.Stream {
+			t.Error("Expected stream=true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":10,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			fmt.Fprintf(w, "event: x\ndata: %s\n\n", e)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProviderWithBaseURL("test-key", "claude-3-haiku-20240307", server.URL)
+
+	out := make(chan PartialAIResponse, 10)
+	if err := provider.SendStreamRequest(context.Background(), &PromptRequest{Message: "Hi"}, out); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	var content strings.Builder
+	done := false
+	for chunk := range out {
+		content.WriteString(chunk.Content)
+		if chunk.Done {
+			done = true
+		}
+	}
+
+	if content.String() != "Hello there" {
+		t.Errorf("Expected 'Hello there', got %q", content.String())
+	}
+	if !done {
+		t.Error("Expected a final Done chunk")
+	}
+}
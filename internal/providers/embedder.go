@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Embedder turns text into vectors for semantic memory retrieval.
+// Vectors from the same embedder and model are comparable by cosine similarity.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	EmbeddingModel() string
+}
+
+// DefaultEmbeddingModel is used by OpenAI-compatible providers when none is configured
+const DefaultEmbeddingModel = "text-embedding-3-small"
+
+// EmbeddingConfigurable is implemented by providers whose embedding model can be changed
+type EmbeddingConfigurable interface {
+	SetEmbeddingModel(model string)
+}
+
+// hashEmbeddingDims is the vector size produced by HashEmbedding
+const hashEmbeddingDims = 256
+
+// HashEmbedding returns a deterministic bag-of-words vector for text, using the
+// hashing trick. Texts sharing words score higher, which is enough to exercise
+// retrieval offline and in tests without calling an embeddings API.
+func HashEmbedding(text string) []float32 {
+	vec := make([]float32, hashEmbeddingDims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len(word) < 3 {
+			continue // Skip short function words
+		}
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(word))
+		vec[h.Sum32()%hashEmbeddingDims]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
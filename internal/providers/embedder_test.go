@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProviderEmbed(t *testing.T) {
+	var reqBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("Expected /embeddings, got %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Returned out of order to check that Index is honoured
+		fmt.Fprint(w, `{"object":"list","data":[
+			{"object":"embedding","index":1,"embedding":[0.0,1.0]},
+			{"object":"embedding","index":0,"embedding":[1.0,0.0]}
+		],"model":"nomic-embed-text"}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProviderWithBaseURL("test-key", "gpt-4o-mini", server.URL)
+	if provider.EmbeddingModel() != DefaultEmbeddingModel {
+		t.Errorf("Expected default embedding model, got %s", provider.EmbeddingModel())
+	}
+	provider.SetEmbeddingModel("nomic-embed-text")
+
+	vectors, err := provider.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if reqBody["model"] != "nomic-embed-text" {
+		t.Errorf("Expected configured model in request, got %v", reqBody["model"])
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("Unexpected vectors: %v", vectors)
+	}
+}
+
+func TestHashEmbedding(t *testing.T) {
+	dog := HashEmbedding("My dog Biscuit loves the park")
+	dogQuery := HashEmbedding("How is Biscuit, my dog?")
+	weather := HashEmbedding("Stormy weather over the harbour tonight")
+
+	similarity := func(a, b []float32) float64 {
+		var dot float64
+		for i := range a {
+			dot += float64(a[i]) * float64(b[i])
+		}
+		return dot
+	}
+
+	if similarity(dog, dogQuery) <= similarity(dog, weather) {
+		t.Errorf("Expected related texts to be more similar: related %.2f, unrelated %.2f",
+			similarity(dog, dogQuery), similarity(dog, weather))
+	}
+
+	// Deterministic across calls
+	again := HashEmbedding("My dog Biscuit loves the park")
+	for i := range dog {
+		if dog[i] != again[i] {
+			t.Fatal("Expected HashEmbedding to be deterministic")
+		}
+	}
+}
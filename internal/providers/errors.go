@@ -0,0 +1,107 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitError is returned when the provider rejects a request with HTTP 429
+type RateLimitError struct {
+	Provider   string
+	RetryAfter time.Duration // Zero when the provider sent no Retry-After hint
+	Err        error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("API request failed: %s rate limited: %v", e.Provider, e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// AuthError is returned when the API key is missing, invalid or lacks permission
+type AuthError struct {
+	Provider   string
+	StatusCode int
+	Err        error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("API request failed: %s authentication failed (status %d): %v", e.Provider, e.StatusCode, e.Err)
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// ContextLengthError is returned when the prompt exceeds the model's context window
+type ContextLengthError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ContextLengthError) Error() string {
+	return fmt.Sprintf("API request failed: %s context length exceeded: %v", e.Provider, e.Err)
+}
+
+func (e *ContextLengthError) Unwrap() error { return e.Err }
+
+// ServerError is returned for 5xx responses and provider overload
+type ServerError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("API request failed: %s server error (status %d): %v", e.Provider, e.StatusCode, e.Err)
+}
+
+func (e *ServerError) Unwrap() error { return e.Err }
+
+// classifyHTTPError maps an HTTP failure onto the typed provider errors.
+// Failures that fit none of them keep the plain "API request failed" form.
+func classifyHTTPError(provider string, status int, code, message string, retryAfter time.Duration, err error) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return &RateLimitError{Provider: provider, RetryAfter: retryAfter, Err: err}
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &AuthError{Provider: provider, StatusCode: status, Err: err}
+	case isContextLengthError(code, message):
+		return &ContextLengthError{Provider: provider, Err: err}
+	case status >= 500:
+		return &ServerError{Provider: provider, StatusCode: status, RetryAfter: retryAfter, Err: err}
+	default:
+		return fmt.Errorf("API request failed: %w", err)
+	}
+}
+
+// isContextLengthError recognises the context window errors used by OpenAI-compatible
+// APIs and Anthropic, which all report them as a plain 400
+func isContextLengthError(code, message string) bool {
+	if code == "context_length_exceeded" {
+		return true
+	}
+	message = strings.ToLower(message)
+	return strings.Contains(message, "context length") ||
+		strings.Contains(message, "context window") ||
+		strings.Contains(message, "prompt is too long")
+}
+
+// parseRetryAfter reads a Retry-After header given as seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -154,6 +154,27 @@ func (m *MockProvider) SendStreamRequest(ctx context.Context, req *PromptRequest
 	return nil
 }
 
+// Embed implements Embedder with deterministic bag-of-words vectors
+func (m *MockProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, m.errorToReturn
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = HashEmbedding(text)
+	}
+	return vectors, nil
+}
+
+// EmbeddingModel implements Embedder
+func (m *MockProvider) EmbeddingModel() string {
+	return "mock-hash"
+}
+
 // Name implements AIProvider interface
 func (m *MockProvider) Name() string {
 	return "mock"
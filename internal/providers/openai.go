@@ -2,11 +2,14 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/dotcommander/roleplay/internal/cache"
 	"github.com/sashabaranov/go-openai"
@@ -17,6 +20,9 @@ type OpenAIProvider struct {
 	client  *openai.Client
 	model   string
 	baseURL string // Store for debug logging
+	retry   RetryPolicy
+
+	embeddingModel string
 }
 
 // NewOpenAIProvider creates a new OpenAI provider instance
@@ -32,6 +38,7 @@ func NewOpenAIProviderWithBaseURL(apiKey, model, baseURL string) *OpenAIProvider
 	}
 
 	config := openai.DefaultConfig(apiKey)
+	transport := http.DefaultTransport
 	if baseURL != "" {
 		// Trust the user-provided base URL - don't modify it
 		// This allows for endpoints like /v1beta (Gemini) or other custom paths
@@ -40,16 +47,21 @@ func NewOpenAIProviderWithBaseURL(apiKey, model, baseURL string) *OpenAIProvider
 		// Add debug transport if DEBUG_HTTP env var is set
 		if os.Getenv("DEBUG_HTTP") == "true" {
 			fmt.Printf("🔧 Debug: OpenAI provider configured with base URL: %s\n", config.BaseURL)
-			config.HTTPClient = &http.Client{
-				Transport: &debugTransport{RoundTripper: http.DefaultTransport},
-			}
+			transport = &debugTransport{RoundTripper: http.DefaultTransport}
 		}
 	}
+	// Capture Retry-After so rate-limit retries can honour it
+	config.HTTPClient = &http.Client{
+		Transport: &retryAfterTransport{RoundTripper: transport},
+	}
 
 	return &OpenAIProvider{
 		client:  openai.NewClientWithConfig(config),
 		model:   model,
 		baseURL: config.BaseURL,
+		retry:   DefaultRetryPolicy(),
+
+		embeddingModel: DefaultEmbeddingModel,
 	}
 }
 
@@ -65,32 +77,31 @@ func (o *OpenAIProvider) SendRequest(ctx context.Context, req *PromptRequest) (*
 		User:     req.UserID, // Add user parameter for better cache routing
 	}
 
-	// o1 models have restrictions on parameters
-	if !strings.HasPrefix(o.model, "o1-") && !strings.HasPrefix(o.model, "o4-") {
-		// Standard models support these parameters
-		apiReq.Temperature = 0.7
-		// Use more tokens for user profile updates which can be lengthy JSON
-		if req.CharacterID == "system-user-profiler" {
-			apiReq.MaxTokens = 4000
-		} else {
-			apiReq.MaxTokens = 2000
-		}
-	}
+	o.applyGeneration(&apiReq, req)
 
 	// Send the request
 	if os.Getenv("DEBUG_HTTP") == "true" {
 		fmt.Printf("🔧 Debug: Sending chat completion request with model: %s\n", o.model)
 	}
-	resp, err := o.client.CreateChatCompletion(ctx, apiReq)
-	if err != nil {
-		// Add debug info if enabled
-		if os.Getenv("DEBUG_HTTP") == "true" {
-			fmt.Printf("🔧 Debug: Request failed - Error: %v\n", err)
-			if o.baseURL != "" {
-				fmt.Printf("🔧 Debug: Expected URL: %s/chat/completions\n", o.baseURL)
+	var resp openai.ChatCompletionResponse
+	err := withRetry(ctx, o.retry, o.Name(), func(ctx context.Context) error {
+		var retryAfter time.Duration
+		var err error
+		resp, err = o.client.CreateChatCompletion(context.WithValue(ctx, retryAfterKey{}, &retryAfter), apiReq)
+		if err != nil {
+			// Add debug info if enabled
+			if os.Getenv("DEBUG_HTTP") == "true" {
+				fmt.Printf("🔧 Debug: Request failed - Error: %v\n", err)
+				if o.baseURL != "" {
+					fmt.Printf("🔧 Debug: Expected URL: %s/chat/completions\n", o.baseURL)
+				}
 			}
+			return o.classifyError(err, retryAfter)
 		}
-		return nil, fmt.Errorf("API request failed: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Debug response if enabled
@@ -100,6 +111,49 @@ func (o *OpenAIProvider) SendRequest(ctx context.Context, req *PromptRequest) (*
 	return o.parseResponse(resp)
 }
 
+// applyGeneration sets sampler parameters from the request, falling back to the
+// provider defaults for anything the character, scenario or config left unset
+func (o *OpenAIProvider) applyGeneration(apiReq *openai.ChatCompletionRequest, req *PromptRequest) {
+	gen := req.Generation
+
+	// o1 models have restrictions on parameters
+	if strings.HasPrefix(o.model, "o1-") || strings.HasPrefix(o.model, "o4-") {
+		apiReq.Seed = gen.Seed
+		return
+	}
+
+	// Standard models support these parameters
+	apiReq.Temperature = 0.7
+	if gen.Temperature != nil {
+		apiReq.Temperature = float32(*gen.Temperature)
+		if apiReq.Temperature == 0 {
+			// go-openai omits a zero temperature, so send the smallest non-zero value
+			apiReq.Temperature = math.SmallestNonzeroFloat32
+		}
+	}
+
+	// Use more tokens for user profile updates which can be lengthy JSON
+	apiReq.MaxTokens = 2000
+	if req.CharacterID == "system-user-profiler" {
+		apiReq.MaxTokens = 4000
+	}
+	if gen.MaxTokens != nil {
+		apiReq.MaxTokens = *gen.MaxTokens
+	}
+
+	if gen.TopP != nil {
+		apiReq.TopP = float32(*gen.TopP)
+	}
+	if gen.PresencePenalty != nil {
+		apiReq.PresencePenalty = float32(*gen.PresencePenalty)
+	}
+	if gen.FrequencyPenalty != nil {
+		apiReq.FrequencyPenalty = float32(*gen.FrequencyPenalty)
+	}
+	apiReq.Stop = gen.Stop
+	apiReq.Seed = gen.Seed
+}
+
 func (o *OpenAIProvider) buildMessages(req *PromptRequest) []openai.ChatCompletionMessage {
 	messages := []openai.ChatCompletionMessage{}
 
@@ -159,20 +213,20 @@ func (o *OpenAIProvider) SendStreamRequest(ctx context.Context, req *PromptReque
 		Stream:   true,
 	}
 
-	// o1 models have restrictions on parameters
-	if !strings.HasPrefix(o.model, "o1-") && !strings.HasPrefix(o.model, "o4-") {
-		// Standard models support these parameters
-		apiReq.Temperature = 0.7
-		// Use more tokens for user profile updates which can be lengthy JSON
-		if req.CharacterID == "system-user-profiler" {
-			apiReq.MaxTokens = 4000
-		} else {
-			apiReq.MaxTokens = 2000
-		}
-	}
+	o.applyGeneration(&apiReq, req)
 
 	// Create the stream
-	stream, err := o.client.CreateChatCompletionStream(ctx, apiReq)
+	// Only opening the stream is retried; a reply that has started is not replayed
+	var stream *openai.ChatCompletionStream
+	err := withRetry(ctx, o.retry, o.Name(), func(ctx context.Context) error {
+		var retryAfter time.Duration
+		var err error
+		stream, err = o.client.CreateChatCompletionStream(context.WithValue(ctx, retryAfterKey{}, &retryAfter), apiReq)
+		if err != nil {
+			return o.classifyError(err, retryAfter)
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create stream: %w", err)
 	}
@@ -241,11 +295,11 @@ func (o *OpenAIProvider) parseResponse(resp openai.ChatCompletionResponse) (*AIR
 		Total:        resp.Usage.TotalTokens,
 	}
 	
-	// Calculate saved tokens based on OpenAI's pricing model
-	// OpenAI offers 50% discount on cached tokens
-	savedTokens := 0
-	if cachedTokens > 0 {
-		savedTokens = cachedTokens / 2
+	// The dollar value of cached tokens depends on the vendor's rates and is
+	// priced by the caller
+	model := resp.Model
+	if model == "" {
+		model = o.model
 	}
 
 	return &AIResponse{
@@ -254,11 +308,79 @@ func (o *OpenAIProvider) parseResponse(resp openai.ChatCompletionResponse) (*AIR
 		CacheMetrics: cache.CacheMetrics{
 			Hit:         cachedTokens > 0,
 			Layers:      cachedLayers,
-			SavedTokens: savedTokens,
+			SavedTokens: cachedTokens,
 		},
+		Model: model,
 	}, nil
 }
 
+// classifyError converts a go-openai error into one of the typed provider errors
+func (o *OpenAIProvider) classifyError(err error, retryAfter time.Duration) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		code, _ := apiErr.Code.(string)
+		return classifyHTTPError(o.Name(), apiErr.HTTPStatusCode, code, apiErr.Message, retryAfter, err)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return classifyHTTPError(o.Name(), reqErr.HTTPStatusCode, "", string(reqErr.Body), retryAfter, err)
+	}
+	return fmt.Errorf("API request failed: %w", err)
+}
+
+// Embed returns embeddings for texts from the /embeddings endpoint
+func (o *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	apiReq := openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: openai.EmbeddingModel(o.embeddingModel),
+	}
+
+	var resp openai.EmbeddingResponse
+	err := withRetry(ctx, o.retry, o.Name(), func(ctx context.Context) error {
+		var retryAfter time.Duration
+		var err error
+		resp, err = o.client.CreateEmbeddings(context.WithValue(ctx, retryAfterKey{}, &retryAfter), apiReq)
+		if err != nil {
+			return o.classifyError(err, retryAfter)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+// EmbeddingModel returns the model used by Embed
+func (o *OpenAIProvider) EmbeddingModel() string { return o.embeddingModel }
+
+// SetEmbeddingModel changes the model used by Embed
+func (o *OpenAIProvider) SetEmbeddingModel(model string) {
+	if model != "" {
+		o.embeddingModel = model
+	}
+}
+
+// SetRetryPolicy replaces the retry policy; zero fields keep their defaults
+func (o *OpenAIProvider) SetRetryPolicy(policy RetryPolicy) {
+	o.retry = policy.withDefaults()
+}
+
 // SupportsBreakpoints indicates that OpenAI-compatible APIs handle caching server-side
 func (o *OpenAIProvider) SupportsBreakpoints() bool { return false }
 
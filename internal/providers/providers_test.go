@@ -3,12 +3,15 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/dotcommander/roleplay/internal/cache"
 	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/sashabaranov/go-openai"
 )
 
 func TestOpenAIProvider(t *testing.T) {
@@ -113,3 +116,71 @@ func TestOpenAIProviderRequest(t *testing.T) {
 	// Note: With the SDK-based implementation, cached tokens might not be reported
 	// depending on the provider. This is a limitation of the OpenAI-compatible approach
 }
+
+func TestOpenAIProviderGenerationParams(t *testing.T) {
+	var reqBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"Very good, sir."}}],"usage":{"total_tokens":10}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProviderWithBaseURL("test-key", "gpt-4o-mini", server.URL)
+
+	temp := 0.3
+	topP := 0.8
+	maxTokens := 60
+	penalty := 0.5
+	seed := 42
+	_, err := provider.SendRequest(context.Background(), &PromptRequest{
+		CharacterID: "butler",
+		Message:     "Hello",
+		Generation: models.GenerationParams{
+			Temperature:     &temp,
+			TopP:            &topP,
+			MaxTokens:       &maxTokens,
+			PresencePenalty: &penalty,
+			Stop:            []string{"END"},
+			Seed:            &seed,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+
+	checks := map[string]float64{
+		"temperature":      0.3,
+		"top_p":            0.8,
+		"max_tokens":       60,
+		"presence_penalty": 0.5,
+		"seed":             42,
+	}
+	for field, want := range checks {
+		got, ok := reqBody[field].(float64)
+		if !ok || math.Abs(got-want) > 1e-6 {
+			t.Errorf("Expected %s=%v, got %v", field, want, reqBody[field])
+		}
+	}
+	if stop, _ := reqBody["stop"].([]interface{}); len(stop) != 1 || stop[0] != "END" {
+		t.Errorf("Expected stop [END], got %v", reqBody["stop"])
+	}
+}
+
+func TestOpenAIProviderGenerationDefaults(t *testing.T) {
+	provider := NewOpenAIProvider("test-key", "gpt-4o-mini")
+
+	var apiReq openai.ChatCompletionRequest
+	provider.applyGeneration(&apiReq, &PromptRequest{CharacterID: "system-user-profiler"})
+	if apiReq.Temperature != 0.7 || apiReq.MaxTokens != 4000 {
+		t.Errorf("Expected built-in defaults, got temperature %v max tokens %d", apiReq.Temperature, apiReq.MaxTokens)
+	}
+
+	zero := 0.0
+	provider.applyGeneration(&apiReq, &PromptRequest{Generation: models.GenerationParams{Temperature: &zero}})
+	if apiReq.Temperature == 0 {
+		t.Error("Expected explicit zero temperature to survive omitempty")
+	}
+}
@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how failed provider calls are retried
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled on each attempt
+	MaxDelay    time.Duration // Upper bound on a single wait, including Retry-After
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// withDefaults fills zero fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = def.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+	return p
+}
+
+// RetryConfigurable is implemented by providers that retry transient failures
+type RetryConfigurable interface {
+	SetRetryPolicy(policy RetryPolicy)
+}
+
+// RetryEvent describes a retry that is about to happen
+type RetryEvent struct {
+	Provider    string
+	Attempt     int // The attempt about to be made, starting at 2
+	MaxAttempts int
+	Delay       time.Duration
+	Err         error // The failure that triggered the retry
+}
+
+type retryNotifierKey struct{}
+
+// WithRetryNotifier returns a context that reports retries to fn.
+// fn is called from the provider's goroutine and must not block.
+func WithRetryNotifier(ctx context.Context, fn func(RetryEvent)) context.Context {
+	return context.WithValue(ctx, retryNotifierKey{}, fn)
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying
+func IsRetryable(err error) bool {
+	var rateLimitErr *RateLimitError
+	var serverErr *ServerError
+	return errors.As(err, &rateLimitErr) || errors.As(err, &serverErr)
+}
+
+// retryAfter returns the provider's Retry-After hint carried by err, if any
+func retryAfter(err error) time.Duration {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr.RetryAfter
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.RetryAfter
+	}
+	return 0
+}
+
+// delay returns how long to wait before the given attempt.
+// A Retry-After hint wins over the computed backoff; otherwise the exponential
+// delay is jittered into [d/2, d] so concurrent clients spread out.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	if hint := retryAfter(err); hint > 0 {
+		return hint
+	}
+
+	d := p.BaseDelay << (attempt - 2)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// withRetry calls fn until it succeeds, fails with a non-retryable error, or
+// the policy runs out of attempts. A Retry-After hint longer than MaxDelay
+// ends the retries early rather than stalling the caller.
+func withRetry(ctx context.Context, policy RetryPolicy, provider string, fn func(ctx context.Context) error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	notify, _ := ctx.Value(retryNotifierKey{}).(func(RetryEvent))
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt == maxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := policy.delay(attempt+1, err)
+		if wait > policy.MaxDelay {
+			return err
+		}
+
+		if notify != nil {
+			notify(RetryEvent{
+				Provider:    provider,
+				Attempt:     attempt + 1,
+				MaxAttempts: maxAttempts,
+				Delay:       wait,
+				Err:         err,
+			})
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+
+	return err
+}
+
+type retryAfterKey struct{}
+
+// retryAfterTransport records the Retry-After header of each response into the
+// slot carried by the request context, since go-openai does not expose headers
+type retryAfterTransport struct {
+	http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err == nil {
+		if slot, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+			*slot = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,193 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+}
+
+func openAIReply(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Recovered"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+}
+
+func TestOpenAIProviderRetriesRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+			return
+		}
+		openAIReply(w)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProviderWithBaseURL("test-key", "gpt-4o-mini", server.URL)
+	provider.SetRetryPolicy(fastRetryPolicy())
+
+	var events []RetryEvent
+	ctx := WithRetryNotifier(context.Background(), func(ev RetryEvent) {
+		events = append(events, ev)
+	})
+
+	resp, err := provider.SendRequest(ctx, &PromptRequest{Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Expected retries to recover, got %v", err)
+	}
+	if resp.Content != "Recovered" {
+		t.Errorf("Expected 'Recovered', got %q", resp.Content)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls.Load())
+	}
+	if len(events) != 2 || events[0].Attempt != 2 || events[1].Attempt != 3 || events[1].MaxAttempts != 4 {
+		t.Errorf("Unexpected retry events: %+v", events)
+	}
+}
+
+func TestOpenAIProviderTypedErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantCalls int32
+		check     func(err error) bool
+	}{
+		{
+			name:      "auth",
+			status:    http.StatusUnauthorized,
+			body:      `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			wantCalls: 1,
+			check: func(err error) bool {
+				var authErr *AuthError
+				return errors.As(err, &authErr) && authErr.StatusCode == http.StatusUnauthorized
+			},
+		},
+		{
+			name:      "context length",
+			status:    http.StatusBadRequest,
+			body:      `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			wantCalls: 1,
+			check: func(err error) bool {
+				var ctxErr *ContextLengthError
+				return errors.As(err, &ctxErr)
+			},
+		},
+		{
+			name:      "server error exhausts attempts",
+			status:    http.StatusServiceUnavailable,
+			body:      `{"error":{"message":"The server is overloaded","type":"server_error"}}`,
+			wantCalls: 4,
+			check: func(err error) bool {
+				var serverErr *ServerError
+				return errors.As(err, &serverErr) && serverErr.StatusCode == http.StatusServiceUnavailable
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			provider := NewOpenAIProviderWithBaseURL("test-key", "gpt-4o-mini", server.URL)
+			provider.SetRetryPolicy(fastRetryPolicy())
+
+			_, err := provider.SendRequest(context.Background(), &PromptRequest{Message: "Hello"})
+			if err == nil || !tt.check(err) {
+				t.Errorf("Unexpected error type: %T %v", err, err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetryAfterBeyondMaxDelayStopsRetrying(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests"}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProviderWithBaseURL("test-key", "gpt-4o-mini", server.URL)
+	provider.SetRetryPolicy(fastRetryPolicy())
+
+	_, err := provider.SendRequest(context.Background(), &PromptRequest{Message: "Hello"})
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected RateLimitError, got %T %v", err, err)
+	}
+	if rateLimitErr.RetryAfter != 120*time.Second {
+		t.Errorf("Expected Retry-After of 120s, got %v", rateLimitErr.RetryAfter)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected no retry past MaxDelay, got %d calls", calls.Load())
+	}
+}
+
+func TestAnthropicProviderRetriesOverload(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(529)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Back"}],"usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProviderWithBaseURL("test-key", "claude-3-haiku-20240307", server.URL)
+	provider.SetRetryPolicy(fastRetryPolicy())
+
+	resp, err := provider.SendRequest(context.Background(), &PromptRequest{Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Expected retry to recover, got %v", err)
+	}
+	if resp.Content != "Back" || calls.Load() != 2 {
+		t.Errorf("Expected 'Back' after 2 calls, got %q after %d", resp.Content, calls.Load())
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	if d := policy.delay(2, &RateLimitError{RetryAfter: 3 * time.Second}); d != 3*time.Second {
+		t.Errorf("Expected Retry-After to win, got %v", d)
+	}
+
+	for attempt, max := range map[int]time.Duration{2: 100 * time.Millisecond, 3: 200 * time.Millisecond, 10: time.Second} {
+		d := policy.delay(attempt, &ServerError{})
+		if d < max/2 || d > max {
+			t.Errorf("Attempt %d: expected delay in [%v, %v], got %v", attempt, max/2, max, d)
+		}
+	}
+
+	if parseRetryAfter("2") != 2*time.Second {
+		t.Error("Expected Retry-After seconds to parse")
+	}
+	if parseRetryAfter("soon") != 0 {
+		t.Error("Expected unparseable Retry-After to be ignored")
+	}
+}
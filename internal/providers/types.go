@@ -22,6 +22,7 @@ type PromptRequest struct {
 	Context          models.ConversationContext
 	SystemPrompt     string
 	CacheBreakpoints []cache.CacheBreakpoint
+	Generation       models.GenerationParams // Resolved sampler settings; unset fields use provider defaults
 }
 
 // AIResponse represents a response from an AI provider
@@ -30,14 +31,19 @@ type AIResponse struct {
 	TokensUsed   TokenUsage
 	CacheMetrics cache.CacheMetrics
 	Emotions     models.EmotionalState
+	Provider     string  // Name of the provider that answered
+	Model        string  // Model that produced the reply
+	Cost         float64 // USD billed for the request; 0 when the model is unpriced
+	CostSaved    float64 // USD saved by provider prompt caching or the response cache
 }
 
 // TokenUsage tracks token consumption
 type TokenUsage struct {
-	Prompt       int
-	Completion   int
-	CachedPrompt int
-	Total        int
+	Prompt        int
+	Completion    int
+	CachedPrompt  int
+	CacheCreation int // Tokens written to the provider cache (Anthropic)
+	Total         int
 }
 
 // PartialAIResponse represents a chunk of streaming response
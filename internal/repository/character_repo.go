@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/dotcommander/roleplay/internal/atomicfile"
 	"github.com/dotcommander/roleplay/internal/models"
 )
 
@@ -55,7 +56,7 @@ func (r *CharacterRepository) SaveCharacter(character *models.Character) error {
 		return fmt.Errorf("failed to marshal character: %w", err)
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	return atomicfile.Write(filename, data, 0644)
 }
 
 // LoadCharacter loads a character from disk
@@ -81,6 +82,21 @@ func (r *CharacterRepository) LoadCharacter(id string) (*models.Character, error
 	return &character, nil
 }
 
+// DeleteCharacter removes a character from disk
+func (r *CharacterRepository) DeleteCharacter(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filename := filepath.Join(r.dataDir, "characters", fmt.Sprintf("%s.json", id))
+	if err := os.Remove(filename); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("character %s not found", id)
+		}
+		return fmt.Errorf("failed to delete character file: %w", err)
+	}
+	return nil
+}
+
 // ListCharacters returns all available character IDs
 func (r *CharacterRepository) ListCharacters() ([]string, error) {
 	r.mu.RLock()
@@ -140,9 +156,9 @@ func (r *CharacterRepository) GetCharacterInfo() ([]CharacterInfo, error) {
 
 // CharacterInfo provides basic character information
 type CharacterInfo struct {
-	ID          string
-	Name        string
-	Description string
-	Tags        []string
-	SpeechStyle string
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	SpeechStyle string   `json:"speech_style,omitempty"`
 }
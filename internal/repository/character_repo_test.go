@@ -117,8 +117,24 @@ func TestCharacterListAndDelete(t *testing.T) {
 		t.Errorf("Expected 3 characters, got %d", len(ids))
 	}
 
-	// Since DeleteCharacter is not implemented, we'll skip deletion test
-	// This is a limitation of the current repository implementation
+	// Delete one character
+	if err := repo.DeleteCharacter("char2"); err != nil {
+		t.Fatalf("Failed to delete character: %v", err)
+	}
+
+	ids, err = repo.ListCharacters()
+	if err != nil {
+		t.Fatalf("Failed to list characters: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("Expected 2 characters after delete, got %d", len(ids))
+	}
+	if _, err := repo.LoadCharacter("char2"); err == nil {
+		t.Error("Expected deleted character to be gone")
+	}
+	if err := repo.DeleteCharacter("char2"); err == nil {
+		t.Error("Expected an error deleting a missing character")
+	}
 }
 
 func TestConcurrentWrites(t *testing.T) {
@@ -512,4 +528,49 @@ func TestAtomicWrites(t *testing.T) {
 	if errorCount > 0 {
 		t.Errorf("Total read errors: %d", errorCount)
 	}
-}
\ No newline at end of file
+}
+func TestAtomicWritesAcrossRepositories(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Separate repositories share no mutex, like two CLI processes
+	repos := make([]*CharacterRepository, 4)
+	for i := range repos {
+		repo, err := NewCharacterRepository(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to create repository: %v", err)
+		}
+		repos[i] = repo
+	}
+
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo *CharacterRepository) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				char := &models.Character{
+					ID:        "shared",
+					Name:      fmt.Sprintf("Writer %d", i),
+					Backstory: fmt.Sprintf("Update %d from writer %d", j, i),
+				}
+				if err := repo.SaveCharacter(char); err != nil {
+					t.Errorf("Writer %d failed to save: %v", i, err)
+					return
+				}
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if _, err := repos[0].LoadCharacter("shared"); err != nil {
+		t.Fatalf("Character file corrupted: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(tempDir, "characters"))
+	if err != nil {
+		t.Fatalf("Failed to read characters directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the character file, found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dotcommander/roleplay/internal/atomicfile"
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+// CharacterStateRepository persists per-user character state.
+// States are stored as <dataDir>/<character-id>/<user-id>.json.
+type CharacterStateRepository struct {
+	dataDir string
+	mu      sync.RWMutex
+}
+
+// NewCharacterStateRepository creates a new character state repository
+func NewCharacterStateRepository(dataDir string) *CharacterStateRepository {
+	return &CharacterStateRepository{dataDir: dataDir}
+}
+
+// stateFilename returns the path of the state file for a character and user
+func (r *CharacterStateRepository) stateFilename(characterID, userID string) string {
+	return filepath.Join(r.dataDir, characterID, fmt.Sprintf("%s.json", userID))
+}
+
+// SaveState writes a character state to disk atomically
+func (r *CharacterStateRepository) SaveState(state *models.CharacterState) error {
+	if state == nil {
+		return fmt.Errorf("state cannot be nil")
+	}
+	if err := ValidateID(state.CharacterID); err != nil {
+		return fmt.Errorf("character %w", err)
+	}
+	if err := ValidateID(state.UserID); err != nil {
+		return fmt.Errorf("user %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(r.dataDir, state.CharacterID), 0755); err != nil {
+		return fmt.Errorf("failed to create character state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal character state: %w", err)
+	}
+
+	return atomicfile.Write(r.stateFilename(state.CharacterID, state.UserID), data, 0644)
+}
+
+// LoadState loads the state of a character toward a user.
+// A missing state is reported with an error satisfying os.IsNotExist.
+func (r *CharacterStateRepository) LoadState(characterID, userID string) (*models.CharacterState, error) {
+	if err := ValidateID(characterID); err != nil {
+		return nil, fmt.Errorf("character %w", err)
+	}
+	if err := ValidateID(userID); err != nil {
+		return nil, fmt.Errorf("user %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, err := os.ReadFile(r.stateFilename(characterID, userID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err // Let caller handle non-existence
+		}
+		return nil, fmt.Errorf("failed to read character state file: %w", err)
+	}
+
+	var state models.CharacterState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal character state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// ListUsers returns the IDs of users with a saved state for a character
+func (r *CharacterStateRepository) ListUsers(characterID string) ([]string, error) {
+	if err := ValidateID(characterID); err != nil {
+		return nil, fmt.Errorf("character %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(r.dataDir, characterID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read character state directory: %w", err)
+	}
+
+	users := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			users = append(users, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+
+	return users, nil
+}
+
+// DeleteState removes the state of a character toward a user
+func (r *CharacterStateRepository) DeleteState(characterID, userID string) error {
+	if err := ValidateID(characterID); err != nil {
+		return fmt.Errorf("character %w", err)
+	}
+	if err := ValidateID(userID); err != nil {
+		return fmt.Errorf("user %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.Remove(r.stateFilename(characterID, userID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete character state: %w", err)
+	}
+
+	return nil
+}
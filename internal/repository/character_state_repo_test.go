@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+func TestCharacterStatePersistence(t *testing.T) {
+	repo := NewCharacterStateRepository(t.TempDir())
+
+	state := &models.CharacterState{
+		CharacterID: "rick-c137",
+		UserID:      "morty",
+		CurrentMood: models.EmotionalState{Joy: 0.4, Anger: 0.2},
+		Memories: []models.Memory{
+			{Type: models.ShortTermMemory, Content: "Wubba lubba dub dub", Timestamp: time.Now(), Emotional: 0.5},
+		},
+		Relationship:     models.RelationshipMetrics{Trust: 0.7, Affection: 0.6, Familiarity: 0.3},
+		PersonalityDrift: models.PersonalityTraits{Openness: 0.05},
+		InteractionCount: 7,
+	}
+
+	if err := repo.SaveState(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	loaded, err := repo.LoadState("rick-c137", "morty")
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if loaded.CurrentMood != state.CurrentMood || loaded.Relationship != state.Relationship {
+		t.Errorf("Loaded state mismatch: %+v", loaded)
+	}
+	if len(loaded.Memories) != 1 || loaded.Memories[0].Content != "Wubba lubba dub dub" {
+		t.Errorf("Expected memories to round-trip, got %+v", loaded.Memories)
+	}
+	if loaded.PersonalityDrift.Openness != 0.05 || loaded.InteractionCount != 7 {
+		t.Errorf("Expected drift and interaction count to round-trip, got %+v", loaded)
+	}
+
+	// States for other users are independent
+	if _, err := repo.LoadState("rick-c137", "summer"); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist error for unknown user, got %v", err)
+	}
+
+	if err := repo.SaveState(&models.CharacterState{CharacterID: "rick-c137", UserID: "summer"}); err != nil {
+		t.Fatalf("Failed to save second state: %v", err)
+	}
+	users, err := repo.ListUsers("rick-c137")
+	if err != nil {
+		t.Fatalf("Failed to list users: %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected 2 users, got %v", users)
+	}
+
+	if err := repo.DeleteState("rick-c137", "summer"); err != nil {
+		t.Fatalf("Failed to delete state: %v", err)
+	}
+	if _, err := repo.LoadState("rick-c137", "summer"); !os.IsNotExist(err) {
+		t.Errorf("Expected deleted state to be gone, got %v", err)
+	}
+
+	// Unknown characters have no users
+	users, err = repo.ListUsers("nobody")
+	if err != nil || len(users) != 0 {
+		t.Errorf("Expected no users for unknown character, got %v (%v)", users, err)
+	}
+}
+
+func TestInvalidCharacterStateIDs(t *testing.T) {
+	repo := NewCharacterStateRepository(t.TempDir())
+
+	tests := []struct {
+		name        string
+		characterID string
+		userID      string
+	}{
+		{"empty character", "", "user"},
+		{"empty user", "char", ""},
+		{"path traversal", "../etc", "user"},
+		{"slash in user", "char", "a/b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.SaveState(&models.CharacterState{CharacterID: tt.characterID, UserID: tt.userID})
+			if err == nil {
+				t.Error("Expected error saving state with invalid ID")
+			}
+			if _, err := repo.LoadState(tt.characterID, tt.userID); err == nil {
+				t.Error("Expected error loading state with invalid ID")
+			}
+		})
+	}
+}
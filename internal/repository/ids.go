@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// maxIDLength keeps IDs within the file name limit of common filesystems
+const maxIDLength = 255
+
+// validID matches the character, scenario, session and user IDs kept on
+// disk; they name files, so path separators are never allowed
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.@-]*$`)
+
+// ValidateID rejects an ID that can't safely name a file in the data directory
+func ValidateID(id string) error {
+	if len(id) > maxIDLength {
+		return fmt.Errorf("ID too long")
+	}
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid ID %q", id)
+	}
+	return nil
+}
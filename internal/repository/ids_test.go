@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"narrator", true},
+		{"rick-c137", true},
+		{"alice@example.com", true},
+		{"session_1.2", true},
+		{"", false},
+		{".hidden", false},
+		{"../escape", false},
+		{`a\b`, false},
+		{"with space", false},
+		{strings.Repeat("a", maxIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateID(tt.id); (err == nil) != tt.valid {
+			t.Errorf("ValidateID(%q) = %v, want valid %v", tt.id, err, tt.valid)
+		}
+	}
+}
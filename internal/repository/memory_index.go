@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/filelock"
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+// IndexedMemory is a memory stored together with its embedding
+type IndexedMemory struct {
+	ID        string            `json:"id"`
+	Content   string            `json:"content"`
+	Type      models.MemoryType `json:"type"`
+	Emotional float64           `json:"emotional"`
+	Timestamp time.Time         `json:"timestamp"`
+	Model     string            `json:"model"` // Embedding model that produced Vector
+	Vector    []float32         `json:"vector"`
+}
+
+// ScoredMemory is a memory returned by a search with its cosine similarity
+type ScoredMemory struct {
+	IndexedMemory
+	Score float64 `json:"score"`
+}
+
+// cachedIndex is a parsed index file. Files only grow, so a later load reads
+// just the lines appended since.
+type cachedIndex struct {
+	offset  int64 // Bytes parsed so far, always at a line boundary
+	entries []IndexedMemory
+}
+
+// MemoryIndex is an on-disk vector index of memories, one JSON Lines file per
+// (character, user) at <dataDir>/<character-id>/<user-id>.jsonl
+type MemoryIndex struct {
+	dataDir string
+	mu      sync.Mutex
+	cache   map[string]*cachedIndex
+}
+
+// NewMemoryIndex creates a memory index rooted at dataDir
+func NewMemoryIndex(dataDir string) *MemoryIndex {
+	return &MemoryIndex{
+		dataDir: dataDir,
+		cache:   make(map[string]*cachedIndex),
+	}
+}
+
+// indexFilename returns the path of the index file for a character and user
+func (ix *MemoryIndex) indexFilename(characterID, userID string) (string, error) {
+	if err := ValidateID(characterID); err != nil {
+		return "", fmt.Errorf("character %w", err)
+	}
+	if err := ValidateID(userID); err != nil {
+		return "", fmt.Errorf("user %w", err)
+	}
+	return filepath.Join(ix.dataDir, characterID, fmt.Sprintf("%s.jsonl", userID)), nil
+}
+
+// load returns the entries of an index file, parsing only what was appended
+// since the last load. A trailing line still being written is left for later.
+// The caller must hold ix.mu.
+func (ix *MemoryIndex) load(filename string) ([]IndexedMemory, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			delete(ix.cache, filename)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open memory index: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat memory index: %w", err)
+	}
+	cached, ok := ix.cache[filename]
+	if !ok || info.Size() < cached.offset {
+		// New to this process, or replaced since
+		cached = &cachedIndex{}
+		ix.cache[filename] = cached
+	}
+	if info.Size() == cached.offset {
+		return cached.entries, nil
+	}
+
+	data := make([]byte, info.Size()-cached.offset)
+	if _, err := f.ReadAt(data, cached.offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read memory index: %w", err)
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+
+	// Entries are appended to a copy so slices handed out earlier stay valid
+	entries := append([]IndexedMemory(nil), cached.entries...)
+	for _, line := range bytes.Split(data[:complete], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry IndexedMemory
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// A line torn by a crash mid-write; the entries around it are intact
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	cached.entries = entries
+	cached.offset += int64(complete)
+	return entries, nil
+}
+
+// Add appends memories to the index of a character and user. Writes are
+// serialised with other processes by a lock file, so none are lost.
+func (ix *MemoryIndex) Add(characterID, userID string, memories ...IndexedMemory) error {
+	filename, err := ix.indexFilename(characterID, userID)
+	if err != nil {
+		return err
+	}
+	if len(memories) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, mem := range memories {
+		data, err := json.Marshal(mem)
+		if err != nil {
+			return fmt.Errorf("failed to marshal memory: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	lock, err := filelock.Acquire(filepath.Join(ix.dataDir, ".lock"))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create memory index directory: %w", err)
+	}
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open memory index: %w", err)
+	}
+	defer f.Close()
+
+	data := buf.Bytes()
+	// Start on a fresh line if a crash left the last one unfinished
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write memory index: %w", err)
+	}
+	return nil
+}
+
+// Search returns up to k memories most similar to query, best first.
+// Entries embedded with a different model, or whose similarity is not above
+// minScore, are skipped.
+func (ix *MemoryIndex) Search(characterID, userID string, query []float32, model string, k int, minScore float64) ([]ScoredMemory, error) {
+	filename, err := ix.indexFilename(characterID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ix.mu.Lock()
+	entries, err := ix.load(filename)
+	ix.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ScoredMemory, 0, k)
+	for _, entry := range entries {
+		if entry.Model != model || len(entry.Vector) != len(query) {
+			continue
+		}
+		score := cosineSimilarity(query, entry.Vector)
+		if score <= minScore {
+			continue
+		}
+		results = append(results, ScoredMemory{IndexedMemory: entry, Score: score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Count returns the number of memories indexed for a character and user
+func (ix *MemoryIndex) Count(characterID, userID string) (int, error) {
+	filename, err := ix.indexFilename(characterID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	entries, err := ix.load(filename)
+	return len(entries), err
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+func TestMemoryIndexSearch(t *testing.T) {
+	dir := t.TempDir()
+	index := NewMemoryIndex(dir)
+
+	memories := []IndexedMemory{
+		{ID: "1", Content: "dog", Type: models.LongTermMemory, Timestamp: time.Now(), Model: "test", Vector: []float32{1, 0, 0}},
+		{ID: "2", Content: "cat", Type: models.LongTermMemory, Timestamp: time.Now(), Model: "test", Vector: []float32{0.8, 0.6, 0}},
+		{ID: "3", Content: "weather", Type: models.LongTermMemory, Timestamp: time.Now(), Model: "test", Vector: []float32{0, 0, 1}},
+		{ID: "4", Content: "other model", Type: models.LongTermMemory, Timestamp: time.Now(), Model: "other", Vector: []float32{1, 0, 0}},
+	}
+	if err := index.Add("rick", "morty", memories...); err != nil {
+		t.Fatalf("Failed to add memories: %v", err)
+	}
+
+	results, err := index.Search("rick", "morty", []float32{1, 0, 0}, "test", 5, 0.1)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results above the threshold for the model, got %d: %+v", len(results), results)
+	}
+	if results[0].ID != "1" || results[1].ID != "2" {
+		t.Errorf("Expected results ranked by similarity, got %s then %s", results[0].ID, results[1].ID)
+	}
+
+	// Top-k limits the result count
+	results, _ = index.Search("rick", "morty", []float32{1, 0, 0}, "test", 1, 0)
+	if len(results) != 1 {
+		t.Errorf("Expected k=1 to return one result, got %d", len(results))
+	}
+
+	// Other users have their own index
+	results, err = index.Search("rick", "summer", []float32{1, 0, 0}, "test", 5, 0)
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected no results for another user, got %v (%v)", results, err)
+	}
+}
+
+func TestMemoryIndexSharedAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+	first := NewMemoryIndex(dir)
+	second := NewMemoryIndex(dir)
+
+	entry := func(id string) IndexedMemory {
+		return IndexedMemory{ID: id, Model: "test", Vector: []float32{1, 0}}
+	}
+
+	// Prime the first instance's cache, then write through the second
+	if err := first.Add("rick", "morty", entry("a")); err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+	if err := second.Add("rick", "morty", entry("b")); err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+	if err := first.Add("rick", "morty", entry("c")); err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+
+	count, err := second.Count("rick", "morty")
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected entries from both instances to be kept, got %d", count)
+	}
+
+	if err := first.Add("../escape", "morty", entry("d")); err == nil {
+		t.Error("Expected invalid character ID to be rejected")
+	}
+}
+
+func TestMemoryIndexConcurrentProcesses(t *testing.T) {
+	dir := t.TempDir()
+
+	// Separate instances stand in for separate processes
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		index := NewMemoryIndex(dir)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				mem := IndexedMemory{ID: fmt.Sprintf("%d-%d", i, j), Model: "test", Vector: []float32{1, 0}}
+				if err := index.Add("rick", "morty", mem); err != nil {
+					t.Errorf("Failed to add: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	count, err := NewMemoryIndex(dir).Count("rick", "morty")
+	if err != nil || count != 100 {
+		t.Errorf("Expected all 100 entries to be kept, got %d (%v)", count, err)
+	}
+}
+
+func TestMemoryIndexSkipsTornLine(t *testing.T) {
+	dir := t.TempDir()
+	index := NewMemoryIndex(dir)
+	if err := index.Add("rick", "morty", IndexedMemory{ID: "a", Model: "test", Vector: []float32{1, 0}}); err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+
+	// A crash mid-write leaves half a line behind
+	filename := filepath.Join(dir, "rick", "morty.jsonl")
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"torn","vec`)
+	f.Close()
+
+	if err := index.Add("rick", "morty", IndexedMemory{ID: "b", Model: "test", Vector: []float32{1, 0}}); err != nil {
+		t.Fatalf("Failed to add after a torn line: %v", err)
+	}
+	results, err := NewMemoryIndex(dir).Search("rick", "morty", []float32{1, 0}, "test", 5, 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected the two complete entries, got %+v", results)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,6 +14,9 @@ import (
 	"github.com/dotcommander/roleplay/internal/models"
 )
 
+// ErrSessionNotFound is returned by LoadSession when no session file exists
+var ErrSessionNotFound = errors.New("session not found")
+
 // Session represents a conversation session
 type Session struct {
 	ID           string           `json:"id"`
@@ -23,17 +27,33 @@ type Session struct {
 	Messages     []SessionMessage `json:"messages"`
 	Memories     []models.Memory  `json:"memories"`
 	CacheMetrics CacheMetrics     `json:"cache_metrics"`
+
+	// Summary is a rolling summary of the first SummarizedCount messages,
+	// which no longer fit the model's context window
+	Summary         string `json:"summary,omitempty"`
+	SummarizedCount int    `json:"summarized_count,omitempty"`
 }
 
 // SessionMessage represents a single message in a session
 type SessionMessage struct {
-	Timestamp    time.Time `json:"timestamp"`
-	Role         string    `json:"role"` // "user" or "character"
-	Content      string    `json:"content"`
-	TokensUsed   int       `json:"tokens_used,omitempty"`
-	CachedTokens int       `json:"cached_tokens,omitempty"` // Tokens served from OpenAI cache
-	CacheHits    int       `json:"cache_hits,omitempty"`
-	CacheMisses  int       `json:"cache_misses,omitempty"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Role         string        `json:"role"` // "user" or "character"
+	Content      string        `json:"content"`
+	TokensUsed   int           `json:"tokens_used,omitempty"`
+	CachedTokens int           `json:"cached_tokens,omitempty"` // Tokens served from OpenAI cache
+	CacheHits    int           `json:"cache_hits,omitempty"`
+	CacheMisses  int           `json:"cache_misses,omitempty"`
+	Usage        *MessageUsage `json:"usage,omitempty"` // Set on character replies
+}
+
+// MessageUsage records which model produced a reply and what it cost
+type MessageUsage struct {
+	Provider         string  `json:"provider,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`                 // USD
+	CostSaved        float64 `json:"cost_saved,omitempty"` // USD saved by caching
 }
 
 // CacheMetrics tracks cache performance for the session
@@ -106,7 +126,7 @@ func (s *SessionRepository) LoadSession(characterID, sessionID string) (*Session
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("session %s not found", sessionID)
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 		}
 		return nil, fmt.Errorf("failed to read session file: %w", err)
 	}
@@ -192,3 +212,74 @@ func (s *SessionRepository) GetLatestSession(characterID string) (*Session, erro
 
 	return s.LoadSession(characterID, latest.ID)
 }
+
+// SessionQuery selects the messages SearchSessions returns
+type SessionQuery struct {
+	Text        string // Matched case-insensitively against message content
+	CharacterID string // All characters when empty
+	UserID      string // All users when empty
+	Limit       int    // No limit when zero
+}
+
+// SessionMatch is a message found by SearchSessions
+type SessionMatch struct {
+	CharacterID string    `json:"character_id"`
+	SessionID   string    `json:"session_id"`
+	UserID      string    `json:"user_id"`
+	Role        string    `json:"role"`
+	Content     string    `json:"content"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// SearchSessions returns the messages containing query.Text, newest first
+func (s *SessionRepository) SearchSessions(query SessionQuery) ([]SessionMatch, error) {
+	characterIDs := []string{query.CharacterID}
+	if query.CharacterID == "" {
+		entries, err := os.ReadDir(filepath.Join(s.dataDir, "sessions"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read sessions directory: %w", err)
+		}
+		characterIDs = characterIDs[:0]
+		for _, entry := range entries {
+			if entry.IsDir() {
+				characterIDs = append(characterIDs, entry.Name())
+			}
+		}
+	}
+
+	text := strings.ToLower(query.Text)
+	matches := []SessionMatch{}
+	for _, characterID := range characterIDs {
+		infos, err := s.ListSessions(characterID)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			session, err := s.LoadSession(characterID, info.ID)
+			if err != nil || (query.UserID != "" && session.UserID != query.UserID) {
+				continue
+			}
+			for _, msg := range session.Messages {
+				if !strings.Contains(strings.ToLower(msg.Content), text) {
+					continue
+				}
+				matches = append(matches, SessionMatch{
+					CharacterID: characterID,
+					SessionID:   session.ID,
+					UserID:      session.UserID,
+					Role:        msg.Role,
+					Content:     msg.Content,
+					Timestamp:   msg.Timestamp,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Timestamp.After(matches[j].Timestamp)
+	})
+	if query.Limit > 0 && len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+	return matches, nil
+}
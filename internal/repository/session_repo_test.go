@@ -152,6 +152,45 @@ func TestSessionList(t *testing.T) {
 	}
 }
 
+func TestSearchSessions(t *testing.T) {
+	repo := NewSessionRepository(t.TempDir())
+	now := time.Now()
+
+	sessions := []*Session{
+		{ID: "s1", CharacterID: "char1", UserID: "alice", Messages: []SessionMessage{
+			{Role: "user", Content: "Tell me about the Dragon", Timestamp: now.Add(-2 * time.Hour)},
+			{Role: "character", Content: "The dragon sleeps beneath the hill", Timestamp: now.Add(-time.Hour)},
+		}},
+		{ID: "s2", CharacterID: "char2", UserID: "bob", Messages: []SessionMessage{
+			{Role: "user", Content: "Any dragons nearby?", Timestamp: now},
+			{Role: "character", Content: "Only cats", Timestamp: now},
+		}},
+	}
+	for _, session := range sessions {
+		if err := repo.SaveSession(session); err != nil {
+			t.Fatalf("Failed to save session %s: %v", session.ID, err)
+		}
+	}
+
+	matches, err := repo.SearchSessions(SessionQuery{Text: "dragon"})
+	if err != nil {
+		t.Fatalf("Failed to search sessions: %v", err)
+	}
+	if len(matches) != 3 || matches[0].SessionID != "s2" || matches[2].Content != "Tell me about the Dragon" {
+		t.Errorf("Expected all three dragon messages, newest first, got %+v", matches)
+	}
+
+	matches, _ = repo.SearchSessions(SessionQuery{Text: "dragon", UserID: "alice", Limit: 1})
+	if len(matches) != 1 || matches[0].Role != "character" || matches[0].CharacterID != "char1" {
+		t.Errorf("Expected alice's newest dragon message, got %+v", matches)
+	}
+
+	matches, _ = repo.SearchSessions(SessionQuery{Text: "dragon", CharacterID: "missing"})
+	if len(matches) != 0 {
+		t.Errorf("Expected no matches for an unknown character, got %+v", matches)
+	}
+}
+
 // TestSessionStats is commented out as GetSessionStats is not implemented
 // This would test aggregate statistics across multiple sessions
 
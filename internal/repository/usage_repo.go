@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dotcommander/roleplay/internal/atomicfile"
+	"github.com/dotcommander/roleplay/internal/filelock"
+)
+
+// UsageRecord is the running token and cost total of one user or character
+// for the current day and month
+type UsageRecord struct {
+	Day           string  `json:"day"`   // YYYY-MM-DD the daily totals belong to
+	Month         string  `json:"month"` // YYYY-MM the monthly totals belong to
+	DailyTokens   int     `json:"daily_tokens"`
+	DailyCost     float64 `json:"daily_cost"`
+	MonthlyTokens int     `json:"monthly_tokens"`
+	MonthlyCost   float64 `json:"monthly_cost"`
+}
+
+// UsageRepository persists usage totals for budget enforcement.
+// Records are stored as <dataDir>/<scope>/<id>.json, where scope is "users"
+// or "characters".
+type UsageRepository struct {
+	dataDir string
+	mu      sync.Mutex
+}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository(dataDir string) *UsageRepository {
+	return &UsageRepository{dataDir: dataDir}
+}
+
+// usageFilename returns the path of the usage record of id within scope
+func (r *UsageRepository) usageFilename(scope, id string) string {
+	return filepath.Join(r.dataDir, scope, fmt.Sprintf("%s.json", id))
+}
+
+// LoadUsage returns the usage record of id, or an empty record when none was saved
+func (r *UsageRepository) LoadUsage(scope, id string) (*UsageRecord, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, fmt.Errorf("%s %w", scope, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.load(scope, id)
+}
+
+// UpdateUsage loads the record of id, applies update and saves it, holding
+// the repository lock and a file lock so concurrent updates in this and other
+// processes are not lost
+func (r *UsageRepository) UpdateUsage(scope, id string, update func(*UsageRecord)) (*UsageRecord, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, fmt.Errorf("%s %w", scope, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lock, err := filelock.Acquire(filepath.Join(r.dataDir, ".lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	record, err := r.load(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	update(record)
+
+	if err := os.MkdirAll(filepath.Join(r.dataDir, scope), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	if err := atomicfile.Write(r.usageFilename(scope, id), data, 0644); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// load reads a usage record; the caller holds r.mu
+func (r *UsageRepository) load(scope, id string) (*UsageRecord, error) {
+	data, err := os.ReadFile(r.usageFilename(scope, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &UsageRecord{}, nil
+		}
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	var record UsageRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal usage: %w", err)
+	}
+	return &record, nil
+}
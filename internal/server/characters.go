@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+func (s *Server) handleListCharacters(w http.ResponseWriter, r *http.Request) {
+	infos, err := s.mgr.ListAvailableCharacters()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	if infos == nil {
+		infos = []repository.CharacterInfo{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"characters": infos})
+}
+
+func (s *Server) handleCreateCharacter(w http.ResponseWriter, r *http.Request) {
+	var char models.Character
+	if !decodeBody(w, r, &char) {
+		return
+	}
+	if repository.ValidateID(char.ID) != nil {
+		writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid character id %q", char.ID))
+		return
+	}
+	if !validCharacter(w, &char) {
+		return
+	}
+	if _, err := s.mgr.GetOrLoadCharacter(char.ID); err == nil {
+		writeError(w, http.StatusConflict, "conflict", fmt.Sprintf("character %s already exists", char.ID))
+		return
+	}
+
+	if err := s.mgr.CreateCharacter(&char); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", fmt.Sprintf("failed to create character: %v", err))
+		return
+	}
+	writeCharacter(w, http.StatusCreated, &char)
+}
+
+func (s *Server) handleGetCharacter(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	char, err := s.mgr.GetOrLoadCharacter(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	writeCharacter(w, http.StatusOK, char)
+}
+
+func (s *Server) handleUpdateCharacter(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	var char models.Character
+	if !decodeBody(w, r, &char) {
+		return
+	}
+	if char.ID != "" && char.ID != id {
+		writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("character id %q does not match the path", char.ID))
+		return
+	}
+	char.ID = id
+	if !validCharacter(w, &char) {
+		return
+	}
+	if _, err := s.mgr.GetOrLoadCharacter(id); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	if err := s.mgr.UpdateCharacter(&char); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", fmt.Sprintf("failed to update character: %v", err))
+		return
+	}
+	writeCharacter(w, http.StatusOK, &char)
+}
+
+func (s *Server) handleDeleteCharacter(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	if _, err := s.mgr.GetOrLoadCharacter(id); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	if err := s.mgr.DeleteCharacter(id); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", fmt.Sprintf("failed to delete character: %v", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validCharacter checks the fields a character needs, answering with an
+// error and returning false when they are missing or out of range
+func validCharacter(w http.ResponseWriter, char *models.Character) bool {
+	if char.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid_character", "character name is required")
+		return false
+	}
+	if char.Generation != nil {
+		if err := char.Generation.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_character", fmt.Sprintf("invalid generation settings: %v", err))
+			return false
+		}
+	}
+	return true
+}
+
+// writeCharacter writes a character while holding its lock, since the bot
+// updates mood and memories in the background
+func writeCharacter(w http.ResponseWriter, status int, char *models.Character) {
+	char.RLock()
+	defer char.RUnlock()
+	writeJSON(w, status, char)
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/dotcommander/roleplay/internal/services"
+)
+
+// chatRequest is the body of POST /chat
+type chatRequest struct {
+	CharacterID string `json:"character_id"`
+	UserID      string `json:"user_id"`
+	SessionID   string `json:"session_id,omitempty"` // A new session is started when empty
+	ScenarioID  string `json:"scenario_id,omitempty"`
+	Message     string `json:"message"`
+	NoCache     bool   `json:"no_cache,omitempty"` // Always ask the provider and don't cache the reply
+	Stream      bool   `json:"stream,omitempty"`   // Answer with server-sent token events
+}
+
+// chatResponse mirrors the output of 'roleplay chat --format json'
+type chatResponse struct {
+	SessionID    string           `json:"session_id"`
+	Response     string           `json:"response"`
+	Provider     string           `json:"provider"`
+	Model        string           `json:"model"`
+	CacheMetrics chatCacheMetrics `json:"cache_metrics"`
+	TokenUsage   chatTokenUsage   `json:"token_usage"`
+	Cost         float64          `json:"cost"`
+	CostSaved    float64          `json:"cost_saved"`
+}
+
+type chatCacheMetrics struct {
+	CacheHit    bool     `json:"cache_hit"`
+	Layers      []string `json:"layers"`
+	SavedTokens int      `json:"saved_tokens"`
+	LatencyMS   int64    `json:"latency_ms"`
+}
+
+type chatTokenUsage struct {
+	Prompt       int `json:"prompt"`
+	Completion   int `json:"completion"`
+	CachedPrompt int `json:"cached_prompt"`
+	Total        int `json:"total"`
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !s.validChatRequest(w, req) {
+		return
+	}
+
+	policy := models.CacheUse
+	if req.NoCache {
+		policy = models.CacheBypass
+	}
+	chatReq := manager.ChatRequest{
+		CharacterID: req.CharacterID,
+		UserID:      req.UserID,
+		SessionID:   req.SessionID,
+		ScenarioID:  req.ScenarioID,
+		Message:     req.Message,
+		CachePolicy: policy,
+	}
+	if req.Stream {
+		s.streamChat(w, r, chatReq)
+		return
+	}
+
+	result, err := s.mgr.Chat(r.Context(), chatReq)
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatResponse(result))
+}
+
+// streamChat answers with a token event per chunk of the reply and a final
+// reply event holding the chat response. An error before the first chunk gets
+// a normal error response; one after it an error event.
+func (s *Server) streamChat(w http.ResponseWriter, r *http.Request, req manager.ChatRequest) {
+	chunks := make(chan providers.PartialAIResponse)
+	req.Stream = chunks
+
+	type outcome struct {
+		result *manager.ChatResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := s.mgr.Chat(r.Context(), req)
+		done <- outcome{result, err}
+	}()
+
+	sse := &sseWriter{w: w}
+	for part := range chunks {
+		if part.Content != "" {
+			sse.named("token", services.TokenData{Content: part.Content})
+		}
+	}
+
+	out := <-done
+	if out.err != nil {
+		status, code := chatErrorStatus(w, out.err)
+		if !sse.started {
+			writeError(w, status, code, out.err.Error())
+			return
+		}
+		sse.named("error", errorBody{Error: errorDetail{Code: code, Message: out.err.Error()}})
+		return
+	}
+	sse.named("reply", newChatResponse(out.result))
+}
+
+// newChatResponse describes a completed chat turn
+func newChatResponse(result *manager.ChatResult) chatResponse {
+	resp := result.Response
+	layers := make([]string, len(resp.CacheMetrics.Layers))
+	for i, layer := range resp.CacheMetrics.Layers {
+		layers[i] = string(layer)
+	}
+	return chatResponse{
+		SessionID: result.Session.ID,
+		Response:  resp.Content,
+		Provider:  resp.Provider,
+		Model:     resp.Model,
+		CacheMetrics: chatCacheMetrics{
+			CacheHit:    resp.CacheMetrics.Hit,
+			Layers:      layers,
+			SavedTokens: resp.CacheMetrics.SavedTokens,
+			LatencyMS:   resp.CacheMetrics.Latency.Milliseconds(),
+		},
+		TokenUsage: chatTokenUsage{
+			Prompt:       resp.TokensUsed.Prompt,
+			Completion:   resp.TokensUsed.Completion,
+			CachedPrompt: resp.TokensUsed.CachedPrompt,
+			Total:        resp.TokensUsed.Total,
+		},
+		Cost:      resp.Cost,
+		CostSaved: resp.CostSaved,
+	}
+}
+
+// validChatRequest checks a chat request names a message, a user and a
+// character and scenario that exist, answering with an error and returning
+// false when it does not
+func (s *Server) validChatRequest(w http.ResponseWriter, req chatRequest) bool {
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "message is required")
+		return false
+	}
+	for _, field := range []struct{ name, value string }{
+		{"character_id", req.CharacterID},
+		{"user_id", req.UserID},
+	} {
+		if repository.ValidateID(field.value) != nil {
+			writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid %s %q", field.name, field.value))
+			return false
+		}
+	}
+	for _, field := range []struct{ name, value string }{
+		{"session_id", req.SessionID},
+		{"scenario_id", req.ScenarioID},
+	} {
+		if field.value != "" && repository.ValidateID(field.value) != nil {
+			writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid %s %q", field.name, field.value))
+			return false
+		}
+	}
+
+	if _, err := s.mgr.GetOrLoadCharacter(req.CharacterID); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return false
+	}
+	if req.ScenarioID != "" {
+		if _, err := s.mgr.GetScenarioRepository().LoadScenario(req.ScenarioID); err != nil {
+			writeError(w, http.StatusNotFound, "not_found", err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// writeChatError answers with the status of a failed chat
+func writeChatError(w http.ResponseWriter, err error) {
+	status, code := chatErrorStatus(w, err)
+	writeError(w, status, code, err.Error())
+}
+
+// chatErrorStatus maps a failed chat to a status and error code, setting
+// Retry-After when a rate limit was hit
+func chatErrorStatus(w http.ResponseWriter, err error) (int, string) {
+	var limited *services.RateLimitExceededError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+	}
+	return chatErrorCode(err)
+}
+
+// chatErrorCode maps a failed chat to a status and error code: rate limits
+// and quotas are the client's to wait out, anything else is the provider's
+// fault
+func chatErrorCode(err error) (int, string) {
+	var limited *services.RateLimitExceededError
+	var quota *services.QuotaError
+	switch {
+	case errors.Is(err, manager.ErrSessionOwner):
+		return http.StatusForbidden, "session_forbidden"
+	case errors.As(err, &limited):
+		return http.StatusTooManyRequests, "rate_limited"
+	case errors.As(err, &quota):
+		return http.StatusTooManyRequests, "quota_exceeded"
+	default:
+		return http.StatusBadGateway, "chat_failed"
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/dotcommander/roleplay/internal/services"
+)
+
+// eventKeepAlive is how often idle event streams are pinged
+const eventKeepAlive = 30 * time.Second
+
+// wsMessage is a message from a WebSocket client. The only type is "chat",
+// which sends a turn in the subscribed session; its reply streams back as
+// token events.
+type wsMessage struct {
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+	ScenarioID string `json:"scenario_id,omitempty"`
+	NoCache    bool   `json:"no_cache,omitempty"`
+}
+
+// wsError reports a failed client message without closing the connection
+type wsError struct {
+	Type  string      `json:"type"` // Always "error"
+	Error errorDetail `json:"error"`
+}
+
+// eventFilter reads a subscription from the query, answering with an error
+// and returning false when it is not valid. character_id is required and
+// session_id narrows it to one session; the session's user, whose state
+// events it also receives, comes from user_id or the saved session.
+func (s *Server) eventFilter(w http.ResponseWriter, r *http.Request) (services.EventFilter, bool) {
+	query := r.URL.Query()
+	filter := services.EventFilter{
+		CharacterID: query.Get("character_id"),
+		UserID:      query.Get("user_id"),
+		SessionID:   query.Get("session_id"),
+	}
+	if repository.ValidateID(filter.CharacterID) != nil {
+		writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid character_id %q", filter.CharacterID))
+		return filter, false
+	}
+	for _, field := range []struct{ name, value string }{
+		{"user_id", filter.UserID},
+		{"session_id", filter.SessionID},
+	} {
+		if field.value != "" && repository.ValidateID(field.value) != nil {
+			writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid %s %q", field.name, field.value))
+			return filter, false
+		}
+	}
+
+	if _, err := s.mgr.GetOrLoadCharacter(filter.CharacterID); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return filter, false
+	}
+	if filter.SessionID != "" && filter.UserID == "" {
+		// A session that doesn't exist yet only gets its own token and reply events
+		if session, err := s.mgr.GetSessionRepository().LoadSession(filter.CharacterID, filter.SessionID); err == nil {
+			filter.UserID = session.UserID
+		}
+	}
+	return filter, true
+}
+
+// handleEvents streams a character's or session's events as server-sent
+// events named after their type
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, ok := s.eventFilter(w, r)
+	if !ok {
+		return
+	}
+	sub := s.mgr.GetBot().Events().Subscribe(filter)
+	defer sub.Close()
+
+	sse := &sseWriter{w: w}
+	sse.comment("subscribed") // Sends the headers before the first event
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			sse.named(string(event.Type), event)
+		case <-keepAlive.C:
+			sse.comment("keepalive")
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// handleEventsWebSocket sends a character's or session's events as JSON text
+// messages, and takes chat messages for the subscribed session
+func (s *Server) handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, ok := s.eventFilter(w, r)
+	if !ok {
+		return
+	}
+	conn, ok := upgradeWebSocket(w, r)
+	if !ok {
+		return
+	}
+	sub := s.mgr.GetBot().Events().Subscribe(filter)
+	defer sub.Close()
+
+	// The hijacked connection outlives the request context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	readErr := make(chan error, 1)
+	go func() { readErr <- s.readWebSocket(ctx, conn, filter) }()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				conn.Close(wsCloseGoingAway, "server stopping")
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				conn.Close(wsCloseGoingAway, "")
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.Ping(); err != nil {
+				conn.Close(wsCloseGoingAway, "")
+				return
+			}
+		case err := <-readErr:
+			switch {
+			case errors.Is(err, errWSTooBig):
+				conn.Close(wsCloseTooBig, err.Error())
+			case errors.Is(err, errWSProtocol):
+				conn.Close(wsCloseProtocolError, err.Error())
+			default:
+				conn.Close(wsCloseNormal, "")
+			}
+			return
+		case <-s.closing:
+			conn.Close(wsCloseGoingAway, "server stopping")
+			// Let a chat in flight finish before its context is cancelled
+			<-readErr
+			return
+		}
+	}
+}
+
+// readWebSocket handles client messages until the connection ends. A chat
+// runs to completion before the next message is read.
+func (s *Server) readWebSocket(ctx context.Context, conn *wsConn, filter services.EventFilter) error {
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			conn.WriteJSON(wsError{Type: "error", Error: errorDetail{Code: "invalid_json", Message: fmt.Sprintf("invalid message: %v", err)}})
+			continue
+		}
+		if detail, ok := s.chatOverWebSocket(ctx, filter, msg); !ok {
+			conn.WriteJSON(wsError{Type: "error", Error: detail})
+		}
+	}
+}
+
+// chatOverWebSocket sends msg as a streamed turn in the subscribed session,
+// returning the error to report when it fails
+func (s *Server) chatOverWebSocket(ctx context.Context, filter services.EventFilter, msg wsMessage) (errorDetail, bool) {
+	switch {
+	case msg.Type != "chat":
+		return errorDetail{Code: "invalid_request", Message: fmt.Sprintf("unknown message type %q", msg.Type)}, false
+	case filter.SessionID == "" || filter.UserID == "":
+		return errorDetail{Code: "invalid_request", Message: "chat needs a subscription to a session with a user"}, false
+	case msg.Message == "":
+		return errorDetail{Code: "invalid_request", Message: "message is required"}, false
+	}
+	if msg.ScenarioID != "" {
+		if repository.ValidateID(msg.ScenarioID) != nil {
+			return errorDetail{Code: "invalid_id", Message: fmt.Sprintf("invalid scenario_id %q", msg.ScenarioID)}, false
+		}
+		if _, err := s.mgr.GetScenarioRepository().LoadScenario(msg.ScenarioID); err != nil {
+			return errorDetail{Code: "not_found", Message: err.Error()}, false
+		}
+	}
+
+	if !s.startChat() {
+		return errorDetail{Code: "unavailable", Message: "server is stopping"}, false
+	}
+	defer s.chats.Done()
+
+	policy := models.CacheUse
+	if msg.NoCache {
+		policy = models.CacheBypass
+	}
+
+	// The reply reaches the client as token events, so the chunks are dropped
+	chunks := make(chan providers.PartialAIResponse)
+	go func() {
+		for range chunks {
+		}
+	}()
+	_, err := s.mgr.Chat(ctx, manager.ChatRequest{
+		CharacterID: filter.CharacterID,
+		UserID:      filter.UserID,
+		SessionID:   filter.SessionID,
+		ScenarioID:  msg.ScenarioID,
+		Message:     msg.Message,
+		CachePolicy: policy,
+		Stream:      chunks,
+	})
+	if err != nil {
+		_, code := chatErrorCode(err)
+		return errorDetail{Code: code, Message: err.Error()}, false
+	}
+	return errorDetail{}, true
+}
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/dotcommander/roleplay/internal/services"
+)
+
+// readSSE returns the name and data of the next event, skipping comments
+func readSSE(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && data != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	ts := newTestServer(t)
+	providers.SetGlobalMockResponses([]string{"Once upon a time..."})
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	body := `{"character_id":"narrator","user_id":"alice","message":"Tell me a story","stream":true}`
+	resp, err := ts.Client().Post(ts.URL+"/chat", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	r := bufio.NewReader(resp.Body)
+	name, data := readSSE(t, r)
+	var token services.TokenData
+	if err := json.Unmarshal([]byte(data), &token); name != "token" || err != nil || token.Content != "Once upon a time..." {
+		t.Errorf("Expected a token event with the reply, got %s %s", name, data)
+	}
+
+	name, data = readSSE(t, r)
+	var reply chatResponse
+	if err := json.Unmarshal([]byte(data), &reply); name != "reply" || err != nil || reply.Response != "Once upon a time..." || reply.SessionID == "" {
+		t.Errorf("Expected a reply event with the chat response, got %s %s", name, data)
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	ts := newTestServer(t)
+	providers.SetGlobalMockResponses([]string{"Once upon a time..."})
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/events?character_id=narrator")
+	if err != nil {
+		t.Fatalf("Subscribing failed: %v", err)
+	}
+	defer resp.Body.Close()
+	r := bufio.NewReader(resp.Body)
+	if line, _ := r.ReadString('\n'); line != ": subscribed\n" {
+		t.Fatalf("Expected the subscription to be confirmed, got %q", line)
+	}
+
+	do(t, ts, "POST", "/chat", chatRequest{CharacterID: "narrator", UserID: "alice", SessionID: "s1", Message: "Tell me a story"}, nil)
+
+	// State events may come first; the reply ends the turn
+	for {
+		name, data := readSSE(t, r)
+		if name != "reply" {
+			continue
+		}
+		var event struct {
+			services.Event
+			Data services.ReplyData `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if event.SessionID != "s1" || event.UserID != "alice" || event.Data.Content != "Once upon a time..." {
+			t.Errorf("Unexpected reply event: %s", data)
+		}
+		return
+	}
+}
+
+// dialWebSocket opens a WebSocket to path on ts
+func dialWebSocket(t *testing.T, ts *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	return dialWebSocketAddr(t, ts.Listener.Addr().String(), path)
+}
+
+// dialWebSocketAddr opens a WebSocket to path on the server listening at addr
+func dialWebSocketAddr(t *testing.T, addr, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	req := "GET " + path + " HTTP/1.1\r\nHost: " + addr +
+		"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: " + key + "\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("Expected the upgrade to be accepted, got %d %v", resp.StatusCode, resp.Header)
+	}
+	return conn, r
+}
+
+// writeClientFrame sends a masked frame, as clients must
+func writeClientFrame(t *testing.T, conn net.Conn, opcode byte, payload []byte) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Failed to write frame: %v", err)
+	}
+}
+
+// readServerFrame reads an unmasked frame
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(r, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestEventsWebSocketChat(t *testing.T) {
+	ts := newTestServer(t)
+	providers.SetGlobalMockResponses([]string{"Once upon a time..."})
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	conn, r := dialWebSocket(t, ts, "/events/ws?character_id=narrator&session_id=ws1&user_id=alice")
+
+	writeClientFrame(t, conn, wsText, []byte(`{"type":"nonsense"}`))
+	if _, payload := readServerFrame(t, r); !strings.Contains(string(payload), `"code":"invalid_request"`) {
+		t.Errorf("Expected an error for an unknown message type, got %s", payload)
+	}
+
+	writeClientFrame(t, conn, wsText, []byte(`{"type":"chat","message":"Tell me a story"}`))
+	var tokens strings.Builder
+	for {
+		opcode, payload := readServerFrame(t, r)
+		if opcode != wsText {
+			continue
+		}
+		var event services.Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			t.Fatalf("Failed to decode event %s: %v", payload, err)
+		}
+		if event.Type == services.EventToken {
+			tokens.WriteString(event.Data.(map[string]interface{})["content"].(string))
+		}
+		if event.Type == services.EventReply {
+			break
+		}
+	}
+	if tokens.String() != "Once upon a time..." {
+		t.Errorf("Expected the reply to stream as tokens, got %q", tokens.String())
+	}
+
+	writeClientFrame(t, conn, wsClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+	if opcode, _ := readServerFrame(t, r); opcode != wsClose {
+		t.Errorf("Expected the close to be answered, got opcode %d", opcode)
+	}
+
+	var session repository.Session
+	if status := do(t, ts, "GET", "/characters/narrator/sessions/ws1", nil, &session); status != http.StatusOK || len(session.Messages) != 2 {
+		t.Errorf("Expected the turn to be recorded in the session, got %d %+v", status, session.Messages)
+	}
+}
+
+func TestEventsErrors(t *testing.T) {
+	ts := newTestServer(t)
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		code   string
+	}{
+		{"missing character", "/events", http.StatusBadRequest, "invalid_id"},
+		{"unknown character", "/events?character_id=nobody", http.StatusNotFound, "not_found"},
+		{"invalid session", "/events?character_id=narrator&session_id=.hidden", http.StatusBadRequest, "invalid_id"},
+		{"plain request to websocket", "/events/ws?character_id=narrator", http.StatusUpgradeRequired, "websocket_required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr errorBody
+			status := do(t, ts, "GET", tt.path, nil, &apiErr)
+			if status != tt.status || apiErr.Error.Code != tt.code {
+				t.Errorf("Expected %d %s, got %d %+v", tt.status, tt.code, status, apiErr)
+			}
+		})
+	}
+}
+
+func TestServeEndsEventStreamsOnShutdown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := manager.NewCharacterManagerWithoutProvider(&config.Config{DefaultProvider: "mock"})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.GetBot().Stop()
+	if err := mgr.CreateCharacter(&models.Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(mgr).Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/events?character_id=narrator")
+	if err != nil {
+		t.Fatalf("Subscribing failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for shutdown with an open event stream")
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Errorf("Expected the event stream to end cleanly, got %v", err)
+	}
+}
+
+// gatedProvider holds each reply until release is closed
+type gatedProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *gatedProvider) Name() string { return "gated" }
+
+func (p *gatedProvider) SendRequest(ctx context.Context, req *providers.PromptRequest) (*providers.AIResponse, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return &providers.AIResponse{Content: "The end."}, nil
+}
+
+func (p *gatedProvider) SendStreamRequest(ctx context.Context, req *providers.PromptRequest, out chan<- providers.PartialAIResponse) error {
+	defer close(out)
+	resp, err := p.SendRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	out <- providers.PartialAIResponse{Content: resp.Content, Done: true}
+	return nil
+}
+
+func TestServeWaitsForWebSocketChats(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := manager.NewCharacterManagerWithoutProvider(&config.Config{DefaultProvider: "mock"})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.GetBot().Stop()
+	provider := &gatedProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	mgr.UseProvider(provider)
+	if err := mgr.CreateCharacter(&models.Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(mgr).Serve(ctx, ln) }()
+
+	conn, _ := dialWebSocketAddr(t, ln.Addr().String(), "/events/ws?character_id=narrator&session_id=ws1&user_id=alice")
+	writeClientFrame(t, conn, wsText, []byte(`{"type":"chat","message":"Finish the story"}`))
+	<-provider.started
+
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected shutdown to wait for the chat, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(provider.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for shutdown")
+	}
+
+	session, err := mgr.GetSessionRepository().LoadSession("narrator", "ws1")
+	if err != nil || len(session.Messages) != 2 {
+		t.Errorf("Expected the turn to be recorded before shutdown returned, got %v %+v", err, session)
+	}
+}
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// defaultOpenAIUser is the user ID of chat completions that name no user
+const defaultOpenAIUser = "openai"
+
+// openAIMessage is a message in an OpenAI chat completion request or reply
+type openAIMessage struct {
+	Role    string        `json:"role,omitempty"`
+	Content openAIContent `json:"content"`
+}
+
+// openAIContent is message text, which requests may also send as an array
+// of content parts; only text parts are kept
+type openAIContent string
+
+func (c *openAIContent) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = openAIContent(text)
+		return nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("content must be a string or an array of content parts")
+	}
+	var joined strings.Builder
+	for _, part := range parts {
+		if part.Type == "text" {
+			joined.WriteString(part.Text)
+		}
+	}
+	*c = openAIContent(joined.String())
+	return nil
+}
+
+// chatCompletionRequest is the body of POST /v1/chat/completions. Sampler
+// settings are not accepted, since characters and scenarios carry their own.
+type chatCompletionRequest struct {
+	Model    string          `json:"model"` // A character ID
+	Messages []openAIMessage `json:"messages"`
+	Stream   bool            `json:"stream,omitempty"`
+	User     string          `json:"user,omitempty"`
+	Scenario string          `json:"scenario,omitempty"` // Extension: a scenario ID
+}
+
+type chatCompletion struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+	Usage   *chatCompletionUsage   `json:"usage,omitempty"`
+}
+
+type chatCompletionChoice struct {
+	Index        int            `json:"index"`
+	Message      *openAIMessage `json:"message,omitempty"`
+	Delta        *openAIMessage `json:"delta,omitempty"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+type chatCompletionUsage struct {
+	PromptTokens        int                 `json:"prompt_tokens"`
+	CompletionTokens    int                 `json:"completion_tokens"`
+	TotalTokens         int                 `json:"total_tokens"`
+	PromptTokensDetails promptTokensDetails `json:"prompt_tokens_details"`
+}
+
+type promptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// openAIModel describes a character as a model in GET /v1/models
+type openAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+func (s *Server) handleListModels(w http.ResponseWriter, r *http.Request) {
+	infos, err := s.mgr.ListAvailableCharacters()
+	if err != nil {
+		writeOpenAIError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+
+	data := make([]openAIModel, 0, len(infos))
+	for _, info := range infos {
+		data = append(data, s.characterModel(info.ID))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"object": "list", "data": data})
+}
+
+func (s *Server) handleGetModel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("model")
+	if repository.ValidateID(id) != nil {
+		writeOpenAIError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("the model %q does not exist", id))
+		return
+	}
+	if _, err := s.mgr.GetOrLoadCharacter(id); err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("the model %q does not exist", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.characterModel(id))
+}
+
+// characterModel describes a character as a model
+func (s *Server) characterModel(id string) openAIModel {
+	model := openAIModel{ID: id, Object: "model", OwnedBy: "roleplay"}
+	if char, err := s.mgr.GetOrLoadCharacter(id); err == nil {
+		char.RLock()
+		model.Created = char.LastModified.Unix()
+		char.RUnlock()
+	}
+	return model
+}
+
+// handleChatCompletions answers an OpenAI chat completion as the character
+// named by the model. The last message is the user's turn and the ones before
+// it the conversation so far; system messages are dropped, since the
+// character supplies the system prompt.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_json", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	chatReq, ok := s.completionChatRequest(w, req)
+	if !ok {
+		return
+	}
+
+	id := completionID()
+	created := time.Now().Unix()
+	if req.Stream {
+		s.streamChatCompletion(w, r, chatReq, id, created)
+		return
+	}
+
+	result, err := s.mgr.Chat(r.Context(), chatReq)
+	if err != nil {
+		status, code := chatErrorStatus(w, err)
+		writeOpenAIError(w, status, code, err.Error())
+		return
+	}
+
+	resp := result.Response
+	writeJSON(w, http.StatusOK, chatCompletion{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   req.Model,
+		Choices: []chatCompletionChoice{{
+			Message:      &openAIMessage{Role: "assistant", Content: openAIContent(resp.Content)},
+			FinishReason: finishReason("stop"),
+		}},
+		Usage: &chatCompletionUsage{
+			PromptTokens:        resp.TokensUsed.Prompt,
+			CompletionTokens:    resp.TokensUsed.Completion,
+			TotalTokens:         resp.TokensUsed.Total,
+			PromptTokensDetails: promptTokensDetails{CachedTokens: resp.TokensUsed.CachedPrompt},
+		},
+	})
+}
+
+// completionChatRequest turns a chat completion into a session turn,
+// answering with an error and returning false when it is not valid
+func (s *Server) completionChatRequest(w http.ResponseWriter, req chatCompletionRequest) (manager.ChatRequest, bool) {
+	if repository.ValidateID(req.Model) != nil {
+		writeOpenAIError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("the model %q does not exist", req.Model))
+		return manager.ChatRequest{}, false
+	}
+	if _, err := s.mgr.GetOrLoadCharacter(req.Model); err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("the model %q does not exist", req.Model))
+		return manager.ChatRequest{}, false
+	}
+	if req.Scenario != "" {
+		if repository.ValidateID(req.Scenario) != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid scenario %q", req.Scenario))
+			return manager.ChatRequest{}, false
+		}
+		if _, err := s.mgr.GetScenarioRepository().LoadScenario(req.Scenario); err != nil {
+			writeOpenAIError(w, http.StatusNotFound, "not_found", err.Error())
+			return manager.ChatRequest{}, false
+		}
+	}
+	userID := req.User
+	if userID == "" {
+		userID = defaultOpenAIUser
+	}
+	if repository.ValidateID(userID) != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid user %q", userID))
+		return manager.ChatRequest{}, false
+	}
+
+	var history []models.Message
+	for _, msg := range req.Messages {
+		if msg.Role == "user" || msg.Role == "assistant" {
+			history = append(history, models.Message{Role: msg.Role, Content: string(msg.Content)})
+		}
+	}
+	if len(history) == 0 || history[len(history)-1].Role != "user" {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request", "messages must end with a user message")
+		return manager.ChatRequest{}, false
+	}
+	last := history[len(history)-1]
+
+	// Each client user keeps one running session per character, which the
+	// user profile agent reads from
+	return manager.ChatRequest{
+		CharacterID: req.Model,
+		UserID:      userID,
+		SessionID:   "openai-" + userID,
+		ScenarioID:  req.Scenario,
+		Message:     last.Content,
+		History:     history[:len(history)-1],
+	}, true
+}
+
+// streamChatCompletion answers with server-sent chat.completion.chunk events,
+// ending with data: [DONE]. An error before the first chunk gets a normal
+// error response; one after it an error event.
+func (s *Server) streamChatCompletion(w http.ResponseWriter, r *http.Request, req manager.ChatRequest, id string, created int64) {
+	chunks := make(chan providers.PartialAIResponse)
+	req.Stream = chunks
+
+	type outcome struct {
+		result *manager.ChatResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := s.mgr.Chat(r.Context(), req)
+		done <- outcome{result, err}
+	}()
+
+	sse := &sseWriter{w: w}
+	chunk := func(delta openAIMessage, finish *string) {
+		sse.event(chatCompletion{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   req.CharacterID,
+			Choices: []chatCompletionChoice{{Delta: &delta, FinishReason: finish}},
+		})
+	}
+
+	for part := range chunks {
+		if part.Content == "" {
+			continue
+		}
+		if !sse.started {
+			chunk(openAIMessage{Role: "assistant"}, nil)
+		}
+		chunk(openAIMessage{Content: openAIContent(part.Content)}, nil)
+	}
+
+	out := <-done
+	if out.err != nil {
+		status, code := chatErrorStatus(w, out.err)
+		if !sse.started {
+			writeOpenAIError(w, status, code, out.err.Error())
+			return
+		}
+		sse.event(errorBody{Error: errorDetail{Code: code, Message: out.err.Error(), Type: openAIErrorType(status, code)}})
+		return
+	}
+
+	if !sse.started {
+		chunk(openAIMessage{Role: "assistant"}, nil)
+	}
+	chunk(openAIMessage{}, finishReason("stop"))
+	sse.done()
+}
+
+// writeOpenAIError answers in OpenAI's error format
+func writeOpenAIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorBody{Error: errorDetail{Code: code, Message: message, Type: openAIErrorType(status, code)}})
+}
+
+// openAIErrorType maps a status to the error types OpenAI clients expect
+func openAIErrorType(status int, code string) string {
+	switch {
+	case code == "quota_exceeded":
+		return "insufficient_quota"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status < 500:
+		return "invalid_request_error"
+	default:
+		return "api_error"
+	}
+}
+
+func finishReason(reason string) *string {
+	return &reason
+}
+
+// completionID returns a random chat completion ID
+func completionID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+func TestListModels(t *testing.T) {
+	ts := newTestServer(t)
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	var list struct {
+		Object string        `json:"object"`
+		Data   []openAIModel `json:"data"`
+	}
+	if status := do(t, ts, "GET", "/v1/models", nil, &list); status != http.StatusOK {
+		t.Fatalf("Expected 200 listing models, got %d", status)
+	}
+	if list.Object != "list" || len(list.Data) != 1 || list.Data[0].ID != "narrator" || list.Data[0].Object != "model" {
+		t.Errorf("Expected the narrator to be listed as a model, got %+v", list)
+	}
+
+	var apiErr errorBody
+	if status := do(t, ts, "GET", "/v1/models/nobody", nil, &apiErr); status != http.StatusNotFound || apiErr.Error.Code != "model_not_found" {
+		t.Errorf("Expected an unknown model to be missing, got %d %+v", status, apiErr)
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	ts := newTestServer(t)
+	providers.SetGlobalMockResponses([]string{"Once upon a time..."})
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	body := map[string]interface{}{
+		"model": "narrator",
+		"user":  "alice",
+		"messages": []map[string]interface{}{
+			{"role": "system", "content": "Ignored"},
+			{"role": "user", "content": "Hello"},
+			{"role": "assistant", "content": "Greetings"},
+			{"role": "user", "content": []map[string]string{{"type": "text", "text": "Tell me a story"}}},
+		},
+	}
+	var completion chatCompletion
+	if status := do(t, ts, "POST", "/v1/chat/completions", body, &completion); status != http.StatusOK {
+		t.Fatalf("Expected 200 from chat completions, got %d", status)
+	}
+	if completion.Object != "chat.completion" || completion.Model != "narrator" || !strings.HasPrefix(completion.ID, "chatcmpl-") {
+		t.Errorf("Unexpected completion: %+v", completion)
+	}
+	if len(completion.Choices) != 1 || completion.Choices[0].Message == nil {
+		t.Fatalf("Expected one choice with a message, got %+v", completion.Choices)
+	}
+	choice := completion.Choices[0]
+	if choice.Message.Role != "assistant" || choice.Message.Content != "Once upon a time..." {
+		t.Errorf("Unexpected message: %+v", choice.Message)
+	}
+	if choice.FinishReason == nil || *choice.FinishReason != "stop" {
+		t.Errorf("Expected finish reason stop, got %v", choice.FinishReason)
+	}
+
+	var session repository.Session
+	if status := do(t, ts, "GET", "/characters/narrator/sessions/openai-alice", nil, &session); status != http.StatusOK {
+		t.Fatalf("Expected the turn to be recorded in the user's session, got %d", status)
+	}
+	if len(session.Messages) != 2 || session.Messages[0].Content != "Tell me a story" {
+		t.Errorf("Expected the session to hold the new turn only, got %+v", session.Messages)
+	}
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	ts := newTestServer(t)
+	providers.SetGlobalMockResponses([]string{"Once upon a time, there was a narrator."})
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	body := `{"model":"narrator","stream":true,"messages":[{"role":"user","content":"Tell me a story"}]}`
+	resp, err := ts.Client().Post(ts.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Chat completion failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			events = append(events, data)
+		}
+	}
+	if len(events) < 3 || events[len(events)-1] != "[DONE]" {
+		t.Fatalf("Expected chunks ending with [DONE], got %v", events)
+	}
+
+	var content strings.Builder
+	var finish string
+	for i, event := range events[:len(events)-1] {
+		var chunk chatCompletion
+		if err := json.Unmarshal([]byte(event), &chunk); err != nil {
+			t.Fatalf("Failed to decode chunk %q: %v", event, err)
+		}
+		if chunk.Object != "chat.completion.chunk" || len(chunk.Choices) != 1 || chunk.Choices[0].Delta == nil {
+			t.Fatalf("Unexpected chunk: %s", event)
+		}
+		if i == 0 && chunk.Choices[0].Delta.Role != "assistant" {
+			t.Errorf("Expected the first chunk to carry the role, got %s", event)
+		}
+		content.WriteString(string(chunk.Choices[0].Delta.Content))
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+	if content.String() != "Once upon a time, there was a narrator." {
+		t.Errorf("Expected the streamed content to add up to the reply, got %q", content.String())
+	}
+	if finish != "stop" {
+		t.Errorf("Expected the last chunk to finish with stop, got %q", finish)
+	}
+}
+
+func TestChatCompletionErrors(t *testing.T) {
+	ts := newTestServer(t)
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	user := []map[string]string{{"role": "user", "content": "Hi"}}
+	tests := []struct {
+		name    string
+		body    interface{}
+		status  int
+		code    string
+		errType string
+	}{
+		{"unknown model", map[string]interface{}{"model": "nobody", "messages": user}, http.StatusNotFound, "model_not_found", "invalid_request_error"},
+		{"unknown scenario", map[string]interface{}{"model": "narrator", "scenario": "nowhere", "messages": user}, http.StatusNotFound, "not_found", "invalid_request_error"},
+		{"no user message", map[string]interface{}{"model": "narrator", "messages": []map[string]string{{"role": "system", "content": "Hi"}}}, http.StatusBadRequest, "invalid_request", "invalid_request_error"},
+		{"invalid content", map[string]interface{}{"model": "narrator", "messages": []map[string]interface{}{{"role": "user", "content": 42}}}, http.StatusBadRequest, "invalid_json", "invalid_request_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr errorBody
+			status := do(t, ts, "POST", "/v1/chat/completions", tt.body, &apiErr)
+			if status != tt.status || apiErr.Error.Code != tt.code || apiErr.Error.Type != tt.errType {
+				t.Errorf("Expected %d %s %s, got %d %+v", tt.status, tt.code, tt.errType, status, apiErr)
+			}
+		})
+	}
+}
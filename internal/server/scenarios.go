@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+func (s *Server) handleListScenarios(w http.ResponseWriter, r *http.Request) {
+	scenarios, err := s.mgr.GetScenarioRepository().ListScenarios()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	if scenarios == nil {
+		scenarios = []*models.Scenario{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"scenarios": scenarios})
+}
+
+func (s *Server) handleCreateScenario(w http.ResponseWriter, r *http.Request) {
+	var scenario models.Scenario
+	if !decodeBody(w, r, &scenario) {
+		return
+	}
+	if repository.ValidateID(scenario.ID) != nil {
+		writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid scenario id %q", scenario.ID))
+		return
+	}
+	if !validScenario(w, &scenario) {
+		return
+	}
+	repo := s.mgr.GetScenarioRepository()
+	if _, err := repo.LoadScenario(scenario.ID); err == nil {
+		writeError(w, http.StatusConflict, "conflict", fmt.Sprintf("scenario %s already exists", scenario.ID))
+		return
+	}
+
+	if scenario.Version == 0 {
+		scenario.Version = 1
+	}
+	if err := repo.SaveScenario(&scenario); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", fmt.Sprintf("failed to save scenario: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, &scenario)
+}
+
+func (s *Server) handleGetScenario(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	scenario, err := s.mgr.GetScenarioRepository().LoadScenario(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, scenario)
+}
+
+// handleUpdateScenario replaces a scenario, bumping its version when the
+// prompt changes
+func (s *Server) handleUpdateScenario(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	var scenario models.Scenario
+	if !decodeBody(w, r, &scenario) {
+		return
+	}
+	if scenario.ID != "" && scenario.ID != id {
+		writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("scenario id %q does not match the path", scenario.ID))
+		return
+	}
+	scenario.ID = id
+	if !validScenario(w, &scenario) {
+		return
+	}
+	repo := s.mgr.GetScenarioRepository()
+	existing, err := repo.LoadScenario(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	scenario.CreatedAt = existing.CreatedAt
+	scenario.LastUsed = existing.LastUsed
+	scenario.Version = existing.Version
+	if scenario.Prompt != existing.Prompt {
+		scenario.Version++
+	}
+	if err := repo.SaveScenario(&scenario); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", fmt.Sprintf("failed to save scenario: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, &scenario)
+}
+
+func (s *Server) handleDeleteScenario(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	repo := s.mgr.GetScenarioRepository()
+	if _, err := repo.LoadScenario(id); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	if err := repo.DeleteScenario(id); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validScenario checks the fields a scenario needs, answering with an error
+// and returning false when they are missing or out of range
+func validScenario(w http.ResponseWriter, scenario *models.Scenario) bool {
+	if scenario.Prompt == "" {
+		writeError(w, http.StatusBadRequest, "invalid_scenario", "scenario prompt is required")
+		return false
+	}
+	if scenario.Generation != nil {
+		if err := scenario.Generation.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_scenario", fmt.Sprintf("invalid generation settings: %v", err))
+			return false
+		}
+	}
+	return true
+}
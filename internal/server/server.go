@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// shutdownTimeout is how long requests in flight get to finish on shutdown
+const shutdownTimeout = 15 * time.Second
+
+// maxBodyBytes bounds request bodies
+const maxBodyBytes = 1 << 20
+
+// Server exposes a CharacterManager's characters, scenarios, sessions, user
+// profiles and chat over a JSON HTTP API
+type Server struct {
+	mgr *manager.CharacterManager
+	mux *http.ServeMux
+
+	// closing is closed on shutdown to end event streams, which would
+	// otherwise hold it up
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// chats tracks WebSocket chats in flight; their connections are
+	// hijacked, so Shutdown does not wait for them
+	chatsMu sync.Mutex
+	chats   sync.WaitGroup
+}
+
+// New creates a server for mgr
+func New(mgr *manager.CharacterManager) *Server {
+	s := &Server{mgr: mgr, mux: http.NewServeMux(), closing: make(chan struct{})}
+	s.routes()
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+
+	s.mux.HandleFunc("GET /characters", s.handleListCharacters)
+	s.mux.HandleFunc("POST /characters", s.handleCreateCharacter)
+	s.mux.HandleFunc("GET /characters/{id}", s.handleGetCharacter)
+	s.mux.HandleFunc("PUT /characters/{id}", s.handleUpdateCharacter)
+	s.mux.HandleFunc("DELETE /characters/{id}", s.handleDeleteCharacter)
+
+	s.mux.HandleFunc("GET /characters/{id}/sessions", s.handleListSessions)
+	s.mux.HandleFunc("GET /characters/{id}/sessions/{session}", s.handleGetSession)
+
+	s.mux.HandleFunc("GET /scenarios", s.handleListScenarios)
+	s.mux.HandleFunc("POST /scenarios", s.handleCreateScenario)
+	s.mux.HandleFunc("GET /scenarios/{id}", s.handleGetScenario)
+	s.mux.HandleFunc("PUT /scenarios/{id}", s.handleUpdateScenario)
+	s.mux.HandleFunc("DELETE /scenarios/{id}", s.handleDeleteScenario)
+
+	s.mux.HandleFunc("GET /users/{user}/profiles", s.handleListProfiles)
+	s.mux.HandleFunc("GET /users/{user}/profiles/{character}", s.handleGetProfile)
+	s.mux.HandleFunc("DELETE /users/{user}/profiles/{character}", s.handleDeleteProfile)
+
+	s.mux.HandleFunc("POST /chat", s.handleChat)
+
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	s.mux.HandleFunc("GET /events/ws", s.handleEventsWebSocket)
+
+	// OpenAI-compatible facade: each character is a model
+	s.mux.HandleFunc("GET /v1/models", s.handleListModels)
+	s.mux.HandleFunc("GET /v1/models/{model}", s.handleGetModel)
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+
+	// Anything else gets the same error envelope as the API's own errors
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	})
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run serves on addr until ctx is cancelled, then stops accepting
+// connections and waits for requests in flight to finish
+func (s *Server) Run(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(s.close)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+
+	// Shutdown calls s.close in its own goroutine; calling it here makes
+	// sure no chat starts while the drain waits
+	s.close()
+	chatsDone := make(chan struct{})
+	go func() {
+		s.chats.Wait()
+		close(chatsDone)
+	}()
+	select {
+	case <-chatsDone:
+	case <-shutdownCtx.Done():
+		return fmt.Errorf("failed to shut down: %w", shutdownCtx.Err())
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// close ends event streams and stops WebSocket chats from starting
+func (s *Server) close() {
+	s.closeOnce.Do(func() {
+		s.chatsMu.Lock()
+		defer s.chatsMu.Unlock()
+		close(s.closing)
+	})
+}
+
+// startChat registers a WebSocket chat with the shutdown drain, reporting
+// false once the server is stopping
+func (s *Server) startChat() bool {
+	s.chatsMu.Lock()
+	defer s.chatsMu.Unlock()
+	select {
+	case <-s.closing:
+		return false
+	default:
+	}
+	s.chats.Add(1)
+	return true
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// errorBody is the envelope of every error response
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"` // OpenAI's error type, set on /v1 routes
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to write response: %v\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorBody{Error: errorDetail{Code: code, Message: message}})
+}
+
+// decodeBody reads a JSON request body into v, answering with an error and
+// returning false when it cannot
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// pathID reads an ID from the request path, answering with an error and
+// returning false when it is not valid
+func pathID(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	id := r.PathValue(name)
+	if repository.ValidateID(id) != nil {
+		writeError(w, http.StatusBadRequest, "invalid_id", fmt.Sprintf("invalid %s %q", name, id))
+		return "", false
+	}
+	return id, true
+}
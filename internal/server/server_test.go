@@ -0,0 +1,254 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// newTestServer serves a manager backed by the mock provider and a
+// temporary data directory
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	providers.ResetGlobalMock()
+
+	cfg := &config.Config{
+		CacheConfig: config.CacheConfig{
+			CleanupInterval: 5 * time.Minute,
+			DefaultTTL:      10 * time.Minute,
+		},
+		DefaultProvider: "mock",
+		Model:           "mock-model",
+		APIKey:          "mock-key",
+	}
+	mgr, err := manager.NewCharacterManagerWithoutProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	t.Cleanup(mgr.GetBot().Stop)
+
+	ts := httptest.NewServer(New(mgr))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// do sends a request with an optional JSON body and decodes the JSON reply
+// into out, returning the status code
+func do(t *testing.T, ts *httptest.Server, method, path string, body, out interface{}) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestCharacterCRUD(t *testing.T) {
+	ts := newTestServer(t)
+
+	char := models.Character{ID: "narrator", Name: "The Narrator", Backstory: "Tells tales"}
+	if status := do(t, ts, "POST", "/characters", &char, nil); status != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a character, got %d", status)
+	}
+
+	var apiErr errorBody
+	if status := do(t, ts, "POST", "/characters", &char, &apiErr); status != http.StatusConflict || apiErr.Error.Code != "conflict" {
+		t.Errorf("Expected a conflict creating the character twice, got %d %+v", status, apiErr)
+	}
+
+	var list struct {
+		Characters []repository.CharacterInfo `json:"characters"`
+	}
+	do(t, ts, "GET", "/characters", nil, &list)
+	if len(list.Characters) != 1 || list.Characters[0].ID != "narrator" {
+		t.Errorf("Expected the narrator to be listed, got %+v", list.Characters)
+	}
+
+	char.Backstory = "Tells taller tales"
+	var updated models.Character
+	if status := do(t, ts, "PUT", "/characters/narrator", &char, &updated); status != http.StatusOK {
+		t.Fatalf("Expected 200 updating the character, got %d", status)
+	}
+
+	var got models.Character
+	do(t, ts, "GET", "/characters/narrator", nil, &got)
+	if got.Backstory != "Tells taller tales" {
+		t.Errorf("Expected the updated backstory, got %q", got.Backstory)
+	}
+
+	if status := do(t, ts, "DELETE", "/characters/narrator", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("Expected 204 deleting the character, got %d", status)
+	}
+	apiErr = errorBody{}
+	if status := do(t, ts, "GET", "/characters/narrator", nil, &apiErr); status != http.StatusNotFound || apiErr.Error.Code != "not_found" {
+		t.Errorf("Expected the deleted character to be gone, got %d %+v", status, apiErr)
+	}
+}
+
+func TestScenarioCRUD(t *testing.T) {
+	ts := newTestServer(t)
+
+	scenario := models.Scenario{ID: "tavern", Name: "Tavern", Prompt: "A busy tavern at night."}
+	var created models.Scenario
+	if status := do(t, ts, "POST", "/scenarios", scenario, &created); status != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a scenario, got %d", status)
+	}
+	if created.Version != 1 {
+		t.Errorf("Expected a new scenario to start at version 1, got %d", created.Version)
+	}
+
+	scenario.Prompt = "A quiet tavern at dawn."
+	var updated models.Scenario
+	do(t, ts, "PUT", "/scenarios/tavern", scenario, &updated)
+	if updated.Version != 2 {
+		t.Errorf("Expected a new prompt to bump the version to 2, got %d", updated.Version)
+	}
+
+	var list struct {
+		Scenarios []models.Scenario `json:"scenarios"`
+	}
+	do(t, ts, "GET", "/scenarios", nil, &list)
+	if len(list.Scenarios) != 1 || list.Scenarios[0].Prompt != "A quiet tavern at dawn." {
+		t.Errorf("Expected the updated scenario to be listed, got %+v", list.Scenarios)
+	}
+
+	if status := do(t, ts, "DELETE", "/scenarios/tavern", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("Expected 204 deleting the scenario, got %d", status)
+	}
+	if status := do(t, ts, "GET", "/scenarios/tavern", nil, &errorBody{}); status != http.StatusNotFound {
+		t.Errorf("Expected the deleted scenario to be gone, got %d", status)
+	}
+}
+
+func TestChatRecordsSession(t *testing.T) {
+	ts := newTestServer(t)
+	providers.SetGlobalMockResponses([]string{"Once upon a time...", "And then..."})
+
+	do(t, ts, "POST", "/characters", &models.Character{ID: "narrator", Name: "The Narrator"}, nil)
+
+	var first chatResponse
+	status := do(t, ts, "POST", "/chat", chatRequest{CharacterID: "narrator", UserID: "alice", Message: "Tell me a story"}, &first)
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200 from chat, got %d", status)
+	}
+	if first.Response != "Once upon a time..." || first.SessionID == "" {
+		t.Fatalf("Unexpected chat response: %+v", first)
+	}
+
+	var second chatResponse
+	do(t, ts, "POST", "/chat", chatRequest{CharacterID: "narrator", UserID: "alice", SessionID: first.SessionID, Message: "Go on"}, &second)
+	if second.SessionID != first.SessionID {
+		t.Errorf("Expected the session to continue, got %s", second.SessionID)
+	}
+
+	var sessions struct {
+		Sessions []repository.SessionInfo `json:"sessions"`
+	}
+	do(t, ts, "GET", "/characters/narrator/sessions", nil, &sessions)
+	if len(sessions.Sessions) != 1 || sessions.Sessions[0].MessageCount != 4 {
+		t.Fatalf("Expected one session with both turns, got %+v", sessions.Sessions)
+	}
+
+	var session repository.Session
+	do(t, ts, "GET", "/characters/narrator/sessions/"+first.SessionID, nil, &session)
+	if len(session.Messages) != 4 || session.Messages[3].Content != "And then..." {
+		t.Errorf("Expected the session to hold both turns, got %+v", session.Messages)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"unknown character", "GET", "/characters/nobody", nil, http.StatusNotFound, "not_found"},
+		{"invalid id", "GET", "/characters/.hidden", nil, http.StatusBadRequest, "invalid_id"},
+		{"missing name", "POST", "/characters", &models.Character{ID: "nameless"}, http.StatusBadRequest, "invalid_character"},
+		{"chat with unknown character", "POST", "/chat", chatRequest{CharacterID: "nobody", UserID: "alice", Message: "Hi"}, http.StatusNotFound, "not_found"},
+		{"chat without message", "POST", "/chat", chatRequest{CharacterID: "nobody", UserID: "alice"}, http.StatusBadRequest, "invalid_request"},
+		{"missing profile", "GET", "/users/alice/profiles/narrator", nil, http.StatusNotFound, "not_found"},
+		{"unknown route", "GET", "/nowhere", nil, http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr errorBody
+			status := do(t, ts, tt.method, tt.path, tt.body, &apiErr)
+			if status != tt.status || apiErr.Error.Code != tt.code || apiErr.Error.Message == "" {
+				t.Errorf("Expected %d %s, got %d %+v", tt.status, tt.code, status, apiErr)
+			}
+		})
+	}
+}
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := manager.NewCharacterManagerWithoutProvider(&config.Config{DefaultProvider: "mock"})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.GetBot().Stop()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(mgr).Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/health")
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	resp.Body.Close()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for shutdown")
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	if _, err := s.mgr.GetOrLoadCharacter(id); err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+
+	sessions, err := s.mgr.GetSessionRepository().ListSessions(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	if sessions == nil {
+		sessions = []repository.SessionInfo{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	sessionID, ok := pathID(w, r, "session")
+	if !ok {
+		return
+	}
+
+	session, err := s.mgr.GetSessionRepository().LoadSession(id, sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "user")
+	if !ok {
+		return
+	}
+
+	profiles, err := s.mgr.GetUserProfileRepository().ListUserProfiles(userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	if profiles == nil {
+		profiles = []*models.UserProfile{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"profiles": profiles})
+}
+
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "user")
+	if !ok {
+		return
+	}
+	characterID, ok := pathID(w, r, "character")
+	if !ok {
+		return
+	}
+
+	profile, err := s.mgr.GetUserProfileRepository().LoadUserProfile(userID, characterID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "not_found",
+				fmt.Sprintf("no profile found for user '%s' with character '%s'", userID, characterID))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (s *Server) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "user")
+	if !ok {
+		return
+	}
+	characterID, ok := pathID(w, r, "character")
+	if !ok {
+		return
+	}
+
+	repo := s.mgr.GetUserProfileRepository()
+	if _, err := repo.LoadUserProfile(userID, characterID); os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, "not_found",
+			fmt.Sprintf("no profile found for user '%s' with character '%s'", userID, characterID))
+		return
+	}
+	if err := repo.DeleteUserProfile(userID, characterID); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
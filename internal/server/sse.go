@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// sseWriter writes server-sent events, sending the headers with the first one
+type sseWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (s *sseWriter) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	h := s.w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// event writes v as an unnamed data event
+func (s *sseWriter) event(v interface{}) {
+	s.named("", v)
+}
+
+// named writes v as a data event of the given name
+func (s *sseWriter) named(name string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to encode event: %v\n", err)
+		return
+	}
+	event := "data: " + string(data) + "\n\n"
+	if name != "" {
+		event = "event: " + name + "\n" + event
+	}
+	s.write(event)
+}
+
+// comment writes a comment line, which clients ignore; it keeps idle
+// connections from timing out
+func (s *sseWriter) comment(text string) {
+	s.write(": " + text + "\n\n")
+}
+
+// done ends the stream the way OpenAI does
+func (s *sseWriter) done() {
+	s.write("data: [DONE]\n\n")
+}
+
+func (s *sseWriter) write(event string) {
+	s.start()
+	if _, err := fmt.Fprint(s.w, event); err != nil {
+		return // The client went away; the chat is cancelled with the request
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -4,31 +4,65 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dotcommander/roleplay/internal/cache"
 	"github.com/dotcommander/roleplay/internal/config"
 	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/pricing"
 	"github.com/dotcommander/roleplay/internal/providers"
 	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/dotcommander/roleplay/internal/tokenizer"
+	"github.com/google/uuid"
 )
 
 // CharacterBot is the main service for managing characters and conversations
 type CharacterBot struct {
 	characters       map[string]*models.Character
+	states           map[string]*models.CharacterState // Keyed by stateKey(character, user)
+	stateStore       CharacterStateStore
 	cache            *cache.PromptCache
 	responseCache    *cache.ResponseCache
 	providers        map[string]providers.AIProvider
+	providerOrder    []string // Registration order, used after the configured chain
+	breakers         map[string]*CircuitBreaker
 	config           *config.Config
 	scenarioRepo     *repository.ScenarioRepository
 	userProfileRepo  *repository.UserProfileRepository
 	userProfileAgent *UserProfileAgent
+	emotionAnalyzer  EmotionAnalyzer
+	persister        *statePersister
+	embedder         providers.Embedder
+	memoryIndex      *repository.MemoryIndex
+	indexing         sync.WaitGroup // Pending memory index writes, awaited by Stop
+	retrievalWarned  atomic.Bool    // Retrieval failures are reported once; see warnRetrieval
+	summarizer       MemorySummarizer
+	tokenizer        tokenizer.Tokenizer
+	pricing          *pricing.Registry
+	unpriced         sync.Map // "provider/model" pairs already warned about
+	budgets          *BudgetTracker // nil when no budgets are configured
+	consolidating    map[string]bool // State keys with a consolidation pass running
+	consolidations   sync.WaitGroup  // Background consolidation passes, awaited by Stop
+	events           *EventBus
+	profileUpdates   sync.WaitGroup // Background user profile updates, awaited by Stop
+	sweeps           sync.WaitGroup // Sweeps of the shared cache store, awaited by Stop
+	stop             chan struct{}  // Closed by Stop to end the background workers
+	stopOnce         sync.Once
+	background       context.Context    // Cancelled by Stop, ending background passes' requests
+	cancelBackground context.CancelFunc
 	rateLimiter      *RateLimiter
+	rateLimitMode    RateLimitMode // RateLimitFail unless set
+	now              func() time.Time // Clock for recorded timestamps; see SetClock
+	logs             io.Writer        // Where warnings go; see SetLogOutput
 	mu               sync.RWMutex
 	cacheHits        int
 	cacheMisses      int
@@ -37,8 +71,7 @@ type CharacterBot struct {
 // NewCharacterBot creates a new character bot instance
 func NewCharacterBot(cfg *config.Config) *CharacterBot {
 	// Get config path for scenario repository
-	home, _ := os.UserHomeDir()
-	configPath := filepath.Join(home, ".config", "roleplay")
+	configPath := cfg.StorageDir()
 	userProfileDataDir := filepath.Join(configPath, "user_profiles")
 
 	// Create user profiles directory if it doesn't exist
@@ -50,6 +83,7 @@ func NewCharacterBot(cfg *config.Config) *CharacterBot {
 
 	cb := &CharacterBot{
 		characters: make(map[string]*models.Character),
+		states:     make(map[string]*models.CharacterState),
 		cache: cache.NewPromptCache(
 			cfg.CacheConfig.DefaultTTL,
 			5*time.Minute,
@@ -57,17 +91,43 @@ func NewCharacterBot(cfg *config.Config) *CharacterBot {
 		),
 		responseCache:   cache.NewResponseCache(cfg.CacheConfig.DefaultTTL),
 		providers:       make(map[string]providers.AIProvider),
+		breakers:        make(map[string]*CircuitBreaker),
 		config:          cfg,
 		scenarioRepo:    repository.NewScenarioRepository(configPath),
 		userProfileRepo: userProfileRepo,
-		rateLimiter:     NewRateLimiter(14, 1*time.Minute), // 14 req/min to stay under 15 limit
+		consolidating:   make(map[string]bool),
+		events:          NewEventBus(),
+		stop:            make(chan struct{}),
+		tokenizer:       tokenizer.ForModel(cfg.Model, cfg.Tokenizer.Dir),
+		pricing:         pricing.NewRegistry(cfg.Pricing),
+		rateLimiter:     NewRateLimiter(cfg.RateLimits),
+		now:             time.Now,
+		logs:            stderr{},
 		cacheHits:       0,
 		cacheMisses:     0,
 	}
 
+	cb.background, cb.cancelBackground = context.WithCancel(context.Background())
+	cb.cache.SetTokenCounter(cb.tokenizer.Count)
+	cb.cache.SetLogOutput(cb.logs)
+	cb.responseCache.SetLogOutput(cb.logs)
+	cb.cache.SetLimits(cfg.CacheConfig.MaxEntries, cfg.CacheConfig.MaxBytes)
+	cb.responseCache.SetLimits(cfg.CacheConfig.MaxEntries, cfg.CacheConfig.MaxBytes)
+
+	if len(cfg.Budgets.Users) > 0 || len(cfg.Budgets.Characters) > 0 {
+		cb.budgets = NewBudgetTracker(cfg.Budgets, repository.NewUsageRepository(filepath.Join(configPath, "usage")))
+		cb.budgets.now = cb.now
+	}
+
+	// The offline lexicon needs no provider, so it is ready immediately
+	if cfg.Emotion.Analyzer != "none" {
+		cb.emotionAnalyzer = NewLexiconEmotionAnalyzer()
+	}
+
 	// Start background workers
 	if cfg.CacheConfig.CleanupInterval > 0 {
-		go cb.cache.CleanupWorker(cfg.CacheConfig.CleanupInterval)
+		go cb.cache.CleanupWorker(cfg.CacheConfig.CleanupInterval, cb.stop)
+		go cb.responseCache.CleanupWorker(cfg.CacheConfig.CleanupInterval, cb.stop)
 	}
 	go cb.memoryConsolidationWorker()
 
@@ -83,11 +143,245 @@ func (cb *CharacterBot) InitializeUserProfileAgent() {
 		return
 	}
 
-	// Get the default provider for the UserProfileAgent
-	if provider, ok := cb.providers[cb.config.DefaultProvider]; ok {
-		cb.userProfileAgent = NewUserProfileAgent(provider, cb.userProfileRepo)
+	// Extraction goes through the failover chain and counts toward budgets
+	if _, ok := cb.providers[cb.config.DefaultProvider]; ok {
+		cb.userProfileAgent = NewUserProfileAgent(meteredProvider{cb}, cb.userProfileRepo)
+		cb.userProfileAgent.logs = cb.logs
+	} else {
+		fmt.Fprintf(cb.logs, "Warning: Default provider %s not found for UserProfileAgent\n", cb.config.DefaultProvider)
+	}
+}
+
+// InitializeEmotionAnalyzer switches to the LLM emotion analyzer when configured.
+// It must be called after the default provider is registered.
+func (cb *CharacterBot) InitializeEmotionAnalyzer() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.config.Emotion.Analyzer != "llm" {
+		return
+	}
+
+	if _, ok := cb.providers[cb.config.DefaultProvider]; ok {
+		cb.emotionAnalyzer = NewLLMEmotionAnalyzer(meteredProvider{cb})
 	} else {
-		fmt.Fprintf(os.Stderr, "Warning: Default provider %s not found for UserProfileAgent\n", cb.config.DefaultProvider)
+		fmt.Fprintf(cb.logs, "Warning: Default provider %s not found for emotion analysis, using lexicon\n", cb.config.DefaultProvider)
+	}
+}
+
+// embeddingProfiles are the provider profiles known to serve the default
+// embedding model. Other OpenAI-compatible endpoints, such as Ollama or Groq,
+// only embed once memory.embedding_model names a model they serve.
+var embeddingProfiles = map[string]bool{"openai": true, "mock": true}
+
+// InitializeEmbedder enables semantic memory retrieval using the first
+// registered provider that can embed, preferring the default.
+// It must be called after providers are registered.
+func (cb *CharacterBot) InitializeEmbedder() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.config.MemoryConfig.RetrievalTopK <= 0 {
+		return
+	}
+
+	for _, name := range append([]string{cb.config.DefaultProvider}, cb.providerOrder...) {
+		if cb.config.MemoryConfig.EmbeddingModel == "" && !embeddingProfiles[strings.ToLower(name)] {
+			continue
+		}
+		if e, ok := cb.providers[name].(providers.Embedder); ok {
+			cb.embedder = e
+			return
+		}
+	}
+}
+
+// SetEmbedder replaces the embedder used for the memory index; nil disables retrieval
+func (cb *CharacterBot) SetEmbedder(embedder providers.Embedder) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.embedder = embedder
+}
+
+// SetTokenizer replaces the tokenizer used to measure prompt layers and history
+func (cb *CharacterBot) SetTokenizer(tok tokenizer.Tokenizer) {
+	cb.mu.Lock()
+	cb.tokenizer = tok
+	cb.mu.Unlock()
+	cb.cache.SetTokenCounter(tok.Count)
+}
+
+// countTokens counts the tokens of text with the model's tokenizer
+func (cb *CharacterBot) countTokens(text string) int {
+	cb.mu.RLock()
+	tok := cb.tokenizer
+	cb.mu.RUnlock()
+	return tok.Count(text)
+}
+
+// SetMemoryIndex sets the vector index that stores and retrieves memories
+func (cb *CharacterBot) SetMemoryIndex(index *repository.MemoryIndex) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.memoryIndex = index
+}
+
+// SetCacheStore shares the prompt cache, response cache and rate limits
+// with other processes through store
+func (cb *CharacterBot) SetCacheStore(store *cache.DiskStore) {
+	cb.cache.SetStore(store)
+	cb.responseCache.SetStore(store)
+	cb.rateLimiter.SetStore(store)
+
+	// Short-lived processes never reach a cleanup tick, so expired entries
+	// left by earlier processes are swept once up front
+	cb.sweeps.Add(1)
+	go func() {
+		defer cb.sweeps.Done()
+		cb.cache.SweepStore()
+		cb.responseCache.SweepStore()
+	}()
+}
+
+// SetEmotionAnalyzer replaces the analyzer run on each reply; nil disables analysis
+func (cb *CharacterBot) SetEmotionAnalyzer(analyzer EmotionAnalyzer) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.emotionAnalyzer = analyzer
+}
+
+// SetStateStore loads per-user character state from store and enables
+// write-behind persistence of mood, memory and personality changes to it
+func (cb *CharacterBot) SetStateStore(store CharacterStateStore) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.stateStore = store
+	cb.persister = newStatePersister(store, cb.lookupState, cb.config.Persistence.SaveDebounce)
+	cb.persister.logs = cb.logs
+}
+
+// SetClock makes the bot read the time from now when it timestamps
+// characters, memories and state, e.g. a fixed clock in tests. It must be
+// called before the bot is used. Budget periods follow the same clock.
+func (cb *CharacterBot) SetClock(now func() time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.now = now
+	if cb.budgets != nil {
+		cb.budgets.now = now
+	}
+}
+
+// Now returns the current time by the bot's clock
+func (cb *CharacterBot) Now() time.Time {
+	return cb.now()
+}
+
+// SetLogOutput sends the bot's warnings and background notices to w instead
+// of stderr. It must be called before the bot is used.
+func (cb *CharacterBot) SetLogOutput(w io.Writer) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.logs = w
+	cb.rateLimiter.logs = w
+	cb.cache.SetLogOutput(w)
+	cb.responseCache.SetLogOutput(w)
+	if cb.budgets != nil {
+		cb.budgets.logs = w
+	}
+	if cb.persister != nil {
+		cb.persister.logs = w
+	}
+	if cb.userProfileAgent != nil {
+		cb.userProfileAgent.logs = w
+	}
+}
+
+// LogOutput returns where the bot writes warnings
+func (cb *CharacterBot) LogOutput() io.Writer {
+	return cb.logs
+}
+
+// stderr writes to os.Stderr as it is at the time of each write
+type stderr struct{}
+
+func (stderr) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
+
+// stateKey identifies the state of a character toward one user
+func stateKey(characterID, userID string) string {
+	return characterID + "::" + userID
+}
+
+// GetCharacterState returns the character's state toward a user, loading it
+// from the state store or starting from the base character on first contact
+func (cb *CharacterBot) GetCharacterState(characterID, userID string) (*models.CharacterState, error) {
+	char, err := cb.GetCharacter(characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := stateKey(characterID, userID)
+	cb.mu.RLock()
+	state, exists := cb.states[key]
+	store := cb.stateStore
+	cb.mu.RUnlock()
+	if exists {
+		return state, nil
+	}
+
+	if store != nil {
+		loaded, err := store.LoadState(characterID, userID)
+		if err == nil {
+			state = loaded
+		} else if !os.IsNotExist(err) {
+			fmt.Fprintf(cb.logs, "Warning: Failed to load state of %s for %s: %v\n", characterID, userID, err)
+		}
+	}
+	if state == nil {
+		char.RLock()
+		state = models.NewCharacterState(char, userID, cb.now())
+		char.RUnlock()
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	// Another request may have loaded it meanwhile
+	if existing, exists := cb.states[key]; exists {
+		return existing, nil
+	}
+	cb.states[key] = state
+	return state, nil
+}
+
+// lookupState returns an already loaded state by key
+func (cb *CharacterBot) lookupState(key string) (*models.CharacterState, bool) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	state, exists := cb.states[key]
+	return state, exists
+}
+
+// FlushCharacterState writes any pending character changes immediately
+func (cb *CharacterBot) FlushCharacterState() error {
+	if p := cb.statePersister(); p != nil {
+		return p.Flush()
+	}
+	return nil
+}
+
+// statePersister returns the configured persister, or nil
+func (cb *CharacterBot) statePersister() *statePersister {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.persister
+}
+
+// markDirty schedules a save of a character's evolved state toward a user
+func (cb *CharacterBot) markDirty(state *models.CharacterState) {
+	if p := cb.statePersister(); p != nil {
+		p.MarkDirty(stateKey(state.CharacterID, state.UserID))
 	}
 }
 
@@ -95,9 +389,28 @@ func (cb *CharacterBot) InitializeUserProfileAgent() {
 func (cb *CharacterBot) RegisterProvider(name string, provider providers.AIProvider) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	if _, exists := cb.providers[name]; !exists {
+		cb.providerOrder = append(cb.providerOrder, name)
+		cb.breakers[name] = NewCircuitBreaker(
+			cb.config.CircuitBreaker.FailureThreshold,
+			cb.config.CircuitBreaker.Cooldown,
+		)
+	}
 	cb.providers[name] = provider
 }
 
+// ProviderCircuitState returns the circuit breaker state for a registered provider
+func (cb *CharacterBot) ProviderCircuitState(name string) (CircuitState, bool) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	breaker, exists := cb.breakers[name]
+	if !exists {
+		return CircuitClosed, false
+	}
+	return breaker.State(), true
+}
+
 // CreateCharacter adds a new character to the bot
 func (cb *CharacterBot) CreateCharacter(char *models.Character) error {
 	cb.mu.Lock()
@@ -107,7 +420,7 @@ func (cb *CharacterBot) CreateCharacter(char *models.Character) error {
 		return fmt.Errorf("character %s already exists", char.ID)
 	}
 
-	char.LastModified = time.Now()
+	char.LastModified = cb.now()
 	cb.characters[char.ID] = char
 
 	// Pre-cache core personality
@@ -116,6 +429,32 @@ func (cb *CharacterBot) CreateCharacter(char *models.Character) error {
 	return nil
 }
 
+// UpdateCharacter replaces a character the bot already knows, re-caching
+// its core personality
+func (cb *CharacterBot) UpdateCharacter(char *models.Character) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if _, exists := cb.characters[char.ID]; !exists {
+		return fmt.Errorf("character %s not found", char.ID)
+	}
+
+	char.LastModified = cb.now()
+	cb.characters[char.ID] = char
+	cb.warmupCache(char)
+
+	return nil
+}
+
+// RemoveCharacter forgets a character and its cached system prompt
+func (cb *CharacterBot) RemoveCharacter(id string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	delete(cb.characters, id)
+	cb.cache.Delete(cb.generateCharacterSystemPromptCacheKey(id))
+}
+
 // GetCharacter retrieves a character by ID
 func (cb *CharacterBot) GetCharacter(id string) (*models.Character, error) {
 	cb.mu.RLock()
@@ -129,47 +468,201 @@ func (cb *CharacterBot) GetCharacter(id string) (*models.Character, error) {
 	return char, nil
 }
 
+// preparedRequest holds the state shared between prompt assembly and response handling
+type preparedRequest struct {
+	char                  *models.Character
+	state                 *models.CharacterState
+	apiReq                *providers.PromptRequest
+	breakpoints           []cache.CacheBreakpoint
+	cacheKey              string
+	cacheHit              bool
+	effectiveTTL          time.Duration
+	responseCacheKey      string
+	characterPromptCached bool
+}
+
 // ProcessRequest handles a conversation request
 func (cb *CharacterBot) ProcessRequest(ctx context.Context, req *models.ConversationRequest) (*providers.AIResponse, error) {
-	// Check rate limit first
-	allowed, _ := cb.rateLimiter.Allow(req.UserID, req.CharacterID)
-	if !allowed {
-		// Return a helpful error message with current rate info
-		currentRate := cb.rateLimiter.GetCurrentRate(req.UserID, req.CharacterID)
-		return nil, fmt.Errorf("rate limit exceeded: %d/14 requests per minute for this user-character pair. Please wait before sending more messages", currentRate)
+	// Check response cache first
+	responseCacheKey := cb.responseCacheKey(req)
+	if cachedResp, found := cb.lookupResponseCache(req, responseCacheKey); found {
+		cb.publishReply(req, cachedResp, true)
+		return cachedResp, nil
 	}
 
-	// Check response cache first
-	responseCacheKey := cb.responseCache.GenerateKey(req.CharacterID, req.UserID, req.Message)
-	if cachedResp, found := cb.responseCache.Get(responseCacheKey); found {
-		cb.mu.Lock()
-		cb.cacheHits++
-		cb.mu.Unlock()
-
-		// Return cached response with cache hit metrics
-		return &providers.AIResponse{
-			Content: cachedResp.Content,
-			TokensUsed: providers.TokenUsage{
-				Prompt:       0,
-				Completion:   0,
-				CachedPrompt: cachedResp.TokensUsed.Prompt,
-				Total:        0,
-			},
-			CacheMetrics: cache.CacheMetrics{
-				Hit:         true,
-				Layers:      []cache.CacheLayer{cache.ConversationLayer},
-				SavedTokens: cachedResp.TokensUsed.Total,
-				Latency:     time.Since(cachedResp.CachedAt),
-			},
-		}, nil
+	// Cached replies are free, so budgets only gate provider requests
+	if err := cb.checkBudget(req.UserID, req.CharacterID); err != nil {
+		return nil, err
+	}
+
+	prep, err := cb.prepareRequest(ctx, req, responseCacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send request, failing over along the provider chain
+	start := time.Now()
+	resp, err := cb.sendWithFailover(ctx, prep.apiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	cb.finalizeResponse(ctx, req, prep, resp, start)
+	cb.publishReply(req, resp, false)
+
+	return resp, nil
+}
+
+// ProcessStreamRequest handles a conversation request while streaming the reply.
+// Chunks are forwarded to out as they arrive; out is closed when the call returns.
+// The returned response carries the full content and runs through the same state
+// update, caching and profile bookkeeping as ProcessRequest.
+func (cb *CharacterBot) ProcessStreamRequest(ctx context.Context, req *models.ConversationRequest, out chan<- providers.PartialAIResponse) (*providers.AIResponse, error) {
+	defer close(out)
+
+	// A cached response is delivered as a single chunk
+	responseCacheKey := cb.responseCacheKey(req)
+	if cachedResp, found := cb.lookupResponseCache(req, responseCacheKey); found {
+		select {
+		case out <- providers.PartialAIResponse{Content: cachedResp.Content, Done: true}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		cb.publishToken(req.CharacterID, req.UserID, req.Context.SessionID, cachedResp.Content)
+		cb.publishReply(req, cachedResp, true)
+		return cachedResp, nil
+	}
+
+	if err := cb.checkBudget(req.UserID, req.CharacterID); err != nil {
+		return nil, err
+	}
+
+	prep, err := cb.prepareRequest(ctx, req, responseCacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	content, providerName, err := cb.streamWithFailover(ctx, prep.apiReq, out)
+	if err != nil {
+		return nil, err
+	}
+
+	// Streaming APIs do not report usage consistently, so estimate it
+	promptTokens := cb.countTokens(prep.apiReq.SystemPrompt)
+	completionTokens := cb.countTokens(content)
+	resp := &providers.AIResponse{
+		Content:  content,
+		Provider: providerName,
+		TokensUsed: providers.TokenUsage{
+			Prompt:     promptTokens,
+			Completion: completionTokens,
+			Total:      promptTokens + completionTokens,
+		},
+	}
+
+	cb.finalizeResponse(ctx, req, prep, resp, start)
+	cb.publishReply(req, resp, false)
+
+	select {
+	case out <- providers.PartialAIResponse{Done: true}:
+	case <-ctx.Done():
+	}
+
+	return resp, nil
+}
+
+// responseCacheHistory is how many recent turns a cached reply depends on
+const responseCacheHistory = 6
+
+// responseCacheKey identifies the reply to a request. A reply is only reused
+// for the same message in the same scenario, after the same recent turns and
+// from the same version of the character.
+func (cb *CharacterBot) responseCacheKey(req *models.ConversationRequest) string {
+	key := cache.ResponseKey{
+		CharacterID: req.CharacterID,
+		UserID:      req.UserID,
+		Message:     req.Message,
+	}
+
+	if char, err := cb.GetCharacter(req.CharacterID); err == nil {
+		char.RLock()
+		key.CharacterVersion = cache.HashText(cb.buildCoreCharacterSystemPrompt(char))
+		char.RUnlock()
+	}
+	if req.ScenarioID != "" {
+		if scenario, err := cb.scenarioRepo.LoadScenario(req.ScenarioID); err == nil {
+			key.Scenario = cache.HashText(scenario.ID, scenario.Prompt)
+		} else {
+			key.Scenario = cache.HashText(req.ScenarioID)
+		}
+	}
+
+	recent := req.Context.RecentMessages
+	if len(recent) > responseCacheHistory {
+		recent = recent[len(recent)-responseCacheHistory:]
+	}
+	history := make([]string, 0, 2*len(recent))
+	for _, msg := range recent {
+		history = append(history, msg.Role, msg.Content)
 	}
+	key.History = cache.HashText(history...)
+
+	return cb.responseCache.GenerateKey(key)
+}
+
+// lookupResponseCache returns a cached response for the key and records the
+// hit or miss; requests that refresh or bypass the cache always miss
+func (cb *CharacterBot) lookupResponseCache(req *models.ConversationRequest, key string) (*providers.AIResponse, bool) {
+	if req.CachePolicy != models.CacheUse {
+		return nil, false
+	}
+	cachedResp, found := cb.responseCache.Get(key)
 
 	cb.mu.Lock()
-	cb.cacheMisses++
+	if found {
+		cb.cacheHits++
+	} else {
+		cb.cacheMisses++
+	}
 	cb.mu.Unlock()
 
+	if !found {
+		return nil, false
+	}
+
+	// Return cached response with cache hit metrics
+	resp := &providers.AIResponse{
+		Content: cachedResp.Content,
+		TokensUsed: providers.TokenUsage{
+			Prompt:       0,
+			Completion:   0,
+			CachedPrompt: cachedResp.TokensUsed.Prompt,
+			Total:        0,
+		},
+		CacheMetrics: cache.CacheMetrics{
+			Hit:         true,
+			Layers:      []cache.CacheLayer{cache.ConversationLayer},
+			SavedTokens: cachedResp.TokensUsed.Total,
+			Latency:     time.Since(cachedResp.CachedAt),
+		},
+	}
+
+	// The whole request was avoided, so it saved what it would have cost
+	if price, ok := cb.lookupPrice(cb.config.DefaultProvider, cb.config.Model); ok {
+		resp.CostSaved = price.Cost(pricing.Usage{
+			Prompt:       cachedResp.TokensUsed.Prompt,
+			CachedPrompt: cachedResp.TokensUsed.CachedPrompt,
+			Completion:   cachedResp.TokensUsed.Completion,
+		})
+	}
+	return resp, true
+}
+
+// prepareRequest builds the layered prompt and selects a provider for the request
+func (cb *CharacterBot) prepareRequest(ctx context.Context, req *models.ConversationRequest, responseCacheKey string) (*preparedRequest, error) {
 	// Build prompt with cache awareness
-	prompt, breakpoints, err := cb.BuildPrompt(req)
+	prompt, breakpoints, err := cb.BuildPromptContext(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -178,32 +671,21 @@ func (cb *CharacterBot) ProcessRequest(ctx context.Context, req *models.Conversa
 	cacheKey := cb.generateCacheKey(req.CharacterID, req.UserID, req.ScenarioID, breakpoints)
 	cachedEntry, hit := cb.cache.Get(cacheKey)
 
-	// Get character for complexity check
+	// Get character and its state toward this user for complexity check
 	char, err := cb.GetCharacter(req.CharacterID)
 	if err != nil {
 		return nil, err
 	}
-
-	// Cache hit tracking is now done internally
-
-	// Adaptive TTL based on conversation activity
-	effectiveTTL := cb.cache.CalculateAdaptiveTTL(cachedEntry, len(char.Memories) > 50)
-
-	// Select provider
-	provider := cb.selectProvider()
-	if provider == nil {
-		return nil, fmt.Errorf("no AI provider available")
+	state, err := cb.GetCharacterState(req.CharacterID, req.UserID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Prepare API request
-	apiReq := &providers.PromptRequest{
-		CharacterID:      req.CharacterID,
-		UserID:           req.UserID,
-		Message:          req.Message,
-		Context:          req.Context,
-		SystemPrompt:     prompt,
-		CacheBreakpoints: breakpoints,
-	}
+	// Adaptive TTL based on conversation activity
+	state.RLock()
+	busy := len(state.Memories) > 50
+	state.RUnlock()
+	effectiveTTL := cb.cache.CalculateAdaptiveTTL(cachedEntry, busy)
 
 	// Check if character system prompt was cached
 	characterPromptCached := false
@@ -214,23 +696,62 @@ func (cb *CharacterBot) ProcessRequest(ctx context.Context, req *models.Conversa
 		}
 	}
 
-	// Send request
-	start := time.Now()
-	resp, err := provider.SendRequest(ctx, apiReq)
-	if err != nil {
-		return nil, err
+	return &preparedRequest{
+		char:  char,
+		state: state,
+		apiReq: &providers.PromptRequest{
+			CharacterID:      req.CharacterID,
+			UserID:           req.UserID,
+			Message:          req.Message,
+			Context:          req.Context,
+			SystemPrompt:     prompt,
+			CacheBreakpoints: breakpoints,
+			Generation:       cb.resolveGeneration(char, req.ScenarioID),
+		},
+		breakpoints:           breakpoints,
+		cacheKey:              cacheKey,
+		cacheHit:              hit,
+		effectiveTTL:          effectiveTTL,
+		responseCacheKey:      responseCacheKey,
+		characterPromptCached: characterPromptCached,
+	}, nil
+}
+
+// resolveGeneration layers sampler settings: scenario over character over config defaults
+func (cb *CharacterBot) resolveGeneration(char *models.Character, scenarioID string) models.GenerationParams {
+	var gen models.GenerationParams
+
+	if scenarioID != "" {
+		// BuildPrompt already warned if the scenario could not be loaded
+		if scenario, err := cb.scenarioRepo.LoadScenario(scenarioID); err == nil && scenario.Generation != nil {
+			gen = *scenario.Generation
+		}
+	}
+
+	char.RLock()
+	if char.Generation != nil {
+		gen = gen.Merge(*char.Generation)
 	}
+	char.RUnlock()
 
+	return gen.Merge(cb.config.Generation)
+}
+
+// finalizeResponse records cache metrics, updates character state and caches,
+// and schedules the user profile update for a completed reply
+func (cb *CharacterBot) finalizeResponse(ctx context.Context, req *models.ConversationRequest, prep *preparedRequest, resp *providers.AIResponse, start time.Time) {
 	// Update cache metrics
 	resp.CacheMetrics.Latency = time.Since(start)
-	
+	cb.priceResponse(resp)
+	cb.recordSpend(req.UserID, req.CharacterID, resp)
+
 	// If we had a response cache hit, keep that info
 	// Otherwise, check if we at least had prompt caching
-	if !resp.CacheMetrics.Hit && characterPromptCached {
+	if !resp.CacheMetrics.Hit && prep.characterPromptCached {
 		resp.CacheMetrics.Hit = true
 		resp.CacheMetrics.Layers = []cache.CacheLayer{cache.CorePersonalityLayer}
 		// Estimate saved tokens from character prompt (this is a rough estimate)
-		for _, bp := range breakpoints {
+		for _, bp := range prep.breakpoints {
 			if bp.Layer == cache.CorePersonalityLayer {
 				resp.CacheMetrics.SavedTokens = bp.TokenCount
 				break
@@ -238,28 +759,44 @@ func (cb *CharacterBot) ProcessRequest(ctx context.Context, req *models.Conversa
 		}
 	}
 
-	// Update character state based on response
-	cb.updateCharacterState(req.CharacterID, resp)
+	// Providers rarely report emotions, so score the reply ourselves
+	cb.analyzeEmotions(withSpendScope(ctx, req.UserID, req.CharacterID), resp)
+
+	// Make the exchange retrievable in later conversations
+	cb.indexExchange(req, resp)
+
+	// Update the character's state toward this user based on response
+	cb.updateCharacterState(prep.state, resp)
 
 	// Store in cache with adaptive TTL
-	if !hit {
-		cb.cache.StoreWithTTL(cacheKey, breakpoints, effectiveTTL)
+	if !prep.cacheHit {
+		cb.cache.StoreEntry(&cache.CacheEntry{
+			Key:         prep.cacheKey,
+			CharacterID: req.CharacterID,
+			UserID:      req.UserID,
+			Breakpoints: prep.breakpoints,
+		}, prep.effectiveTTL)
 	}
 
 	// Store response in response cache
-	cb.responseCache.Store(responseCacheKey, resp.Content, cache.TokenUsage{
-		Prompt:       resp.TokensUsed.Prompt,
-		Completion:   resp.TokensUsed.Completion,
-		CachedPrompt: resp.TokensUsed.CachedPrompt,
-		Total:        resp.TokensUsed.Total,
-	})
+	if req.CachePolicy != models.CacheBypass {
+		cb.responseCache.StoreResponse(prep.responseCacheKey, &cache.CachedResponse{
+			CharacterID: req.CharacterID,
+			UserID:      req.UserID,
+			Content:     resp.Content,
+			TokensUsed: cache.TokenUsage{
+				Prompt:       resp.TokensUsed.Prompt,
+				Completion:   resp.TokensUsed.Completion,
+				CachedPrompt: resp.TokensUsed.CachedPrompt,
+				Total:        resp.TokensUsed.Total,
+			},
+		})
+	}
 
 	// Trigger user profile update asynchronously if enabled
 	if cb.userProfileAgent != nil && cb.config.UserProfileConfig.Enabled {
-		go cb.updateUserProfileAsync(req.UserID, char, req.Context.SessionID)
+		cb.updateUserProfileAsync(req.UserID, prep.char, req.Context.SessionID)
 	}
-
-	return resp, nil
 }
 
 // BuildPrompt constructs a layered prompt with cache breakpoints.
@@ -270,19 +807,30 @@ func (cb *CharacterBot) ProcessRequest(ctx context.Context, req *models.Conversa
 //   2. Dynamic Suffix: Conversation history + Current message (not cached)
 // The prefix remains identical across requests for the same context, maximizing cache hits.
 func (cb *CharacterBot) BuildPrompt(req *models.ConversationRequest) (string, []cache.CacheBreakpoint, error) {
+	return cb.BuildPromptContext(context.Background(), req)
+}
+
+// BuildPromptContext is BuildPrompt with a context bounding memory retrieval.
+// It trims req.Context.RecentMessages to the model's context budget, folding
+// older turns into req.Context.Summary.
+func (cb *CharacterBot) BuildPromptContext(ctx context.Context, req *models.ConversationRequest) (string, []cache.CacheBreakpoint, error) {
 	char, err := cb.GetCharacter(req.CharacterID)
 	if err != nil {
 		return "", nil, err
 	}
+	state, err := cb.GetCharacterState(req.CharacterID, req.UserID)
+	if err != nil {
+		return "", nil, err
+	}
 
 	breakpoints := make([]cache.CacheBreakpoint, 0, 7)
 
 	// Layer -1: System/Admin Layer (global instructions, longest TTL)
 	systemPrompt := cb.buildSystemPrompt()
 	breakpoints = append(breakpoints, cache.CacheBreakpoint{
-		Layer:      cache.CacheLayer("system_admin"),
+		Layer:      cache.SystemAdminLayer,
 		Content:    systemPrompt,
-		TokenCount: cache.EstimateTokens(systemPrompt),
+		TokenCount: cb.countTokens(systemPrompt),
 		TTL:        24 * time.Hour, // Very long TTL for system instructions
 	})
 
@@ -291,7 +839,7 @@ func (cb *CharacterBot) BuildPrompt(req *models.ConversationRequest) (string, []
 		scenario, err := cb.scenarioRepo.LoadScenario(req.ScenarioID)
 		if err != nil {
 			// Log warning but continue without scenario
-			fmt.Fprintf(os.Stderr, "Warning: Failed to load scenario %s: %v\n", req.ScenarioID, err)
+			fmt.Fprintf(cb.logs, "Warning: Failed to load scenario %s: %v\n", req.ScenarioID, err)
 		} else if scenario.Prompt != "" {
 			// Very long TTL for scenario context (7 days by default)
 			scenarioTTL := 168 * time.Hour
@@ -299,7 +847,7 @@ func (cb *CharacterBot) BuildPrompt(req *models.ConversationRequest) (string, []
 			breakpoints = append(breakpoints, cache.CacheBreakpoint{
 				Layer:      cache.ScenarioContextLayer,
 				Content:    scenario.Prompt,
-				TokenCount: cache.EstimateTokens(scenario.Prompt),
+				TokenCount: cb.countTokens(scenario.Prompt),
 				TTL:        scenarioTTL,
 				LastUsed:   time.Now(),
 			})
@@ -333,7 +881,7 @@ func (cb *CharacterBot) BuildPrompt(req *models.ConversationRequest) (string, []
 	// If not found in cache, generate and store it
 	if coreCharacterPrompt == "" {
 		coreCharacterPrompt = cb.buildCoreCharacterSystemPrompt(char)
-		cb.cache.Store(cacheKey, cache.CorePersonalityLayer, coreCharacterPrompt, cb.config.CacheConfig.CoreCharacterSystemPromptTTL)
+		cb.storeCorePrompt(char.ID, coreCharacterPrompt)
 		fromCache = false
 	}
 	
@@ -341,7 +889,7 @@ func (cb *CharacterBot) BuildPrompt(req *models.ConversationRequest) (string, []
 	breakpoint := cache.CacheBreakpoint{
 		Layer:      cache.CorePersonalityLayer,
 		Content:    coreCharacterPrompt,
-		TokenCount: cache.EstimateTokens(coreCharacterPrompt),
+		TokenCount: cb.countTokens(coreCharacterPrompt),
 		TTL:        cb.config.CacheConfig.CoreCharacterSystemPromptTTL,
 		LastUsed:   time.Now(),
 	}
@@ -354,41 +902,43 @@ func (cb *CharacterBot) BuildPrompt(req *models.ConversationRequest) (string, []
 	breakpoints = append(breakpoints, breakpoint)
 
 	// Layer 2: Learned Behaviors (semi-static, medium TTL)
-	behaviors := cb.buildLearnedBehaviors(char)
+	behaviors := cb.buildLearnedBehaviors(char, state)
 	if behaviors != "" {
 		breakpoints = append(breakpoints, cache.CacheBreakpoint{
 			Layer:      cache.LearnedBehaviorLayer,
 			Content:    behaviors,
-			TokenCount: cache.EstimateTokens(behaviors),
+			TokenCount: cb.countTokens(behaviors),
 			TTL:        cb.config.CacheConfig.DefaultTTL * 2,
 		})
 	}
 
 	// Layer 3: Emotional State (dynamic, short TTL)
-	emotional := cb.buildEmotionalContext(char)
+	emotional := cb.buildEmotionalContext(char, state)
 	breakpoints = append(breakpoints, cache.CacheBreakpoint{
 		Layer:      cache.EmotionalStateLayer,
 		Content:    emotional,
-		TokenCount: cache.EstimateTokens(emotional),
+		TokenCount: cb.countTokens(emotional),
 		TTL:        5 * time.Minute,
 	})
 
 	// Layer 4: User Context (semi-dynamic, medium TTL)
-	userContext := cb.buildUserContext(req.UserID, char)
+	recalled := cb.recallMemories(ctx, req)
+	userContext := cb.buildUserContext(req.UserID, char, state, recalled)
 	breakpoints = append(breakpoints, cache.CacheBreakpoint{
 		Layer:      cache.UserMemoryLayer,
 		Content:    userContext,
-		TokenCount: cache.EstimateTokens(userContext),
+		TokenCount: cb.countTokens(userContext),
 		TTL:        cb.config.CacheConfig.DefaultTTL,
 	})
 
-	// Layer 5: Conversation History (dynamic, no cache)
-	conversation := cb.buildConversationHistory(req.Context)
+	// Layer 5: Conversation summary (dynamic, no cache); the turns that fit
+	// the remaining budget are sent as chat messages
+	conversation := cb.fitConversation(ctx, req, char, breakpoints)
 	if conversation != "" {
 		breakpoints = append(breakpoints, cache.CacheBreakpoint{
 			Layer:      cache.ConversationLayer,
 			Content:    conversation,
-			TokenCount: cache.EstimateTokens(conversation),
+			TokenCount: cb.countTokens(conversation),
 			TTL:        0, // No caching for conversation
 		})
 	}
@@ -403,11 +953,22 @@ func (cb *CharacterBot) warmupCache(char *models.Character) {
 	// Build core character system prompt
 	corePrompt := cb.buildCoreCharacterSystemPrompt(char)
 
-	// Create a stable cache key for this character's core system prompt
-	key := cb.generateCharacterSystemPromptCacheKey(char.ID)
-
 	// Store with very long TTL from config
-	cb.cache.Store(key, cache.CorePersonalityLayer, corePrompt, cb.config.CacheConfig.CoreCharacterSystemPromptTTL)
+	cb.storeCorePrompt(char.ID, corePrompt)
+}
+
+// storeCorePrompt caches a character's core system prompt under its stable key
+func (cb *CharacterBot) storeCorePrompt(characterID, corePrompt string) {
+	cb.cache.StoreEntry(&cache.CacheEntry{
+		Key:         cb.generateCharacterSystemPromptCacheKey(characterID),
+		CharacterID: characterID,
+		Breakpoints: []cache.CacheBreakpoint{{
+			Layer:    cache.CorePersonalityLayer,
+			Content:  corePrompt,
+			TTL:      cb.config.CacheConfig.CoreCharacterSystemPromptTTL,
+			LastUsed: time.Now(),
+		}},
+	}, 0)
 }
 
 // generateCharacterSystemPromptCacheKey creates a stable cache key for a character's core system prompt
@@ -420,9 +981,8 @@ func (cb *CharacterBot) generateCharacterSystemPromptCacheKey(characterID string
 func (cb *CharacterBot) InvalidateCharacterCache(characterID string) error {
 	cacheKey := cb.generateCharacterSystemPromptCacheKey(characterID)
 	
-	// Remove from cache by storing empty breakpoints
-	// (Since there's no Delete method, we overwrite with empty data)
-	cb.cache.StoreWithTTL(cacheKey, []cache.CacheBreakpoint{}, 0)
+	// Remove from cache, including the copy shared with other processes
+	cb.cache.Delete(cacheKey)
 	
 	// If character exists, rebuild and cache the prompt
 	if char, err := cb.GetCharacter(characterID); err == nil {
@@ -432,6 +992,16 @@ func (cb *CharacterBot) InvalidateCharacterCache(characterID string) error {
 	return nil
 }
 
+// CharacterSystemPrompt returns the core system prompt defining a character's
+// persona, without user, scenario or conversation context
+func (cb *CharacterBot) CharacterSystemPrompt(characterID string) (string, error) {
+	char, err := cb.GetCharacter(characterID)
+	if err != nil {
+		return "", err
+	}
+	return cb.buildCoreCharacterSystemPrompt(char), nil
+}
+
 // buildCoreCharacterSystemPrompt generates the static, foundational system prompt for a character.
 // This includes all unchanging character attributes that define their core identity.
 // This content is designed to be cached with a very long TTL and exceed 1024 tokens for OpenAI caching.
@@ -602,14 +1172,25 @@ Achievements: %s
 }
 
 
-func (cb *CharacterBot) buildLearnedBehaviors(char *models.Character) string {
-	// Extract patterns from medium-term memories
+func (cb *CharacterBot) buildLearnedBehaviors(char *models.Character, state *models.CharacterState) string {
+	// Extract patterns from medium-term memories: those authored in the base
+	// definition, then those learned with this user
 	patterns := make([]string, 0)
+	char.RLock()
 	for _, mem := range char.Memories {
 		if mem.Type == models.MediumTermMemory {
 			patterns = append(patterns, mem.Content)
 		}
 	}
+	char.RUnlock()
+
+	state.RLock()
+	for _, mem := range state.Memories {
+		if mem.Type == models.MediumTermMemory {
+			patterns = append(patterns, mem.Content)
+		}
+	}
+	state.RUnlock()
 
 	if len(patterns) == 0 {
 		return ""
@@ -618,8 +1199,11 @@ func (cb *CharacterBot) buildLearnedBehaviors(char *models.Character) string {
 	return fmt.Sprintf("[LEARNED PATTERNS]\n%s", strings.Join(patterns, "\n"))
 }
 
-func (cb *CharacterBot) buildEmotionalContext(char *models.Character) string {
-	return fmt.Sprintf(`[EMOTIONAL STATE]
+func (cb *CharacterBot) buildEmotionalContext(char *models.Character, state *models.CharacterState) string {
+	state.RLock()
+	defer state.RUnlock()
+
+	context := fmt.Sprintf(`[EMOTIONAL STATE]
 Current Mood:
 - Joy: %.2f
 - Surprise: %.2f
@@ -627,34 +1211,39 @@ Current Mood:
 - Fear: %.2f
 - Sadness: %.2f
 - Disgust: %.2f`,
-		char.CurrentMood.Joy,
-		char.CurrentMood.Surprise,
-		char.CurrentMood.Anger,
-		char.CurrentMood.Fear,
-		char.CurrentMood.Sadness,
-		char.CurrentMood.Disgust,
+		state.CurrentMood.Joy,
+		state.CurrentMood.Surprise,
+		state.CurrentMood.Anger,
+		state.CurrentMood.Fear,
+		state.CurrentMood.Sadness,
+		state.CurrentMood.Disgust,
 	)
-}
 
-func (cb *CharacterBot) buildConversationHistory(ctx models.ConversationContext) string {
-	if len(ctx.RecentMessages) == 0 {
-		return ""
-	}
+	// Personality drift with this user is layered on the cached core prompt
+	if state.PersonalityDrift != (models.PersonalityTraits{}) {
+		char.RLock()
+		p := state.EffectivePersonality(char.Personality)
+		char.RUnlock()
+		context += fmt.Sprintf(`
 
-	history := "[CONVERSATION HISTORY]\n"
-	for _, msg := range ctx.RecentMessages {
-		history += fmt.Sprintf("%s: %s\n", msg.Role, msg.Content)
+Personality with this user (OCEAN):
+- Openness: %.2f
+- Conscientiousness: %.2f
+- Extraversion: %.2f
+- Agreeableness: %.2f
+- Neuroticism: %.2f`,
+			p.Openness, p.Conscientiousness, p.Extraversion, p.Agreeableness, p.Neuroticism)
 	}
 
-	return history
+	return context
 }
 
-func (cb *CharacterBot) buildUserContext(userID string, char *models.Character) string {
+func (cb *CharacterBot) buildUserContext(userID string, char *models.Character, state *models.CharacterState, recalled []repository.ScoredMemory) string {
 	// Try to load user profile if available
 	if cb.userProfileRepo != nil && cb.config.UserProfileConfig.Enabled {
 		profile, err := cb.userProfileRepo.LoadUserProfile(userID, char.ID)
 		if err == nil && profile != nil {
-			return cb.buildUserProfileLayer(userID, char.ID, profile)
+			return cb.buildUserProfileLayer(userID, char.ID, profile) + formatRecalledMemories(recalled)
 		}
 	}
 
@@ -664,12 +1253,23 @@ You are speaking with: %s
 
 Remember to address them by their name throughout the conversation.`, userID)
 
-	// Add any user-specific memories
+	state.RLock()
+	defer state.RUnlock()
+
+	if state.InteractionCount > 0 {
+		context += fmt.Sprintf("\n\nRelationship: trust %.2f, affection %.2f, familiarity %.2f",
+			state.Relationship.Trust, state.Relationship.Affection, state.Relationship.Familiarity)
+	}
+
+	// Semantic retrieval picks the memories relevant to this message;
+	// without it, fall back to every long-term memory shared with this user
+	if recalled != nil {
+		return context + formatRecalledMemories(recalled)
+	}
+
 	var userMemories []string
-	for _, mem := range char.Memories {
+	for _, mem := range state.Memories {
 		if mem.Type == models.LongTermMemory && mem.Content != "" {
-			// Check if memory mentions this user (simple check)
-			// In a more advanced system, you'd have user-specific memory storage
 			userMemories = append(userMemories, mem.Content)
 		}
 	}
@@ -766,55 +1366,257 @@ func (cb *CharacterBot) generateCacheKey(charID, userID, scenarioID string, brea
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func (cb *CharacterBot) selectProvider() providers.AIProvider {
+// namedProvider pairs a registered provider with its name and circuit breaker
+type namedProvider struct {
+	name     string
+	provider providers.AIProvider
+	breaker  *CircuitBreaker
+}
+
+// providerChain returns the providers to try in order: the default provider,
+// then the configured fallbacks, then anything else that was registered
+func (cb *CharacterBot) providerChain() []namedProvider {
 	cb.mu.RLock()
 	defer cb.mu.RUnlock()
 
-	// Try to get the default provider
-	if provider, exists := cb.providers[cb.config.DefaultProvider]; exists {
-		return provider
+	names := []string{cb.config.DefaultProvider}
+	for _, profile := range cb.config.FallbackProviders {
+		names = append(names, profile.Name)
 	}
+	names = append(names, cb.providerOrder...)
 
-	// Fallback to first available provider
-	for _, p := range cb.providers {
-		return p
+	seen := make(map[string]bool)
+	var chain []namedProvider
+	for _, name := range names {
+		provider, exists := cb.providers[name]
+		if !exists || seen[name] {
+			continue
+		}
+		seen[name] = true
+		chain = append(chain, namedProvider{name: name, provider: provider, breaker: cb.breakers[name]})
 	}
 
-	return nil
+	return chain
+}
+
+// estimateRequestTokens approximates the tokens a request counts against a
+// provider's tokens-per-minute limit: the prompt plus the reply allowance
+func (cb *CharacterBot) estimateRequestTokens(apiReq *providers.PromptRequest) int {
+	tokens := cb.countTokens(apiReq.SystemPrompt) + cb.countTokens(apiReq.Message)
+	for _, msg := range apiReq.Context.RecentMessages {
+		tokens += cb.messageTokens(msg)
+	}
+	if apiReq.Generation.MaxTokens != nil {
+		tokens += *apiReq.Generation.MaxTokens
+	}
+	return tokens
+}
+
+// admit charges a request to the provider's rate limit. Over the limit it
+// fails, unless the bot is in wait mode and this is the last provider left
+// to try, in which case it queues for capacity.
+func (cb *CharacterBot) admit(ctx context.Context, apiReq *providers.PromptRequest, provider string, estimate int, last bool) error {
+	if last && cb.RateLimitMode() == RateLimitWait {
+		return cb.rateLimiter.Wait(ctx, apiReq.UserID, apiReq.CharacterID, provider, estimate)
+	}
+	return cb.rateLimiter.Allow(provider, estimate)
 }
 
-func (cb *CharacterBot) updateCharacterState(charID string, resp *providers.AIResponse) {
-	char, err := cb.GetCharacter(charID)
+// sendWithFailover sends the request to the first provider in the chain that
+// answers, skipping providers whose circuit is open or that are over their
+// rate limit
+func (cb *CharacterBot) sendWithFailover(ctx context.Context, apiReq *providers.PromptRequest) (*providers.AIResponse, error) {
+	chain := cb.providerChain()
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no AI provider available")
+	}
+
+	estimate := cb.estimateRequestTokens(apiReq)
+	var failures []error
+	for i, p := range chain {
+		if !p.breaker.Allow() {
+			failures = append(failures, fmt.Errorf("%s: circuit open", p.name))
+			continue
+		}
+		if err := cb.admit(ctx, apiReq, p.name, estimate, i == len(chain)-1); err != nil {
+			p.breaker.Abandon()
+			if ctx.Err() != nil || len(chain) == 1 {
+				return nil, err
+			}
+			failures = append(failures, err)
+			continue
+		}
+
+		resp, err := p.provider.SendRequest(ctx, apiReq)
+		if err == nil {
+			p.breaker.RecordSuccess()
+			resp.Provider = p.name
+			if resp.TokensUsed.Total > 0 {
+				cb.rateLimiter.Adjust(p.name, resp.TokensUsed.Total-estimate)
+			}
+			return resp, nil
+		}
+
+		// A cancelled request says nothing about the provider's health
+		if ctx.Err() != nil {
+			p.breaker.Abandon()
+			return nil, err
+		}
+
+		p.breaker.RecordFailure()
+		if len(chain) == 1 {
+			return nil, err
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", p.name, err))
+	}
+
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(failures...))
+}
+
+// streamWithFailover streams the reply from the first provider in the chain that
+// answers. Failover only happens before any content has been forwarded to out;
+// once the reply has started, an error ends the stream.
+func (cb *CharacterBot) streamWithFailover(ctx context.Context, apiReq *providers.PromptRequest, out chan<- providers.PartialAIResponse) (string, string, error) {
+	chain := cb.providerChain()
+	if len(chain) == 0 {
+		return "", "", fmt.Errorf("no AI provider available")
+	}
+
+	estimate := cb.estimateRequestTokens(apiReq)
+	var failures []error
+	for i, p := range chain {
+		if !p.breaker.Allow() {
+			failures = append(failures, fmt.Errorf("%s: circuit open", p.name))
+			continue
+		}
+		if err := cb.admit(ctx, apiReq, p.name, estimate, i == len(chain)-1); err != nil {
+			p.breaker.Abandon()
+			if ctx.Err() != nil || len(chain) == 1 {
+				return "", "", err
+			}
+			failures = append(failures, err)
+			continue
+		}
+
+		content, started, err := cb.streamFromProvider(ctx, p.provider, apiReq, out)
+		if err == nil {
+			p.breaker.RecordSuccess()
+			return content, p.name, nil
+		}
+
+		if ctx.Err() != nil {
+			p.breaker.Abandon()
+			return "", "", err
+		}
+
+		p.breaker.RecordFailure()
+		if started || len(chain) == 1 {
+			return "", "", err
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", p.name, err))
+	}
+
+	return "", "", fmt.Errorf("all providers failed: %w", errors.Join(failures...))
+}
+
+// streamFromProvider forwards one provider's chunks to out and returns the
+// accumulated content and whether any content was forwarded
+func (cb *CharacterBot) streamFromProvider(ctx context.Context, provider providers.AIProvider, apiReq *providers.PromptRequest, out chan<- providers.PartialAIResponse) (string, bool, error) {
+	chunks := make(chan providers.PartialAIResponse)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- provider.SendStreamRequest(ctx, apiReq, chunks)
+	}()
+
+	var content strings.Builder
+	for chunk := range chunks {
+		if chunk.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Content)
+		select {
+		case out <- providers.PartialAIResponse{Content: chunk.Content}:
+		case <-ctx.Done():
+			// Drain so the provider goroutine can finish
+			for range chunks {
+			}
+			return "", true, ctx.Err()
+		}
+		cb.publishToken(apiReq.CharacterID, apiReq.UserID, apiReq.Context.SessionID, chunk.Content)
+	}
+
+	if err := <-streamErr; err != nil {
+		return "", content.Len() > 0, err
+	}
+	return content.String(), content.Len() > 0, nil
+}
+
+// emotionAnalysisTimeout bounds how long a reply waits on an LLM emotion analyzer
+const emotionAnalysisTimeout = 10 * time.Second
+
+// analyzeEmotions fills resp.Emotions when the provider left them empty.
+// Analysis failures are non-fatal and leave the mood unchanged.
+func (cb *CharacterBot) analyzeEmotions(ctx context.Context, resp *providers.AIResponse) {
+	cb.mu.RLock()
+	analyzer := cb.emotionAnalyzer
+	cb.mu.RUnlock()
+
+	if analyzer == nil || resp.Emotions != (models.EmotionalState{}) || resp.Content == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, emotionAnalysisTimeout)
+	defer cancel()
+
+	emotions, err := analyzer.Analyze(ctx, resp.Content)
 	if err != nil {
+		fmt.Fprintf(cb.logs, "Warning: Emotion analysis failed: %v\n", err)
 		return
 	}
+	resp.Emotions = emotions
+}
 
-	char.Lock()
-	defer char.Unlock()
+func (cb *CharacterBot) updateCharacterState(state *models.CharacterState, resp *providers.AIResponse) {
+	state.Lock()
 
 	// Update emotional state with decay
-	char.CurrentMood = cb.blendEmotions(char.CurrentMood, resp.Emotions, 0.3)
+	previousMood := state.CurrentMood
+	state.CurrentMood = cb.blendEmotions(state.CurrentMood, resp.Emotions, 0.3)
+	if state.CurrentMood != previousMood {
+		cb.events.Publish(Event{
+			Type:        EventMoodChanged,
+			CharacterID: state.CharacterID,
+			UserID:      state.UserID,
+			Data:        MoodChangeData{Previous: previousMood, Current: state.CurrentMood},
+		})
+	}
+	state.UpdateRelationship(resp.Emotions)
 
 	// Add to short-term memory
 	memory := models.Memory{
+		ID:        uuid.New().String(),
 		Type:      models.ShortTermMemory,
 		Content:   resp.Content,
-		Timestamp: time.Now(),
+		Timestamp: cb.now(),
 		Emotional: cb.calculateEmotionalWeight(resp.Emotions),
 	}
-	char.Memories = append(char.Memories, memory)
+	state.Memories = append(state.Memories, memory)
 
-	// Trigger consolidation if needed
-	if len(char.Memories) > cb.config.MemoryConfig.ShortTermWindow {
-		go cb.consolidateMemories(char)
+	// Trigger consolidation once the short-term window overflows
+	if window := cb.config.MemoryConfig.ShortTermWindow; window > 0 && countMemories(state.Memories, models.ShortTermMemory) > window {
+		cb.scheduleConsolidation(state)
 	}
 
 	// Evolution logic
 	if cb.config.PersonalityConfig.EvolutionEnabled {
-		cb.evolvePersonality(char, resp)
+		cb.evolvePersonality(state, resp)
 	}
 
-	char.LastModified = time.Now()
+	state.LastModified = cb.now()
+	state.Unlock()
+
+	// After Stop the persister saves at once, which needs the state lock
+	cb.markDirty(state)
 }
 
 func (cb *CharacterBot) blendEmotions(current, new models.EmotionalState, rate float64) models.EmotionalState {
@@ -835,20 +1637,22 @@ func (cb *CharacterBot) calculateEmotionalWeight(emotions models.EmotionalState)
 	return total / 6.0
 }
 
-func (cb *CharacterBot) evolvePersonality(char *models.Character, resp *providers.AIResponse) {
+// maxPersonalityDrift bounds how far evolution moves any trait from the base
+const maxPersonalityDrift = 0.2
+
+// evolvePersonality accumulates bounded drift from the base personality
+func (cb *CharacterBot) evolvePersonality(state *models.CharacterState, resp *providers.AIResponse) {
 	// Calculate trait impacts based on interaction
 	impacts := cb.analyzeInteractionImpacts(resp)
 
 	// Apply bounded evolution
 	driftRate := cb.config.PersonalityConfig.MaxDriftRate
-	char.Personality.Openness += impacts.Openness * driftRate
-	char.Personality.Conscientiousness += impacts.Conscientiousness * driftRate
-	char.Personality.Extraversion += impacts.Extraversion * driftRate
-	char.Personality.Agreeableness += impacts.Agreeableness * driftRate
-	char.Personality.Neuroticism += impacts.Neuroticism * driftRate
-
-	// Normalize to keep traits in [0, 1] range
-	char.Personality = models.NormalizePersonality(char.Personality)
+	state.PersonalityDrift.Openness += impacts.Openness * driftRate
+	state.PersonalityDrift.Conscientiousness += impacts.Conscientiousness * driftRate
+	state.PersonalityDrift.Extraversion += impacts.Extraversion * driftRate
+	state.PersonalityDrift.Agreeableness += impacts.Agreeableness * driftRate
+	state.PersonalityDrift.Neuroticism += impacts.Neuroticism * driftRate
+	state.PersonalityDrift = models.ClampPersonality(state.PersonalityDrift, -maxPersonalityDrift, maxPersonalityDrift)
 }
 
 func (cb *CharacterBot) analyzeInteractionImpacts(resp *providers.AIResponse) models.PersonalityTraits {
@@ -868,65 +1672,31 @@ func (cb *CharacterBot) memoryConsolidationWorker() {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		cb.consolidateAllMemories()
+	for {
+		select {
+		case <-ticker.C:
+			cb.consolidateAllMemories()
+		case <-cb.stop:
+			return
+		}
 	}
 }
 
 func (cb *CharacterBot) consolidateAllMemories() {
 	cb.mu.RLock()
-	chars := make([]*models.Character, 0, len(cb.characters))
-	for _, char := range cb.characters {
-		chars = append(chars, char)
+	states := make([]*models.CharacterState, 0, len(cb.states))
+	for _, state := range cb.states {
+		states = append(states, state)
 	}
 	cb.mu.RUnlock()
 
-	for _, char := range chars {
-		cb.consolidateMemories(char)
-	}
-}
-
-func (cb *CharacterBot) consolidateMemories(char *models.Character) {
-	char.Lock()
-	defer char.Unlock()
-
-	// Group memories by emotional significance
-	emotionalMemories := make([]models.Memory, 0)
-
-	threshold := 0.7 // Emotional weight threshold
-
-	for _, mem := range char.Memories {
-		if mem.Type == models.ShortTermMemory && mem.Emotional > threshold {
-			emotionalMemories = append(emotionalMemories, mem)
-		}
-	}
-
-	// Consolidate emotional memories into medium-term
-	if len(emotionalMemories) > 3 {
-		consolidated := models.Memory{
-			Type:      models.MediumTermMemory,
-			Content:   cb.synthesizeMemories(emotionalMemories),
-			Timestamp: time.Now(),
-			Emotional: cb.averageEmotionalWeight(emotionalMemories),
-		}
-		char.Memories = append(char.Memories, consolidated)
-	}
-
-	// Prune old short-term memories
-	cutoff := time.Now().Add(-cb.config.MemoryConfig.MediumTermDuration)
-	filtered := make([]models.Memory, 0)
-
-	for _, mem := range char.Memories {
-		if mem.Type != models.ShortTermMemory || mem.Timestamp.After(cutoff) {
-			filtered = append(filtered, mem)
-		}
+	for _, state := range states {
+		cb.consolidateMemories(cb.background, state)
 	}
-
-	char.Memories = filtered
 }
 
+// synthesizeMemories joins memories verbatim when no summarizer is available
 func (cb *CharacterBot) synthesizeMemories(memories []models.Memory) string {
-	// In a real implementation, this would use NLP to create a coherent summary
 	contents := make([]string, 0, len(memories))
 	for _, mem := range memories {
 		contents = append(contents, mem.Content)
@@ -947,16 +1717,87 @@ func (cb *CharacterBot) averageEmotionalWeight(memories []models.Memory) float64
 	return total / float64(len(memories))
 }
 
+// SetRateLimitMode chooses whether requests over a provider's rate limit
+// fail or wait for capacity
+func (cb *CharacterBot) SetRateLimitMode(mode RateLimitMode) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.rateLimitMode = mode
+}
+
+// RateLimitMode returns how requests over a provider's rate limit are handled
+func (cb *CharacterBot) RateLimitMode() RateLimitMode {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	if cb.rateLimitMode == "" {
+		return RateLimitFail
+	}
+	return cb.rateLimitMode
+}
+
+// GetCacheStats returns the size, hits, misses and evictions of the prompt
+// and response caches
+func (cb *CharacterBot) GetCacheStats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		"prompt":   cb.cache.GetStats(),
+		"response": cb.responseCache.GetStats(),
+	}
+}
+
+// Events returns the bus the bot publishes reply tokens and state changes on
+func (cb *CharacterBot) Events() *EventBus {
+	return cb.events
+}
+
+// publishToken publishes a chunk of a streamed reply
+func (cb *CharacterBot) publishToken(characterID, userID, sessionID, content string) {
+	cb.events.Publish(Event{
+		Type:        EventToken,
+		CharacterID: characterID,
+		UserID:      userID,
+		SessionID:   sessionID,
+		Data:        TokenData{Content: content},
+	})
+}
+
+// publishReply publishes a finished reply, cached when it came from the
+// response cache
+func (cb *CharacterBot) publishReply(req *models.ConversationRequest, resp *providers.AIResponse, cached bool) {
+	cb.events.Publish(Event{
+		Type:        EventReply,
+		CharacterID: req.CharacterID,
+		UserID:      req.UserID,
+		SessionID:   req.Context.SessionID,
+		Data: ReplyData{
+			Content:  resp.Content,
+			Provider: resp.Provider,
+			Cached:   cached,
+		},
+	})
+}
+
 // GetRateLimiterStats returns current rate limiting statistics
 func (cb *CharacterBot) GetRateLimiterStats() map[string]interface{} {
 	return cb.rateLimiter.GetStats()
 }
 
-// Stop gracefully stops the character bot and its components
+// Stop gracefully stops the character bot and its components, flushing
+// pending character state to the store. It is safe to call more than once.
 func (cb *CharacterBot) Stop() {
-	if cb.rateLimiter != nil {
-		cb.rateLimiter.Stop()
-	}
+	cb.stopOnce.Do(func() {
+		close(cb.stop)
+		cb.cancelBackground()
+		cb.sweeps.Wait()
+		cb.waitForProfileUpdates(profileUpdateStopTimeout)
+		cb.waitForConsolidation(consolidationStopTimeout)
+		cb.waitForIndexing(indexingStopTimeout)
+		if p := cb.statePersister(); p != nil {
+			if err := p.Stop(); err != nil {
+				fmt.Fprintf(cb.logs, "Warning: Failed to persist character state: %v\n", err)
+			}
+		}
+		cb.events.Close()
+	})
 }
 
 // Utility functions
@@ -996,14 +1837,19 @@ func formatStringMap(items map[string]string, defaultText string) string {
 	if len(items) == 0 {
 		return defaultText
 	}
+	// Sorted so the prompt, and every cache keyed on it, is stable
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	var result strings.Builder
-	i := 0
-	for key, value := range items {
+	for i, key := range keys {
 		if i > 0 {
 			result.WriteString("\n")
 		}
-		result.WriteString(fmt.Sprintf("• %s: %s", key, value))
-		i++
+		result.WriteString(fmt.Sprintf("• %s: %s", key, items[key]))
 	}
 	return result.String()
 }
@@ -1081,19 +1927,41 @@ func (cb *CharacterBot) UpdateUserProfile(userID string, char *models.Character,
 	cb.updateUserProfileSync(userID, char, sessionID)
 }
 
-// updateUserProfileAsync asynchronously updates the user profile based on conversation history
+// profileUpdateStopTimeout bounds how long Stop waits for profile updates
+const profileUpdateStopTimeout = 10 * time.Second
+
+// updateUserProfileAsync asynchronously updates the user profile based on conversation history.
+// Stop waits for it to finish.
 func (cb *CharacterBot) updateUserProfileAsync(userID string, char *models.Character, sessionID string) {
-	go cb.updateUserProfileSync(userID, char, sessionID)
+	cb.profileUpdates.Add(1)
+	go func() {
+		defer cb.profileUpdates.Done()
+		cb.updateUserProfileSync(userID, char, sessionID)
+	}()
+}
+
+// waitForProfileUpdates blocks until background profile updates finish or timeout elapses
+func (cb *CharacterBot) waitForProfileUpdates(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		cb.profileUpdates.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		fmt.Fprintf(cb.logs, "Warning: Gave up waiting for user profile updates after %s\n", timeout)
+	}
 }
 
 // updateUserProfileSync performs the actual user profile update
 func (cb *CharacterBot) updateUserProfileSync(userID string, char *models.Character, sessionID string) {
 	// Only proceed if we have the minimum messages for an update
-	sessionRepo := repository.NewSessionRepository(filepath.Join(os.Getenv("HOME"), ".config", "roleplay"))
+	sessionRepo := repository.NewSessionRepository(cb.config.StorageDir())
 
 	currentSession, err := sessionRepo.LoadSession(char.ID, sessionID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE WARNING: Failed to load session %s for user %s: %v\n", sessionID, userID, err)
+		fmt.Fprintf(cb.logs, "BACKGROUND PROFILE UPDATE WARNING: Failed to load session %s for user %s: %v\n", sessionID, userID, err)
 		return
 	}
 
@@ -1111,14 +1979,14 @@ func (cb *CharacterBot) updateUserProfileSync(userID string, char *models.Charac
 
 	if cb.userProfileAgent == nil {
 		// This shouldn't happen, but log it clearly
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE ERROR: UserProfileAgent not initialized for %s/%s\n", userID, char.ID)
+		fmt.Fprintf(cb.logs, "BACKGROUND PROFILE UPDATE ERROR: UserProfileAgent not initialized for %s/%s\n", userID, char.ID)
 		return
 	}
 
 	// Load existing profile to have a fallback
 	existingProfile, err := cb.userProfileRepo.LoadUserProfile(userID, char.ID)
 	if err != nil && !os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE WARNING: Failed to load existing profile for %s/%s: %v\n", userID, char.ID, err)
+		fmt.Fprintf(cb.logs, "BACKGROUND PROFILE UPDATE WARNING: Failed to load existing profile for %s/%s: %v\n", userID, char.ID, err)
 		// Continue without existing profile, agent will create new one if successful
 	}
 	if os.IsNotExist(err) || existingProfile == nil {
@@ -1131,7 +1999,7 @@ func (cb *CharacterBot) updateUserProfileSync(userID string, char *models.Charac
 	}
 
 	// Create a context with timeout for the background operation
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(withSpendScope(context.Background(), userID, char.ID), 30*time.Second)
 	defer cancel()
 
 	// The agent is now resilient - it returns existing profile on failure
@@ -1149,17 +2017,24 @@ func (cb *CharacterBot) updateUserProfileSync(userID string, char *models.Charac
 		timestamp := time.Now().Format(time.RFC3339)
 		if strings.Contains(updateErr.Error(), "FAILED TO SAVE") {
 			// Profile was updated in memory but not persisted
-			fmt.Fprintf(os.Stderr, "[%s] BACKGROUND PROFILE UPDATE: In-memory update succeeded but save failed for %s/%s: %v\n", 
+			fmt.Fprintf(cb.logs, "[%s] BACKGROUND PROFILE UPDATE: In-memory update succeeded but save failed for %s/%s: %v\n", 
 				timestamp, userID, char.ID, updateErr)
 		} else {
 			// More fundamental error (extraction, parsing, validation)
-			fmt.Fprintf(os.Stderr, "[%s] BACKGROUND PROFILE UPDATE FAILED for %s/%s: %v. Profile remains at version %d.\n", 
+			fmt.Fprintf(cb.logs, "[%s] BACKGROUND PROFILE UPDATE FAILED for %s/%s: %v. Profile remains at version %d.\n", 
 				timestamp, userID, char.ID, updateErr, existingProfile.Version)
 		}
 	} else if updatedProfile != nil {
+		cb.events.Publish(Event{
+			Type:        EventProfileUpdated,
+			CharacterID: char.ID,
+			UserID:      userID,
+			SessionID:   sessionID,
+			Data:        updatedProfile,
+		})
 		// Success - only log if not using a local model to reduce noise
 		if cb.config.DefaultProvider != "ollama" && cb.config.DefaultProvider != "local" {
-			fmt.Fprintf(os.Stdout, "[%s] Background user profile for %s with %s successfully updated to version %d\n", 
+			fmt.Fprintf(cb.logs, "[%s] Background user profile for %s with %s successfully updated to version %d\n", 
 				time.Now().Format(time.RFC3339), userID, char.ID, updatedProfile.Version)
 		}
 	}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
+	"io"
+	"runtime"
 	"testing"
 	"time"
 
@@ -51,6 +54,29 @@ func (m *mockProvider) SendStreamRequest(ctx context.Context, req *providers.Pro
 
 func (m *mockProvider) Name() string { return m.name }
 
+// newMockBot creates a bot for cfg, with a fresh mock provider as its default
+// and char created. Cache settings are filled in for the test.
+func newMockBot(t *testing.T, cfg *config.Config, char *models.Character) (*CharacterBot, *providers.MockProvider) {
+	t.Helper()
+
+	cfg.DefaultProvider = "mock"
+	cfg.CacheConfig = config.CacheConfig{
+		DefaultTTL:      10 * time.Minute,
+		CleanupInterval: 5 * time.Minute,
+	}
+
+	mock := providers.NewMockProvider()
+	mock.Reset()
+	t.Cleanup(providers.ResetGlobalMock)
+
+	bot := NewCharacterBot(cfg)
+	bot.RegisterProvider("mock", mock)
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	return bot, mock
+}
+
 func TestCharacterBot(t *testing.T) {
 	cfg := &config.Config{
 		DefaultProvider: "mock",
@@ -211,9 +237,9 @@ func TestMemoryConsolidation(t *testing.T) {
 
 	bot := NewCharacterBot(cfg)
 
-	char := &models.Character{
-		ID:   "memory-test",
-		Name: "Memory Test",
+	state := &models.CharacterState{
+		CharacterID: "memory-test",
+		UserID:      "user-123",
 		Memories: []models.Memory{
 			{Type: models.ShortTermMemory, Content: "Memory 1", Emotional: 0.8, Timestamp: time.Now()},
 			{Type: models.ShortTermMemory, Content: "Memory 2", Emotional: 0.9, Timestamp: time.Now()},
@@ -224,11 +250,11 @@ func TestMemoryConsolidation(t *testing.T) {
 	}
 
 	// Consolidate memories
-	bot.consolidateMemories(char)
+	bot.consolidateMemories(context.Background(), state)
 
 	// Check for consolidated memory
 	hasMediumTerm := false
-	for _, mem := range char.Memories {
+	for _, mem := range state.Memories {
 		if mem.Type == models.MediumTermMemory {
 			hasMediumTerm = true
 			break
@@ -279,20 +305,289 @@ func TestPersonalityEvolution(t *testing.T) {
 	originalOpenness := char.Personality.Openness
 	originalExtraversion := char.Personality.Extraversion
 
-	bot.evolvePersonality(char, resp)
+	state := models.NewCharacterState(char, "user-123", time.Now())
+	bot.evolvePersonality(state, resp)
+	evolved := state.EffectivePersonality(char.Personality)
 
 	// Verify personality changed but within bounds
-	if char.Personality.Openness <= originalOpenness {
+	if evolved.Openness <= originalOpenness {
 		t.Error("Expected openness to increase with high surprise")
 	}
 
-	if char.Personality.Extraversion <= originalExtraversion {
+	if evolved.Extraversion <= originalExtraversion {
 		t.Error("Expected extraversion to increase with high joy")
 	}
 
 	// Verify changes are bounded
 	maxChange := cfg.PersonalityConfig.MaxDriftRate
-	if char.Personality.Openness-originalOpenness > maxChange {
+	if evolved.Openness-originalOpenness > maxChange {
 		t.Error("Personality change exceeded max drift rate")
 	}
+
+	// The base definition is never modified
+	if char.Personality.Openness != originalOpenness {
+		t.Error("Expected the base personality to stay unchanged")
+	}
+
+	// However long the conversation, drift stays bounded
+	for i := 0; i < 1000; i++ {
+		bot.evolvePersonality(state, resp)
+	}
+	drift := state.PersonalityDrift
+	for name, d := range map[string]float64{
+		"openness":          drift.Openness,
+		"conscientiousness": drift.Conscientiousness,
+		"extraversion":      drift.Extraversion,
+		"agreeableness":     drift.Agreeableness,
+	} {
+		if d != maxPersonalityDrift {
+			t.Errorf("Expected %s drift to settle at %v, got %v", name, maxPersonalityDrift, d)
+		}
+	}
+	evolved = state.EffectivePersonality(char.Personality)
+	if evolved.Openness-originalOpenness > maxPersonalityDrift {
+		t.Errorf("Expected openness within %v of the base, got %v", maxPersonalityDrift, evolved.Openness)
+	}
+}
+
+func TestProcessStreamRequest(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "mock",
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+	}
+
+	bot := NewCharacterBot(cfg)
+	bot.RegisterProvider("mock", &mockProvider{name: "mock"})
+
+	char := &models.Character{
+		ID:        "stream-test",
+		Name:      "Stream Test",
+		Backstory: "Testing streamed replies",
+	}
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	req := &models.ConversationRequest{
+		CharacterID: "stream-test",
+		UserID:      "user-123",
+		Message:     "Hello",
+	}
+
+	out := make(chan providers.PartialAIResponse, 10)
+	resp, err := bot.ProcessStreamRequest(context.Background(), req, out)
+	if err != nil {
+		t.Fatalf("Failed to stream request: %v", err)
+	}
+
+	var streamed string
+	done := false
+	for chunk := range out {
+		streamed += chunk.Content
+		if chunk.Done {
+			done = true
+		}
+	}
+
+	if streamed != "Mock stream response" {
+		t.Errorf("Expected streamed content 'Mock stream response', got %q", streamed)
+	}
+	if !done {
+		t.Error("Expected a final Done chunk")
+	}
+	if resp.Content != streamed {
+		t.Errorf("Expected final response to match streamed content, got %q", resp.Content)
+	}
+	if resp.TokensUsed.Completion == 0 {
+		t.Error("Expected estimated completion tokens")
+	}
+}
+
+func TestProcessRequestFailover(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "primary",
+		FallbackProviders: []config.ProviderProfile{
+			{Name: "secondary"},
+		},
+		CircuitBreaker: config.CircuitBreakerConfig{
+			FailureThreshold: 1,
+			Cooldown:         time.Hour,
+		},
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+	}
+
+	bot := NewCharacterBot(cfg)
+	primary := &mockProvider{name: "primary", err: errors.New("upstream outage")}
+	bot.RegisterProvider("primary", primary)
+	bot.RegisterProvider("secondary", &mockProvider{name: "secondary"})
+
+	char := &models.Character{ID: "failover-test", Name: "Failover Test"}
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	resp, err := bot.ProcessRequest(context.Background(), &models.ConversationRequest{
+		CharacterID: "failover-test",
+		UserID:      "user-123",
+		Message:     "Hello",
+	})
+	if err != nil {
+		t.Fatalf("Expected failover to succeed, got %v", err)
+	}
+	if resp.Provider != "secondary" {
+		t.Errorf("Expected secondary provider to answer, got %q", resp.Provider)
+	}
+
+	state, ok := bot.ProviderCircuitState("primary")
+	if !ok || state != CircuitOpen {
+		t.Errorf("Expected primary circuit to be open, got %s", state)
+	}
+
+	// Streaming fails over the same way
+	out := make(chan providers.PartialAIResponse, 10)
+	resp, err = bot.ProcessStreamRequest(context.Background(), &models.ConversationRequest{
+		CharacterID: "failover-test",
+		UserID:      "user-123",
+		Message:     "Tell me more",
+	}, out)
+	if err != nil {
+		t.Fatalf("Expected streamed failover to succeed, got %v", err)
+	}
+	if resp.Provider != "secondary" {
+		t.Errorf("Expected secondary provider to stream, got %q", resp.Provider)
+	}
+
+	// With every provider down the errors are joined
+	bot.RegisterProvider("secondary", &mockProvider{name: "secondary", err: errors.New("also down")})
+	_, err = bot.ProcessRequest(context.Background(), &models.ConversationRequest{
+		CharacterID: "failover-test",
+		UserID:      "user-123",
+		Message:     "Anyone there?",
+	})
+	if err == nil {
+		t.Fatal("Expected an error when every provider fails")
+	}
+}
+
+func TestResolveGeneration(t *testing.T) {
+	defaultTemp := 0.7
+	defaultMax := 500
+	cfg := &config.Config{
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+		Generation: models.GenerationParams{Temperature: &defaultTemp, MaxTokens: &defaultMax},
+	}
+
+	bot := NewCharacterBot(cfg)
+
+	terse := 0.2
+	shortReplies := 80
+	butler := &models.Character{
+		ID:         "butler",
+		Generation: &models.GenerationParams{Temperature: &terse, MaxTokens: &shortReplies},
+	}
+	bard := &models.Character{ID: "bard"}
+
+	butlerGen := bot.resolveGeneration(butler, "")
+	if *butlerGen.Temperature != 0.2 || *butlerGen.MaxTokens != 80 {
+		t.Errorf("Expected butler settings, got temperature %v max tokens %v", *butlerGen.Temperature, *butlerGen.MaxTokens)
+	}
+
+	bardGen := bot.resolveGeneration(bard, "")
+	if *bardGen.Temperature != 0.7 || *bardGen.MaxTokens != 500 {
+		t.Errorf("Expected config defaults for bard, got temperature %v max tokens %v", *bardGen.Temperature, *bardGen.MaxTokens)
+	}
+}
+
+func TestProcessRequestUpdatesMood(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "mock",
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+		MemoryConfig: config.MemoryConfig{
+			ShortTermWindow: 20,
+		},
+	}
+
+	bot := NewCharacterBot(cfg)
+	bot.RegisterProvider("mock", &mockProvider{
+		name: "mock",
+		response: &providers.AIResponse{
+			Content: "I'm furious! This is outrageous and I hate it.",
+		},
+	})
+
+	char := &models.Character{
+		ID:        "mood-test",
+		Name:      "Mood Test",
+		Backstory: "Testing emotion analysis",
+	}
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	resp, err := bot.ProcessRequest(context.Background(), &models.ConversationRequest{
+		CharacterID: "mood-test",
+		UserID:      "user-123",
+		Message:     "What do you think?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to process request: %v", err)
+	}
+
+	if resp.Emotions.Anger == 0 {
+		t.Errorf("Expected the reply to be scored as angry, got %+v", resp.Emotions)
+	}
+
+	updated, _ := bot.GetCharacterState("mood-test", "user-123")
+	updated.RLock()
+	defer updated.RUnlock()
+	if updated.CurrentMood.Anger <= 0 {
+		t.Errorf("Expected mood to shift toward anger, got %+v", updated.CurrentMood)
+	}
+	if len(updated.Memories) == 0 || updated.Memories[len(updated.Memories)-1].Emotional == 0 {
+		t.Error("Expected the reply memory to carry emotional weight")
+	}
+
+	// Another user still sees the character's base mood
+	other, _ := bot.GetCharacterState("mood-test", "user-456")
+	if other.CurrentMood != (models.EmotionalState{}) || len(other.Memories) != 0 {
+		t.Errorf("Expected another user's state to be untouched, got %+v", other.CurrentMood)
+	}
+}
+
+func TestStopEndsBackgroundWorkers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	before := runtime.NumGoroutine()
+
+	bot := NewCharacterBot(&config.Config{
+		CacheConfig: config.CacheConfig{DefaultTTL: 10 * time.Minute, CleanupInterval: time.Minute},
+	})
+	char := &models.Character{ID: "narrator", Name: "Narrator"}
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	// The session doesn't exist, so the update only logs a warning
+	bot.SetLogOutput(io.Discard)
+	bot.updateUserProfileAsync("alice", char, "missing")
+	bot.Stop()
+
+	// The workers return once they see the stop channel closed
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected the bot's goroutines to end on Stop, %d still running", after-before)
+	}
 }
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// QuotaError is returned when a user or character has exhausted a budget
+type QuotaError struct {
+	Scope    string // "user" or "character"
+	ID       string
+	Period   string // "daily" or "monthly"
+	Resource string // "tokens" or "cost"
+	Used     float64
+	Limit    float64
+	ResetsAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s %s has used %s of its %s %s budget; it resets %s",
+		e.Scope, e.ID, formatBudget(e.Resource, e.Used, e.Limit), e.Period, budgetNoun(e.Resource),
+		e.ResetsAt.Format("Jan 2 15:04"))
+}
+
+// budgetScope names the usage record a budget applies to
+type budgetScope struct {
+	name   string // "user" or "character"
+	dir    string // Usage repository scope
+	id     string
+	limits config.BudgetLimits
+}
+
+// budgetCheck is one limit of a scope with the usage counted against it
+type budgetCheck struct {
+	period   string
+	resource string
+	used     float64
+	limit    float64
+	resetsAt time.Time
+}
+
+// BudgetTracker enforces daily and monthly token and cost budgets per user
+// and per character, keeping running totals in a UsageRepository so they
+// survive between invocations
+type BudgetTracker struct {
+	config config.BudgetConfig
+	repo   *repository.UsageRepository
+	now    func() time.Time
+	logs   io.Writer // Where soft limit warnings go
+}
+
+// NewBudgetTracker creates a budget tracker for the configured limits
+func NewBudgetTracker(cfg config.BudgetConfig, repo *repository.UsageRepository) *BudgetTracker {
+	if cfg.WarnAt <= 0 || cfg.WarnAt > 1 {
+		cfg.WarnAt = 0.8
+	}
+	return &BudgetTracker{config: cfg, repo: repo, now: time.Now, logs: stderr{}}
+}
+
+// Check returns a *QuotaError when the user or the character has used up
+// one of its budgets
+func (bt *BudgetTracker) Check(userID, characterID string) error {
+	now := bt.now()
+	for _, scope := range bt.scopes(userID, characterID) {
+		record, err := bt.repo.LoadUsage(scope.dir, scope.id)
+		if err != nil {
+			return fmt.Errorf("failed to check %s budget: %w", scope.name, err)
+		}
+		rollUsage(record, now)
+
+		for _, c := range budgetChecks(scope.limits, record, now) {
+			if c.used >= c.limit {
+				return &QuotaError{
+					Scope:    scope.name,
+					ID:       scope.id,
+					Period:   c.period,
+					Resource: c.resource,
+					Used:     c.used,
+					Limit:    c.limit,
+					ResetsAt: c.resetsAt,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Record adds a reply's tokens and cost to the user's and the character's
+// totals, warning when a total crosses the soft limit
+func (bt *BudgetTracker) Record(userID, characterID string, tokens int, cost float64) {
+	now := bt.now()
+	for _, scope := range bt.scopes(userID, characterID) {
+		var before repository.UsageRecord
+		after, err := bt.repo.UpdateUsage(scope.dir, scope.id, func(r *repository.UsageRecord) {
+			rollUsage(r, now)
+			before = *r
+			r.DailyTokens += tokens
+			r.MonthlyTokens += tokens
+			r.DailyCost += cost
+			r.MonthlyCost += cost
+		})
+		if err != nil {
+			fmt.Fprintf(bt.logs, "Warning: Failed to record %s usage: %v\n", scope.name, err)
+			continue
+		}
+
+		previous := budgetChecks(scope.limits, &before, now)
+		for i, c := range budgetChecks(scope.limits, after, now) {
+			soft := c.limit * bt.config.WarnAt
+			if previous[i].used < soft && c.used >= soft {
+				fmt.Fprintf(bt.logs, "Warning: %s %s has used %.0f%% of its %s %s budget (%s)\n",
+					scope.name, scope.id, c.used/c.limit*100, c.period, budgetNoun(c.resource),
+					formatBudget(c.resource, c.used, c.limit))
+			}
+		}
+	}
+}
+
+// scopes returns the user and character budgets that apply to a request.
+// Viper lowercases map keys, so IDs are matched case-insensitively.
+func (bt *BudgetTracker) scopes(userID, characterID string) []budgetScope {
+	var scopes []budgetScope
+	if limits, ok := lookupBudget(bt.config.Users, userID); ok {
+		scopes = append(scopes, budgetScope{name: "user", dir: "users", id: userID, limits: limits})
+	}
+	if limits, ok := lookupBudget(bt.config.Characters, characterID); ok {
+		scopes = append(scopes, budgetScope{name: "character", dir: "characters", id: characterID, limits: limits})
+	}
+	return scopes
+}
+
+// lookupBudget returns the limits of id, or of the "*" entry
+func lookupBudget(budgets map[string]config.BudgetLimits, id string) (config.BudgetLimits, bool) {
+	if limits, ok := budgets[strings.ToLower(id)]; ok {
+		return limits, true
+	}
+	limits, ok := budgets["*"]
+	return limits, ok
+}
+
+// rollUsage starts new daily and monthly totals when the period has changed
+func rollUsage(r *repository.UsageRecord, now time.Time) {
+	if day := now.Format("2006-01-02"); r.Day != day {
+		r.Day = day
+		r.DailyTokens = 0
+		r.DailyCost = 0
+	}
+	if month := now.Format("2006-01"); r.Month != month {
+		r.Month = month
+		r.MonthlyTokens = 0
+		r.MonthlyCost = 0
+	}
+}
+
+// budgetChecks pairs each configured limit with its usage, in a fixed order so
+// results for the same limits can be compared index by index
+func budgetChecks(limits config.BudgetLimits, r *repository.UsageRecord, now time.Time) []budgetCheck {
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+
+	all := []budgetCheck{
+		{"daily", "tokens", float64(r.DailyTokens), float64(limits.DailyTokens), tomorrow},
+		{"daily", "cost", r.DailyCost, limits.DailyCost, tomorrow},
+		{"monthly", "tokens", float64(r.MonthlyTokens), float64(limits.MonthlyTokens), nextMonth},
+		{"monthly", "cost", r.MonthlyCost, limits.MonthlyCost, nextMonth},
+	}
+	checks := all[:0]
+	for _, c := range all {
+		if c.limit > 0 {
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
+
+func budgetNoun(resource string) string {
+	if resource == "cost" {
+		return "spending"
+	}
+	return "token"
+}
+
+// formatBudget renders usage against a limit, e.g. "$4.12/$5.00" or "8200/10000"
+func formatBudget(resource string, used, limit float64) string {
+	if resource == "cost" {
+		return fmt.Sprintf("$%.2f/$%.2f", used, limit)
+	}
+	return fmt.Sprintf("%.0f/%.0f", used, limit)
+}
+
+// SetBudgetTracker replaces the budget tracker; nil disables budgets
+func (cb *CharacterBot) SetBudgetTracker(bt *BudgetTracker) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if bt != nil {
+		bt.logs = cb.logs
+		bt.now = cb.now
+	}
+	cb.budgets = bt
+}
+
+// checkBudget rejects a request when the user or character is out of budget
+func (cb *CharacterBot) checkBudget(userID, characterID string) error {
+	cb.mu.RLock()
+	budgets := cb.budgets
+	cb.mu.RUnlock()
+	if budgets == nil {
+		return nil
+	}
+	return budgets.Check(userID, characterID)
+}
+
+// recordSpend counts a response against the user's and character's budgets
+func (cb *CharacterBot) recordSpend(userID, characterID string, resp *providers.AIResponse) {
+	cb.mu.RLock()
+	budgets := cb.budgets
+	cb.mu.RUnlock()
+	if budgets == nil {
+		return
+	}
+	budgets.Record(userID, characterID, resp.TokensUsed.Total, resp.Cost)
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// captureStderr returns what fn writes to os.Stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestBudgetTracker(t *testing.T) {
+	cfg := config.BudgetConfig{
+		Users: map[string]config.BudgetLimits{
+			"alice": {DailyTokens: 1000},
+			"*":     {MonthlyCost: 1},
+		},
+		Characters: map[string]config.BudgetLimits{
+			"narrator": {DailyCost: 0.5},
+		},
+	}
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.Local)
+	bt := NewBudgetTracker(cfg, repository.NewUsageRepository(dir))
+	bt.now = func() time.Time { return now }
+
+	if err := bt.Check("Alice", "narrator"); err != nil {
+		t.Fatalf("Expected a fresh budget to allow requests, got %v", err)
+	}
+
+	// Crossing 80% warns once
+	warnings := captureStderr(t, func() {
+		bt.Record("Alice", "narrator", 700, 0.01)
+		bt.Record("Alice", "narrator", 150, 0.01)
+		bt.Record("Alice", "narrator", 50, 0.01)
+	})
+	if strings.Count(warnings, "Warning: user Alice has used 85% of its daily token budget (850/1000)") != 1 {
+		t.Errorf("Expected one soft-limit warning, got %q", warnings)
+	}
+	if err := bt.Check("Alice", "narrator"); err != nil {
+		t.Fatalf("Expected requests below the limit to be allowed, got %v", err)
+	}
+
+	// Totals persist across trackers
+	bt = NewBudgetTracker(cfg, repository.NewUsageRepository(dir))
+	bt.now = func() time.Time { return now }
+	captureStderr(t, func() { bt.Record("Alice", "narrator", 100, 0.01) })
+
+	err := bt.Check("Alice", "narrator")
+	var quota *QuotaError
+	if !errors.As(err, &quota) {
+		t.Fatalf("Expected a QuotaError, got %v", err)
+	}
+	if quota.Scope != "user" || quota.Period != "daily" || quota.Resource != "tokens" || quota.Used != 1000 {
+		t.Errorf("Unexpected quota error: %+v", quota)
+	}
+	if want := time.Date(2026, 3, 15, 0, 0, 0, 0, time.Local); !quota.ResetsAt.Equal(want) {
+		t.Errorf("Expected the daily budget to reset at %v, got %v", want, quota.ResetsAt)
+	}
+
+	// Other users fall back to the "*" entry and keep their own totals
+	if err := bt.Check("bob", "narrator"); err != nil {
+		t.Errorf("Expected bob to be unaffected by alice's usage, got %v", err)
+	}
+
+	// The daily total starts over the next day
+	now = now.Add(24 * time.Hour)
+	if err := bt.Check("Alice", "narrator"); err != nil {
+		t.Errorf("Expected the budget to reset the next day, got %v", err)
+	}
+
+	// Character budgets are shared by every user
+	captureStderr(t, func() { bt.Record("bob", "narrator", 10, 0.5) })
+	if err := bt.Check("carol", "narrator"); !errors.As(err, &quota) || quota.Scope != "character" || quota.Resource != "cost" {
+		t.Errorf("Expected the character's spending budget to be exhausted, got %v", err)
+	}
+}
+
+func TestProcessRequestEnforcesBudget(t *testing.T) {
+	bot, mock := newContextBot(t)
+	mock.SetResponses([]string{"A long and winding tale about the old lighthouse keeper."})
+
+	bt := NewBudgetTracker(config.BudgetConfig{
+		Users: map[string]config.BudgetLimits{"alice": {DailyTokens: 10}},
+	}, repository.NewUsageRepository(t.TempDir()))
+	bot.SetBudgetTracker(bt)
+
+	req := &models.ConversationRequest{CharacterID: "narrator", UserID: "alice", Message: "Tell me a story"}
+	captureStderr(t, func() {
+		if _, err := bot.ProcessRequest(context.Background(), req); err != nil {
+			t.Fatalf("Expected the first request within budget, got %v", err)
+		}
+	})
+
+	// A different message misses the response cache and is refused
+	req.Message = "Tell me another"
+	_, err := bot.ProcessRequest(context.Background(), req)
+	var quota *QuotaError
+	if !errors.As(err, &quota) {
+		t.Fatalf("Expected a QuotaError once the budget is spent, got %v", err)
+	}
+	if mock.GetRequestCount() != 1 {
+		t.Errorf("Expected the refused request not to reach the provider, got %d requests", mock.GetRequestCount())
+	}
+}
+
+func TestBudgetsFollowBotClock(t *testing.T) {
+	bot, _ := newContextBot(t)
+	usage := repository.NewUsageRepository(t.TempDir())
+	bot.SetBudgetTracker(NewBudgetTracker(config.BudgetConfig{
+		Users: map[string]config.BudgetLimits{"alice": {DailyTokens: 1000}},
+	}, usage))
+	bot.SetClock(func() time.Time { return time.Date(2026, 3, 14, 10, 0, 0, 0, time.Local) })
+
+	bot.recordSpend("alice", "narrator", &providers.AIResponse{TokensUsed: providers.TokenUsage{Total: 10}})
+	record, err := usage.LoadUsage("users", "alice")
+	if err != nil {
+		t.Fatalf("Failed to load usage: %v", err)
+	}
+	if record.Day != "2026-03-14" || record.Month != "2026-03" {
+		t.Errorf("Expected usage for the bot's day and month, got %s and %s", record.Day, record.Month)
+	}
+
+	// A tracker set after the clock follows it too
+	later := repository.NewUsageRepository(t.TempDir())
+	bot.SetBudgetTracker(NewBudgetTracker(config.BudgetConfig{
+		Users: map[string]config.BudgetLimits{"alice": {DailyTokens: 1000}},
+	}, later))
+	bot.recordSpend("alice", "narrator", &providers.AIResponse{TokensUsed: providers.TokenUsage{Total: 10}})
+	if record, _ := later.LoadUsage("users", "alice"); record.Day != "2026-03-14" {
+		t.Errorf("Expected usage for the bot's day, got %s", record.Day)
+	}
+}
+
+func TestBackgroundRequestsCountTowardBudgets(t *testing.T) {
+	bot, mock := newConsolidationBot(t, config.MemoryConfig{ShortTermWindow: 1, ConsolidationRate: 1})
+	mock.SetResponses([]string{"I remember the harbour."})
+
+	usage := repository.NewUsageRepository(t.TempDir())
+	bot.SetBudgetTracker(NewBudgetTracker(config.BudgetConfig{
+		Users: map[string]config.BudgetLimits{"alice": {DailyTokens: 1000}, "bob": {DailyTokens: 1}},
+	}, usage))
+
+	// Emotion scoring by the LLM analyzer is charged with the reply
+	bot.SetEmotionAnalyzer(NewLLMEmotionAnalyzer(meteredProvider{bot}))
+	ctx := withSpendScope(context.Background(), "alice", "keeper")
+	bot.analyzeEmotions(ctx, &providers.AIResponse{Content: "What a lovely morning for a walk along the harbour"})
+
+	scored, err := usage.LoadUsage("users", "alice")
+	if err != nil || scored.DailyTokens == 0 {
+		t.Fatalf("Expected emotion scoring to be charged to alice, got %+v (%v)", scored, err)
+	}
+
+	// So are consolidation summaries
+	memories := func() []models.Memory {
+		return []models.Memory{
+			{ID: "m1", Type: models.ShortTermMemory, Content: "Walked along the harbour at dawn", Emotional: 0.5, Timestamp: time.Now()},
+			{ID: "m2", Type: models.ShortTermMemory, Content: "Watched the fishing boats leave", Emotional: 0.5, Timestamp: time.Now()},
+		}
+	}
+	bot.consolidateMemories(context.Background(), &models.CharacterState{CharacterID: "keeper", UserID: "alice", Memories: memories()})
+	consolidated, _ := usage.LoadUsage("users", "alice")
+	if consolidated.DailyTokens <= scored.DailyTokens {
+		t.Errorf("Expected the summary to be charged to alice, got %d tokens after %d", consolidated.DailyTokens, scored.DailyTokens)
+	}
+
+	// A user out of budget gets no summary, and the memories are kept verbatim
+	requests := mock.GetRequestCount()
+	state := &models.CharacterState{CharacterID: "keeper", UserID: "bob", Memories: memories()}
+	captureStderr(t, func() {
+		bot.recordSpend("bob", "keeper", &providers.AIResponse{TokensUsed: providers.TokenUsage{Total: 10}})
+		bot.consolidateMemories(context.Background(), state)
+	})
+	if mock.GetRequestCount() != requests {
+		t.Errorf("Expected no summary request over budget, got %d more", mock.GetRequestCount()-requests)
+	}
+	if len(state.Memories) != 1 || !strings.HasPrefix(state.Memories[0].Content, "Consolidated memories:") {
+		t.Errorf("Expected the memories to be kept verbatim, got %+v", state.Memories)
+	}
+}
+
+func TestProfileUpdatesCountTowardBudgets(t *testing.T) {
+	dir := t.TempDir()
+	bot, mock := newMockBot(t, &config.Config{
+		DataDir: dir,
+		UserProfileConfig: config.UserProfileConfig{
+			Enabled:         true,
+			UpdateFrequency: 1,
+			TurnsToConsider: 5,
+		},
+	}, &models.Character{ID: "keeper", Name: "The Keeper"})
+	bot.InitializeUserProfileAgent()
+	mock.SetResponses([]string{`{"user_id": "alice", "character_id": "keeper", "facts": [], "version": 1}`})
+
+	usage := repository.NewUsageRepository(t.TempDir())
+	bot.SetBudgetTracker(NewBudgetTracker(config.BudgetConfig{
+		Users: map[string]config.BudgetLimits{"alice": {DailyTokens: 1000}},
+	}, usage))
+
+	session := &repository.Session{
+		ID:          "harbour",
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Messages:    []repository.SessionMessage{{Timestamp: time.Now(), Role: "user", Content: "I grew up by the sea"}},
+	}
+	if err := repository.NewSessionRepository(dir).SaveSession(session); err != nil {
+		t.Fatalf("Failed to save session: %v", err)
+	}
+
+	char, _ := bot.GetCharacter("keeper")
+	bot.updateUserProfileSync("alice", char, "harbour")
+
+	if mock.GetRequestCount() != 1 {
+		t.Fatalf("Expected one extraction request, got %d", mock.GetRequestCount())
+	}
+	record, err := usage.LoadUsage("users", "alice")
+	if err != nil || record.DailyTokens == 0 {
+		t.Errorf("Expected the extraction to be charged to alice, got %+v (%v)", record, err)
+	}
+}
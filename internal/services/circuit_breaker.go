@@ -0,0 +1,125 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a provider circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through normally
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until the cooldown has passed
+	CircuitOpen
+	// CircuitHalfOpen lets one probe request through after the cooldown
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker takes a provider out of rotation after repeated failures
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int // Consecutive failures
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+	probing   bool // A half-open probe is in flight
+	now       func() time.Time
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold consecutive
+// failures and half-opens once cooldown has elapsed
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 3
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a request may be sent through the breaker. Once
+// half-open it admits a single probe until that probe's outcome is recorded
+// or it is abandoned.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = CircuitHalfOpen
+		b.probing = false
+	}
+	switch b.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Abandon reports that an allowed request was not sent, or was cancelled
+// without saying anything about the provider's health, so a half-open
+// breaker can admit another probe
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// RecordFailure counts a failure, opening the breaker at the threshold.
+// A failed half-open probe reopens the breaker immediately.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// State returns the current state, accounting for an elapsed cooldown
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
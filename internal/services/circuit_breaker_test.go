@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	if !breaker.Allow() {
+		t.Fatal("Expected a new breaker to be closed")
+	}
+
+	breaker.RecordFailure()
+	if breaker.State() != CircuitClosed {
+		t.Errorf("Expected closed after one failure, got %s", breaker.State())
+	}
+
+	breaker.RecordFailure()
+	if breaker.State() != CircuitOpen {
+		t.Errorf("Expected open after reaching the threshold, got %s", breaker.State())
+	}
+	if breaker.Allow() {
+		t.Error("Expected open breaker to reject requests")
+	}
+
+	// After the cooldown a probe is let through
+	now = now.Add(time.Minute)
+	if !breaker.Allow() {
+		t.Fatal("Expected half-open breaker to allow a probe")
+	}
+	if breaker.State() != CircuitHalfOpen {
+		t.Errorf("Expected half-open, got %s", breaker.State())
+	}
+	if breaker.Allow() {
+		t.Error("Expected half-open breaker to hold further requests while the probe is in flight")
+	}
+	breaker.Abandon()
+	if !breaker.Allow() {
+		t.Fatal("Expected an abandoned probe to free the slot for another")
+	}
+
+	// A failed probe reopens immediately
+	breaker.RecordFailure()
+	if breaker.Allow() {
+		t.Error("Expected failed probe to reopen the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	breaker.Allow()
+	breaker.RecordSuccess()
+	if breaker.State() != CircuitClosed {
+		t.Errorf("Expected successful probe to close the breaker, got %s", breaker.State())
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/cache"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+const (
+	// defaultContextWindow is assumed for models missing from modelContextWindows
+	defaultContextWindow = 8192
+	// defaultResponseReserve is kept free for the reply when max_tokens is unset
+	defaultResponseReserve = 1024
+	// messageOverheadTokens approximates the per-message framing of chat APIs
+	messageOverheadTokens = 4
+	// historyFoldTarget is the share of the history budget left in use after a
+	// fold, so summaries are requested every few turns rather than every turn
+	historyFoldTarget = 0.5
+	// historySummaryTimeout bounds the summary request made while folding
+	historySummaryTimeout = 30 * time.Second
+)
+
+// modelContextWindows maps model name prefixes to context sizes in tokens.
+// The longest matching prefix wins.
+var modelContextWindows = map[string]int{
+	"gpt-4.1":       1047576,
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            200000,
+	"o3":            200000,
+	"o4":            200000,
+	"claude":        200000,
+	"gemini":        1048576,
+	"llama3.1":      131072,
+	"llama3.2":      131072,
+	"llama3.3":      131072,
+	"llama3":        8192,
+	"llama-3.1":     131072,
+	"llama-3.3":     131072,
+	"mistral":       32768,
+	"mixtral":       32768,
+	"qwen2.5":       32768,
+	"deepseek":      65536,
+}
+
+// ModelContextWindow returns the context size of model in tokens
+func ModelContextWindow(model string) int {
+	model = strings.ToLower(model)
+	// Strip routing prefixes such as "openai/" or "anthropic/"
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+
+	best, window := "", defaultContextWindow
+	for prefix, size := range modelContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, window = prefix, size
+		}
+	}
+	return window
+}
+
+// contextWindow returns the configured context size, or the model's
+func (cb *CharacterBot) contextWindow() int {
+	if cb.config.Context.Window > 0 {
+		return cb.config.Context.Window
+	}
+	return ModelContextWindow(cb.config.Model)
+}
+
+// responseReserve returns the tokens kept free for the reply
+func (cb *CharacterBot) responseReserve(char *models.Character, scenarioID string) int {
+	if gen := cb.resolveGeneration(char, scenarioID); gen.MaxTokens != nil {
+		return *gen.MaxTokens
+	}
+	if cb.config.Context.ReserveTokens > 0 {
+		return cb.config.Context.ReserveTokens
+	}
+	return defaultResponseReserve
+}
+
+// messageTokens returns the tokens a chat message costs
+func (cb *CharacterBot) messageTokens(msg models.Message) int {
+	return cb.countTokens(msg.Content) + messageOverheadTokens
+}
+
+// firstFitting returns the index of the oldest message from which the rest of
+// messages fits in budget tokens
+func (cb *CharacterBot) firstFitting(messages []models.Message, budget int) int {
+	used := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		used += cb.messageTokens(messages[i])
+		if used > budget {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// fitConversation trims req.Context.RecentMessages to the turns that fit the
+// model's context after the prefix layers, the message and the reply reserve.
+// Turns that don't fit are folded into req.Context.Summary, together with
+// enough older turns to leave room for the next few exchanges, unless the
+// request asks for them to be dropped. It returns the conversation layer,
+// which carries the summary; the kept turns are sent to the provider as chat
+// messages.
+func (cb *CharacterBot) fitConversation(ctx context.Context, req *models.ConversationRequest, char *models.Character, prefix []cache.CacheBreakpoint) string {
+	used := cb.countTokens(req.Message) + cb.countTokens(buildConversationSummary(req.Context.Summary))
+	for _, bp := range prefix {
+		used += bp.TokenCount
+	}
+	budget := cb.contextWindow() - cb.responseReserve(char, req.ScenarioID) - used
+
+	messages := req.Context.RecentMessages
+	keep := cb.firstFitting(messages, budget)
+	if keep == 0 {
+		return buildConversationSummary(req.Context.Summary)
+	}
+	if req.Context.DropOverflow {
+		req.Context.RecentMessages = messages[keep:]
+		return buildConversationSummary(req.Context.Summary)
+	}
+
+	// Fold past the overflow so the next turns fit without another summary
+	fold := cb.firstFitting(messages, int(float64(budget)*historyFoldTarget))
+	summary, err := cb.summarizeConversation(ctx, char, req.UserID, req.Context.Summary, messages[:fold])
+	if err != nil {
+		fmt.Fprintf(cb.logs, "Warning: Failed to summarise older turns, dropping them from context: %v\n", err)
+		req.Context.RecentMessages = messages[keep:]
+		return buildConversationSummary(req.Context.Summary)
+	}
+
+	req.Context.Summary = summary
+	req.Context.SummarizedCount += fold
+	req.Context.RecentMessages = messages[fold:]
+	return buildConversationSummary(summary)
+}
+
+// buildConversationSummary renders the rolling summary as the conversation layer
+func buildConversationSummary(summary string) string {
+	if summary == "" {
+		return ""
+	}
+	return "[CONVERSATION SO FAR]\n" + summary
+}
+
+const conversationSummaryPrompt = `You keep the running summary of a roleplay conversation between %s and the user %s.
+Update the summary with the new turns. Keep names, facts, promises, decisions and unresolved threads; drop small talk.
+Write in the third person, at most 200 words. Respond ONLY with the updated summary.`
+
+// summarizeConversation folds turns into the previous rolling summary. The
+// request goes through the provider chain and counts toward the user's and
+// character's budgets like a reply.
+func (cb *CharacterBot) summarizeConversation(ctx context.Context, char *models.Character, userID, previous string, turns []models.Message) (string, error) {
+	var sb strings.Builder
+	if previous != "" {
+		sb.WriteString("Summary so far:\n" + previous + "\n\n")
+	}
+	sb.WriteString("New turns:\n")
+	for _, msg := range turns {
+		speaker := userID
+		if msg.Role != "user" {
+			speaker = char.Name
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s\n", speaker, msg.Content))
+	}
+
+	ctx, cancel := context.WithTimeout(withSpendScope(ctx, userID, char.ID), historySummaryTimeout)
+	defer cancel()
+
+	temperature := 0.2
+	maxTokens := 400
+	resp, err := cb.sendMetered(ctx, &providers.PromptRequest{
+		CharacterID:  "system-history-summarizer",
+		Message:      sb.String(),
+		SystemPrompt: fmt.Sprintf(conversationSummaryPrompt, char.Name, userID),
+		Generation: models.GenerationParams{
+			Temperature: &temperature,
+			MaxTokens:   &maxTokens,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("conversation summary request failed: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("conversation summary was empty")
+	}
+	return summary, nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+func TestModelContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128000},
+		{"gpt-4", 8192},
+		{"gpt-4-turbo-preview", 128000},
+		{"openai/gpt-4.1-nano", 1047576},
+		{"claude-3-haiku-20240307", 200000},
+		{"llama3.1:8b", 131072},
+		{"llama3", 8192},
+		{"some-unknown-model", defaultContextWindow},
+	}
+
+	for _, tt := range tests {
+		if got := ModelContextWindow(tt.model); got != tt.want {
+			t.Errorf("ModelContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+// turns returns n alternating user and assistant messages of about 100 tokens each
+func turns(n int) []models.Message {
+	messages := make([]models.Message, n)
+	for i := range messages {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages[i] = models.Message{
+			Role:    role,
+			Content: fmt.Sprintf("turn %d %s", i, strings.Repeat("x", 400)),
+		}
+	}
+	return messages
+}
+
+func newContextBot(t *testing.T) (*CharacterBot, *providers.MockProvider) {
+	t.Helper()
+	return newMockBot(t, &config.Config{
+		// A tiny window leaves room for about ten turns after the prompt layers
+		Context: config.ContextConfig{Window: 2600, ReserveTokens: 200},
+	}, &models.Character{ID: "narrator", Name: "Narrator"})
+}
+
+func TestFitConversationFoldsOlderTurns(t *testing.T) {
+	bot, mock := newContextBot(t)
+	mock.SetResponses([]string{"They met at the tavern."})
+
+	req := &models.ConversationRequest{
+		CharacterID: "narrator",
+		UserID:      "alice",
+		Message:     "What happens next?",
+		Context: models.ConversationContext{
+			RecentMessages:  turns(30),
+			Summary:         "Alice arrived in town.",
+			SummarizedCount: 4,
+		},
+	}
+
+	prompt, _, err := bot.BuildPromptContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+
+	kept := len(req.Context.RecentMessages)
+	if kept == 0 || kept >= 30 {
+		t.Fatalf("Expected the history to be trimmed to the budget, kept %d of 30", kept)
+	}
+	if req.Context.SummarizedCount != 4+30-kept {
+		t.Errorf("Expected folded turns to be counted, got %d with %d kept", req.Context.SummarizedCount, kept)
+	}
+	if last := req.Context.RecentMessages[kept-1].Content; !strings.HasPrefix(last, "turn 29 ") {
+		t.Errorf("Expected the newest turn to be kept, got %q", last[:10])
+	}
+	if req.Context.Summary != "They met at the tavern." {
+		t.Errorf("Expected the rolling summary to be updated, got %q", req.Context.Summary)
+	}
+	if !strings.Contains(prompt, "[CONVERSATION SO FAR]\nThey met at the tavern.") {
+		t.Error("Expected the summary in the conversation layer")
+	}
+	if strings.Contains(prompt, "turn 29") {
+		t.Error("Expected kept turns to be sent as messages, not in the system prompt")
+	}
+
+	// The previous summary and the folded turns are sent to the summariser
+	summaryReq := mock.GetLastRequest()
+	if mock.GetRequestCount() != 1 || summaryReq.CharacterID != "system-history-summarizer" {
+		t.Fatal("Expected one summary request")
+	}
+	if !strings.Contains(summaryReq.Message, "Alice arrived in town.") || !strings.Contains(summaryReq.Message, "Narrator: turn 1 ") {
+		t.Error("Expected the summary request to include the previous summary and the folded turns")
+	}
+
+	// Once folded, the remaining turns fit without another summary
+	req.Context.RecentMessages = append(req.Context.RecentMessages, turns(2)...)
+	if _, _, err := bot.BuildPromptContext(context.Background(), req); err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+	if mock.GetRequestCount() != 1 {
+		t.Error("Expected no summary while the history fits")
+	}
+}
+
+func TestFitConversationWithoutSummary(t *testing.T) {
+	bot, mock := newContextBot(t)
+	mock.SetError(errors.New("provider down"))
+
+	req := &models.ConversationRequest{
+		CharacterID: "narrator",
+		UserID:      "alice",
+		Message:     "What happens next?",
+		Context:     models.ConversationContext{RecentMessages: turns(30)},
+	}
+
+	if _, _, err := bot.BuildPromptContext(context.Background(), req); err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+
+	// Overflowing turns are dropped but stay unsummarised for the next attempt
+	if kept := len(req.Context.RecentMessages); kept == 0 || kept >= 30 {
+		t.Errorf("Expected the history to be trimmed to the budget, kept %d of 30", kept)
+	}
+	if req.Context.SummarizedCount != 0 || req.Context.Summary != "" {
+		t.Errorf("Expected no summary after a failed request, got %d %q", req.Context.SummarizedCount, req.Context.Summary)
+	}
+}
+
+func TestFitConversationDropsClientHistory(t *testing.T) {
+	bot, mock := newContextBot(t)
+
+	req := &models.ConversationRequest{
+		CharacterID: "narrator",
+		UserID:      "alice",
+		Message:     "What happens next?",
+		Context:     models.ConversationContext{RecentMessages: turns(30), DropOverflow: true},
+	}
+
+	if _, _, err := bot.BuildPromptContext(context.Background(), req); err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+
+	kept := len(req.Context.RecentMessages)
+	if kept == 0 || kept >= 30 {
+		t.Errorf("Expected the history to be trimmed to the budget, kept %d of 30", kept)
+	}
+	if last := req.Context.RecentMessages[kept-1].Content; !strings.HasPrefix(last, "turn 29 ") {
+		t.Errorf("Expected the newest turn to be kept, got %q", last[:10])
+	}
+	if mock.GetRequestCount() != 0 || req.Context.Summary != "" || req.Context.SummarizedCount != 0 {
+		t.Error("Expected overflowing client history to be dropped without a summary request")
+	}
+}
+
+// wordTokenizer counts one token per word
+type wordTokenizer struct{}
+
+func (wordTokenizer) Count(text string) int { return len(strings.Fields(text)) }
+func (wordTokenizer) Name() string          { return "words" }
+
+func TestPromptLayersUseTokenizer(t *testing.T) {
+	bot, _ := newContextBot(t)
+	bot.SetTokenizer(wordTokenizer{})
+
+	_, breakpoints, err := bot.BuildPromptContext(context.Background(), &models.ConversationRequest{
+		CharacterID: "narrator",
+		UserID:      "alice",
+		Message:     "Hello",
+	})
+	if err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+
+	for _, bp := range breakpoints {
+		content := strings.TrimPrefix(bp.Content, "<!-- cached:true -->\n")
+		if want := len(strings.Fields(content)); bp.TokenCount != want {
+			t.Errorf("Layer %s: expected %d tokens, got %d", bp.Layer, want, bp.TokenCount)
+		}
+	}
+}
+
+func TestConversationSummaryIsMetered(t *testing.T) {
+	bot, mock := newContextBot(t)
+	mock.SetResponses([]string{"They met at the tavern."})
+	usage := repository.NewUsageRepository(t.TempDir())
+	bot.SetBudgetTracker(NewBudgetTracker(config.BudgetConfig{
+		Characters: map[string]config.BudgetLimits{"narrator": {DailyTokens: 100000}},
+	}, usage))
+
+	req := &models.ConversationRequest{
+		CharacterID: "narrator",
+		UserID:      "alice",
+		Message:     "What happens next?",
+		Context:     models.ConversationContext{RecentMessages: turns(30)},
+	}
+	if _, _, err := bot.BuildPromptContext(context.Background(), req); err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+	if mock.GetRequestCount() != 1 {
+		t.Fatalf("Expected one summary request, got %d", mock.GetRequestCount())
+	}
+
+	record, err := usage.LoadUsage("characters", "narrator")
+	if err != nil || record.DailyTokens == 0 {
+		t.Errorf("Expected the summary to count toward the character's budget, got %+v (%v)", record, err)
+	}
+}
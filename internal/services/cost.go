@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/dotcommander/roleplay/internal/pricing"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// providerModel returns the configured model of a registered provider, or ""
+// when the provider falls back to its own default
+func (cb *CharacterBot) providerModel(name string) string {
+	if name == cb.config.DefaultProvider {
+		return cb.config.Model
+	}
+	for _, profile := range cb.config.FallbackProviders {
+		if profile.Name == name {
+			return profile.Model
+		}
+	}
+	return ""
+}
+
+// priceResponse sets the cost of a provider reply and the amount its prompt
+// cache saved, using the rates of the provider and model that answered.
+// Providers that report their own cost keep it.
+func (cb *CharacterBot) priceResponse(resp *providers.AIResponse) {
+	if resp.Model == "" {
+		resp.Model = cb.providerModel(resp.Provider)
+	}
+	if resp.Cost > 0 {
+		return
+	}
+
+	price, ok := cb.lookupPrice(resp.Provider, resp.Model)
+	if !ok {
+		return
+	}
+	usage := pricing.Usage{
+		Prompt:        resp.TokensUsed.Prompt,
+		CachedPrompt:  resp.TokensUsed.CachedPrompt,
+		CacheCreation: resp.TokensUsed.CacheCreation,
+		Completion:    resp.TokensUsed.Completion,
+	}
+	resp.Cost = price.Cost(usage)
+	resp.CostSaved = price.Saved(usage)
+}
+
+// lookupPrice returns the rates of a model, warning once about unpriced models
+func (cb *CharacterBot) lookupPrice(provider, model string) (pricing.Price, bool) {
+	price, ok := cb.pricing.Lookup(provider, model)
+	if !ok {
+		if _, warned := cb.unpriced.LoadOrStore(provider+"/"+model, true); !warned {
+			fmt.Fprintf(cb.logs, "Warning: No pricing for %s model %q, recording its cost as $0. Add it under pricing in config.yaml\n", provider, model)
+		}
+	}
+	return price, ok
+}
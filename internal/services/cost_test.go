@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/pricing"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+func TestResponseCost(t *testing.T) {
+	mock := providers.NewMockProvider()
+	mock.Reset()
+	t.Cleanup(providers.ResetGlobalMock)
+	mock.SetResponses([]string{"Well met, traveller."})
+	mock.SetCacheHit(true, 0.5)
+
+	cfg := &config.Config{
+		DefaultProvider: "mock",
+		Model:           "test-model",
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+		// The mock is free by default, so price it for the test
+		Pricing: []pricing.Entry{
+			{Provider: "mock", Model: "test-", Price: pricing.Price{Input: 2, CachedInput: 0.5, Output: 8}},
+		},
+	}
+	bot := NewCharacterBot(cfg)
+	bot.RegisterProvider("mock", mock)
+	if err := bot.CreateCharacter(&models.Character{ID: "innkeeper", Name: "Innkeeper"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	req := &models.ConversationRequest{
+		CharacterID: "innkeeper",
+		UserID:      "alice",
+		Message:     "Good evening! Do you have a room for the night?",
+	}
+	resp, err := bot.ProcessRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to process request: %v", err)
+	}
+
+	if resp.Provider != "mock" || resp.Model != "test-model" {
+		t.Errorf("Expected the reply attributed to mock/test-model, got %s/%s", resp.Provider, resp.Model)
+	}
+	usage := resp.TokensUsed
+	uncached := usage.Prompt - usage.CachedPrompt
+	wantCost := (float64(uncached)*2 + float64(usage.CachedPrompt)*0.5 + float64(usage.Completion)*8) / 1_000_000
+	if usage.CachedPrompt == 0 || math.Abs(resp.Cost-wantCost) > 1e-12 {
+		t.Errorf("Expected cost %v from %+v, got %v", wantCost, usage, resp.Cost)
+	}
+	wantSaved := float64(usage.CachedPrompt) * 1.5 / 1_000_000
+	if math.Abs(resp.CostSaved-wantSaved) > 1e-12 {
+		t.Errorf("Expected prompt cache savings %v, got %v", wantSaved, resp.CostSaved)
+	}
+
+	// A response cache hit costs nothing and saves the whole request
+	cached, err := bot.ProcessRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to process repeated request: %v", err)
+	}
+	if cached.Cost != 0 {
+		t.Errorf("Expected a cached reply to be free, got %v", cached.Cost)
+	}
+	if math.Abs(cached.CostSaved-resp.Cost) > 1e-12 {
+		t.Errorf("Expected the cached reply to save %v, got %v", resp.Cost, cached.CostSaved)
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/utils"
+)
+
+// EmotionAnalyzer scores the emotional content of a character's reply
+type EmotionAnalyzer interface {
+	Analyze(ctx context.Context, text string) (models.EmotionalState, error)
+}
+
+// emotion identifies one dimension of models.EmotionalState
+type emotion int
+
+const (
+	emotionJoy emotion = iota
+	emotionSurprise
+	emotionAnger
+	emotionFear
+	emotionSadness
+	emotionDisgust
+)
+
+// lexiconEntry is the emotion a word signals and how strongly
+type lexiconEntry struct {
+	emotion emotion
+	weight  float64
+}
+
+// emotionLexicon maps words to the emotion they signal
+var emotionLexicon = map[string]lexiconEntry{
+	// Joy
+	"happy": {emotionJoy, 1}, "glad": {emotionJoy, 0.8}, "joy": {emotionJoy, 1}, "delight": {emotionJoy, 1},
+	"delighted": {emotionJoy, 1}, "love": {emotionJoy, 0.9}, "wonderful": {emotionJoy, 0.9}, "great": {emotionJoy, 0.6},
+	"excellent": {emotionJoy, 0.7}, "pleased": {emotionJoy, 0.7}, "cheerful": {emotionJoy, 0.8}, "laugh": {emotionJoy, 0.8},
+	"smile": {emotionJoy, 0.7}, "grin": {emotionJoy, 0.7}, "excited": {emotionJoy, 0.8}, "thrilled": {emotionJoy, 1},
+	"fantastic": {emotionJoy, 0.9}, "marvelous": {emotionJoy, 0.9}, "splendid": {emotionJoy, 0.8}, "hooray": {emotionJoy, 1},
+	"grateful": {emotionJoy, 0.7}, "proud": {emotionJoy, 0.6}, "fun": {emotionJoy, 0.6}, "enjoy": {emotionJoy, 0.7},
+	"chuckle": {emotionJoy, 0.6}, "beam": {emotionJoy, 0.7}, "yay": {emotionJoy, 0.9}, "haha": {emotionJoy, 0.7},
+
+	// Surprise
+	"surprise": {emotionSurprise, 1}, "surprised": {emotionSurprise, 1}, "wow": {emotionSurprise, 0.9},
+	"whoa": {emotionSurprise, 0.9}, "astonish": {emotionSurprise, 1}, "astonished": {emotionSurprise, 1},
+	"amazing": {emotionSurprise, 0.7}, "amazed": {emotionSurprise, 0.9}, "unexpected": {emotionSurprise, 0.8},
+	"incredible": {emotionSurprise, 0.7}, "shocked": {emotionSurprise, 0.9}, "startled": {emotionSurprise, 0.9},
+	"gasp": {emotionSurprise, 0.9}, "suddenly": {emotionSurprise, 0.5}, "unbelievable": {emotionSurprise, 0.8},
+	"stunned": {emotionSurprise, 0.9}, "blink": {emotionSurprise, 0.4},
+
+	// Anger
+	"angry": {emotionAnger, 1}, "anger": {emotionAnger, 1}, "furious": {emotionAnger, 1}, "rage": {emotionAnger, 1},
+	"mad": {emotionAnger, 0.7}, "annoyed": {emotionAnger, 0.6}, "irritated": {emotionAnger, 0.6}, "hate": {emotionAnger, 0.9},
+	"outrageous": {emotionAnger, 0.8}, "damn": {emotionAnger, 0.6}, "fool": {emotionAnger, 0.6}, "idiot": {emotionAnger, 0.7},
+	"growl": {emotionAnger, 0.7}, "snarl": {emotionAnger, 0.8}, "scowl": {emotionAnger, 0.7}, "glare": {emotionAnger, 0.6},
+	"shout": {emotionAnger, 0.5}, "frustrated": {emotionAnger, 0.7}, "livid": {emotionAnger, 1}, "resent": {emotionAnger, 0.7},
+	"enough": {emotionAnger, 0.3}, "insult": {emotionAnger, 0.7},
+
+	// Fear
+	"afraid": {emotionFear, 1}, "fear": {emotionFear, 1}, "scared": {emotionFear, 1}, "terrified": {emotionFear, 1},
+	"frightened": {emotionFear, 1}, "nervous": {emotionFear, 0.7}, "anxious": {emotionFear, 0.8}, "worried": {emotionFear, 0.7},
+	"worry": {emotionFear, 0.6}, "danger": {emotionFear, 0.7}, "dangerous": {emotionFear, 0.7}, "panic": {emotionFear, 0.9},
+	"tremble": {emotionFear, 0.8}, "shiver": {emotionFear, 0.5}, "dread": {emotionFear, 0.9}, "uneasy": {emotionFear, 0.6},
+	"threat": {emotionFear, 0.6}, "flee": {emotionFear, 0.7}, "hide": {emotionFear, 0.4}, "horror": {emotionFear, 0.9},
+
+	// Sadness
+	"sad": {emotionSadness, 1}, "sorrow": {emotionSadness, 1}, "unhappy": {emotionSadness, 0.9}, "cry": {emotionSadness, 0.9},
+	"tears": {emotionSadness, 0.9}, "weep": {emotionSadness, 1}, "grief": {emotionSadness, 1}, "lonely": {emotionSadness, 0.8},
+	"miss": {emotionSadness, 0.5}, "lost": {emotionSadness, 0.5}, "sigh": {emotionSadness, 0.6}, "regret": {emotionSadness, 0.7},
+	"sorry": {emotionSadness, 0.5}, "depressed": {emotionSadness, 1}, "heartbroken": {emotionSadness, 1}, "mourn": {emotionSadness, 1},
+	"gloomy": {emotionSadness, 0.7}, "alas": {emotionSadness, 0.7}, "disappointed": {emotionSadness, 0.7}, "melancholy": {emotionSadness, 0.8},
+
+	// Disgust
+	"disgust": {emotionDisgust, 1}, "disgusting": {emotionDisgust, 1}, "disgusted": {emotionDisgust, 1}, "gross": {emotionDisgust, 0.8},
+	"revolting": {emotionDisgust, 1}, "vile": {emotionDisgust, 0.9}, "nasty": {emotionDisgust, 0.7}, "yuck": {emotionDisgust, 0.9},
+	"ew": {emotionDisgust, 0.8}, "eww": {emotionDisgust, 0.9}, "repulsive": {emotionDisgust, 1}, "sickening": {emotionDisgust, 0.9},
+	"foul": {emotionDisgust, 0.7}, "filthy": {emotionDisgust, 0.7}, "rotten": {emotionDisgust, 0.6}, "grimace": {emotionDisgust, 0.5},
+	"appalling": {emotionDisgust, 0.8}, "distasteful": {emotionDisgust, 0.7}, "contempt": {emotionDisgust, 0.8},
+}
+
+// negations cancel the emotion word that follows them
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "hardly": true, "without": true, "nor": true,
+}
+
+// intensifiers strengthen the emotion word that follows them
+var intensifiers = map[string]float64{
+	"very": 1.5, "so": 1.3, "really": 1.3, "extremely": 1.8, "incredibly": 1.6,
+	"utterly": 1.7, "truly": 1.4, "deeply": 1.5, "absolutely": 1.6, "terribly": 1.5,
+}
+
+// LexiconEmotionAnalyzer scores text offline against a word lexicon.
+// It handles simple negation ("not happy") and intensifiers ("very angry"),
+// and treats interrobangs as surprise.
+type LexiconEmotionAnalyzer struct{}
+
+// NewLexiconEmotionAnalyzer creates a new lexicon-based analyzer
+func NewLexiconEmotionAnalyzer() *LexiconEmotionAnalyzer {
+	return &LexiconEmotionAnalyzer{}
+}
+
+// Analyze scores text on the six emotion dimensions, each in [0, 1]
+func (l *LexiconEmotionAnalyzer) Analyze(ctx context.Context, text string) (models.EmotionalState, error) {
+	var raw [6]float64
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	negateWindow := 0
+	boost := 1.0
+	for _, word := range words {
+		word = strings.Trim(word, "'")
+		if negations[word] || strings.HasSuffix(word, "n't") {
+			negateWindow = 3
+			continue
+		}
+		if factor, ok := intensifiers[word]; ok {
+			boost = factor
+			continue
+		}
+
+		if entry, ok := lookupEmotionWord(word); ok && negateWindow == 0 {
+			raw[entry.emotion] += entry.weight * boost
+		}
+
+		boost = 1.0
+		if negateWindow > 0 {
+			negateWindow--
+		}
+	}
+
+	raw[emotionSurprise] += 0.5 * float64(strings.Count(text, "?!")+strings.Count(text, "!?"))
+
+	// Saturate so a handful of strong words approaches but never exceeds 1
+	score := func(v float64) float64 { return 1 - math.Exp(-v/2) }
+	return models.EmotionalState{
+		Joy:      score(raw[emotionJoy]),
+		Surprise: score(raw[emotionSurprise]),
+		Anger:    score(raw[emotionAnger]),
+		Fear:     score(raw[emotionFear]),
+		Sadness:  score(raw[emotionSadness]),
+		Disgust:  score(raw[emotionDisgust]),
+	}, nil
+}
+
+// lookupEmotionWord finds a word in the lexicon, retrying with common suffixes removed
+func lookupEmotionWord(word string) (lexiconEntry, bool) {
+	if entry, ok := emotionLexicon[word]; ok {
+		return entry, true
+	}
+	for _, suffix := range []string{"ing", "ed", "es", "s", "ly"} {
+		if stem := strings.TrimSuffix(word, suffix); stem != word && len(stem) > 2 {
+			if entry, ok := emotionLexicon[stem]; ok {
+				return entry, true
+			}
+			// "smiled" -> "smil" -> "smile"
+			if entry, ok := emotionLexicon[stem+"e"]; ok {
+				return entry, true
+			}
+		}
+	}
+	return lexiconEntry{}, false
+}
+
+// LLMEmotionAnalyzer asks a provider to score the reply as structured JSON.
+// When the call or the JSON fails it falls back to another analyzer.
+type LLMEmotionAnalyzer struct {
+	provider providers.AIProvider
+	fallback EmotionAnalyzer
+}
+
+// NewLLMEmotionAnalyzer creates an LLM-based analyzer that falls back to the lexicon
+func NewLLMEmotionAnalyzer(provider providers.AIProvider) *LLMEmotionAnalyzer {
+	return &LLMEmotionAnalyzer{
+		provider: provider,
+		fallback: NewLexiconEmotionAnalyzer(),
+	}
+}
+
+const emotionAnalysisPrompt = `You are an emotion classifier. Rate how strongly the text expresses each emotion on a scale from 0.0 to 1.0.
+Respond ONLY with JSON of the form {"joy":0.0,"surprise":0.0,"anger":0.0,"fear":0.0,"sadness":0.0,"disgust":0.0}.`
+
+// Analyze scores text via the provider, falling back on failure
+func (a *LLMEmotionAnalyzer) Analyze(ctx context.Context, text string) (models.EmotionalState, error) {
+	temperature := 0.0
+	maxTokens := 100
+	resp, err := a.provider.SendRequest(ctx, &providers.PromptRequest{
+		CharacterID:  "system-emotion-analyzer",
+		Message:      text,
+		SystemPrompt: emotionAnalysisPrompt,
+		Generation: models.GenerationParams{
+			Temperature: &temperature,
+			MaxTokens:   &maxTokens,
+		},
+	})
+	if err != nil {
+		return a.fallbackAnalyze(ctx, text, fmt.Errorf("emotion analysis request failed: %w", err))
+	}
+
+	extracted, err := utils.ExtractValidJSON(resp.Content)
+	if err != nil {
+		return a.fallbackAnalyze(ctx, text, fmt.Errorf("emotion analysis returned no JSON: %w", err))
+	}
+
+	var state models.EmotionalState
+	if err := json.Unmarshal([]byte(extracted), &state); err != nil {
+		return a.fallbackAnalyze(ctx, text, fmt.Errorf("failed to parse emotion analysis: %w", err))
+	}
+
+	return models.EmotionalState{
+		Joy:      clampUnit(state.Joy),
+		Surprise: clampUnit(state.Surprise),
+		Anger:    clampUnit(state.Anger),
+		Fear:     clampUnit(state.Fear),
+		Sadness:  clampUnit(state.Sadness),
+		Disgust:  clampUnit(state.Disgust),
+	}, nil
+}
+
+// fallbackAnalyze uses the fallback analyzer, or reports cause when there is none
+func (a *LLMEmotionAnalyzer) fallbackAnalyze(ctx context.Context, text string, cause error) (models.EmotionalState, error) {
+	if a.fallback == nil {
+		return models.EmotionalState{}, cause
+	}
+	return a.fallback.Analyze(ctx, text)
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
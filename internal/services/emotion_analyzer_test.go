@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+func TestLexiconEmotionAnalyzer(t *testing.T) {
+	analyzer := NewLexiconEmotionAnalyzer()
+
+	tests := []struct {
+		name     string
+		text     string
+		dominant func(models.EmotionalState) float64
+	}{
+		{"joy", "I'm so happy to see you! What a wonderful day, it makes me smile.", func(e models.EmotionalState) float64 { return e.Joy }},
+		{"anger", "I am furious. This is outrageous and I hate it!", func(e models.EmotionalState) float64 { return e.Anger }},
+		{"fear", "I'm terrified, something dangerous is out there and I'm trembling.", func(e models.EmotionalState) float64 { return e.Fear }},
+		{"sadness", "She wept, heartbroken and lonely, tears on her cheeks.", func(e models.EmotionalState) float64 { return e.Sadness }},
+		{"disgust", "Ew, that is disgusting and utterly revolting.", func(e models.EmotionalState) float64 { return e.Disgust }},
+		{"surprise", "Whoa, I'm astonished! That was unexpected?!", func(e models.EmotionalState) float64 { return e.Surprise }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emotions, err := analyzer.Analyze(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+			score := tt.dominant(emotions)
+			if score < 0.5 {
+				t.Errorf("Expected %s score >= 0.5, got %.2f (%+v)", tt.name, score, emotions)
+			}
+			for _, other := range []float64{emotions.Joy, emotions.Surprise, emotions.Anger, emotions.Fear, emotions.Sadness, emotions.Disgust} {
+				if other > score {
+					t.Errorf("Expected %s to dominate, got %+v", tt.name, emotions)
+				}
+				if other < 0 || other > 1 {
+					t.Errorf("Score out of range: %+v", emotions)
+				}
+			}
+		})
+	}
+}
+
+func TestLexiconEmotionAnalyzerNegation(t *testing.T) {
+	analyzer := NewLexiconEmotionAnalyzer()
+
+	emotions, _ := analyzer.Analyze(context.Background(), "I am not happy and I'm never glad about this.")
+	if emotions.Joy != 0 {
+		t.Errorf("Expected negated joy to score 0, got %.2f", emotions.Joy)
+	}
+
+	plain, _ := analyzer.Analyze(context.Background(), "I am angry.")
+	intense, _ := analyzer.Analyze(context.Background(), "I am extremely angry.")
+	if intense.Anger <= plain.Anger {
+		t.Errorf("Expected intensifier to raise anger: plain %.2f, intense %.2f", plain.Anger, intense.Anger)
+	}
+
+	neutral, _ := analyzer.Analyze(context.Background(), "The train leaves at noon.")
+	if neutral != (models.EmotionalState{}) {
+		t.Errorf("Expected neutral text to score zero, got %+v", neutral)
+	}
+}
+
+func TestLLMEmotionAnalyzer(t *testing.T) {
+	provider := &mockProvider{
+		name: "mock",
+		response: &providers.AIResponse{
+			Content: "Here you go:\n```json\n{\"joy\": 0.9, \"surprise\": 0.1, \"anger\": 1.7, \"fear\": 0, \"sadness\": -0.2, \"disgust\": 0}\n```",
+		},
+	}
+	analyzer := NewLLMEmotionAnalyzer(provider)
+
+	emotions, err := analyzer.Analyze(context.Background(), "Some reply")
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if emotions.Joy != 0.9 || emotions.Surprise != 0.1 {
+		t.Errorf("Unexpected scores: %+v", emotions)
+	}
+	if emotions.Anger != 1 || emotions.Sadness != 0 {
+		t.Errorf("Expected scores clamped to [0, 1], got %+v", emotions)
+	}
+
+	// A failing provider falls back to the lexicon
+	failing := NewLLMEmotionAnalyzer(&mockProvider{name: "mock", err: errors.New("unavailable")})
+	emotions, err = failing.Analyze(context.Background(), "I'm so happy!")
+	if err != nil {
+		t.Fatalf("Expected fallback instead of error, got %v", err)
+	}
+	if emotions.Joy == 0 {
+		t.Errorf("Expected lexicon fallback to score joy, got %+v", emotions)
+	}
+
+	// Without a fallback the error is reported
+	failing.fallback = nil
+	if _, err := failing.Analyze(context.Background(), "I'm so happy!"); err == nil {
+		t.Error("Expected error without fallback")
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+// EventType names what an Event reports
+type EventType string
+
+const (
+	// EventToken carries a chunk of a streamed reply
+	EventToken EventType = "token"
+	// EventReply carries a finished reply, streamed or not
+	EventReply EventType = "reply"
+	// EventMoodChanged reports a character's mood toward a user shifting
+	EventMoodChanged EventType = "mood_changed"
+	// EventProfileUpdated reports a new version of a user profile
+	EventProfileUpdated EventType = "profile_updated"
+	// EventMemoryConsolidated reports a memory consolidation pass that changed
+	// a character's memories of a user
+	EventMemoryConsolidated EventType = "memory_consolidated"
+)
+
+// defaultEventBuffer is how many events a subscriber may fall behind by
+const defaultEventBuffer = 256
+
+// Event is something that happened to a character, published on an EventBus.
+// Token and reply events belong to a session; state events only to a
+// character and user, since state is shared across a user's sessions.
+type Event struct {
+	Type        EventType   `json:"type"`
+	CharacterID string      `json:"character_id"`
+	UserID      string      `json:"user_id,omitempty"`
+	SessionID   string      `json:"session_id,omitempty"`
+	Time        time.Time   `json:"time"`
+	Data        interface{} `json:"data,omitempty"`
+}
+
+// TokenData is the data of an EventToken
+type TokenData struct {
+	Content string `json:"content"`
+}
+
+// ReplyData is the data of an EventReply
+type ReplyData struct {
+	Content  string `json:"content"`
+	Provider string `json:"provider,omitempty"`
+	Cached   bool   `json:"cached"`
+}
+
+// MoodChangeData is the data of an EventMoodChanged
+type MoodChangeData struct {
+	Previous models.EmotionalState `json:"previous"`
+	Current  models.EmotionalState `json:"current"`
+}
+
+// MemoryConsolidationData is the data of an EventMemoryConsolidated
+type MemoryConsolidationData struct {
+	Summarized    int             `json:"summarized"`    // Short-term memories folded into recollections
+	Forgotten     int             `json:"forgotten"`     // Short-term memories that fell out of the window
+	Recollections []models.Memory `json:"recollections"` // New medium-term memories
+}
+
+// EventFilter selects the events a subscriber receives; empty fields match
+// anything. A session filter also matches state events of its character and
+// user, which carry no session.
+type EventFilter struct {
+	CharacterID string
+	UserID      string
+	SessionID   string
+}
+
+// Matches reports whether event passes the filter
+func (f EventFilter) Matches(event Event) bool {
+	if f.CharacterID != "" && event.CharacterID != f.CharacterID {
+		return false
+	}
+	if f.SessionID != "" && event.SessionID != "" {
+		return event.SessionID == f.SessionID
+	}
+	if f.UserID != "" && event.UserID != f.UserID {
+		return false
+	}
+	// A session filter without a user can't tell whose state an event is about
+	return f.SessionID == "" || f.UserID != ""
+}
+
+// EventBus fans events out to subscribers. Publishing never blocks: a
+// subscriber that falls a full buffer behind misses events until it catches
+// up, and Dropped counts them.
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events matching its filter until closed
+type Subscription struct {
+	bus     *EventBus
+	filter  EventFilter
+	ch      chan Event
+	dropped atomic.Int64
+}
+
+// Subscribe starts receiving events matching filter. A subscription taken
+// after the bus is closed is closed already.
+func (b *EventBus) Subscribe(filter EventFilter) *Subscription {
+	sub := &Subscription{bus: b, filter: filter, ch: make(chan Event, defaultEventBuffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers event to every matching subscriber, stamping it with the
+// current time if it has none. It is a no-op on a nil bus.
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers returns how many subscriptions are open
+func (b *EventBus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close ends every subscription; later subscriptions are closed at once
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Events returns the channel events arrive on; it is closed with the
+// subscription
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped returns how many events the subscriber missed by falling behind
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+func TestEventFilterMatches(t *testing.T) {
+	token := Event{Type: EventToken, CharacterID: "keeper", UserID: "alice", SessionID: "s1"}
+	mood := Event{Type: EventMoodChanged, CharacterID: "keeper", UserID: "alice"}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		event  Event
+		want   bool
+	}{
+		{"everything", EventFilter{}, token, true},
+		{"character", EventFilter{CharacterID: "keeper"}, mood, true},
+		{"other character", EventFilter{CharacterID: "baker"}, token, false},
+		{"session", EventFilter{CharacterID: "keeper", UserID: "alice", SessionID: "s1"}, token, true},
+		{"other session", EventFilter{CharacterID: "keeper", UserID: "alice", SessionID: "s2"}, token, false},
+		{"session sees its user's state", EventFilter{CharacterID: "keeper", UserID: "alice", SessionID: "s2"}, mood, true},
+		{"session skips other users' state", EventFilter{CharacterID: "keeper", UserID: "bob", SessionID: "s2"}, mood, false},
+		{"session without user skips state", EventFilter{CharacterID: "keeper", SessionID: "s1"}, mood, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventBusDropsForSlowSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(EventFilter{})
+
+	for i := 0; i < defaultEventBuffer+5; i++ {
+		bus.Publish(Event{Type: EventToken, CharacterID: "keeper"})
+	}
+	if sub.Dropped() != 5 {
+		t.Errorf("Expected 5 events dropped, got %d", sub.Dropped())
+	}
+	if event := <-sub.Events(); event.Time.IsZero() {
+		t.Error("Expected published events to be stamped")
+	}
+
+	sub.Close()
+	sub.Close()
+	if bus.Subscribers() != 0 {
+		t.Errorf("Expected no subscribers after close, got %d", bus.Subscribers())
+	}
+
+	bus.Close()
+	if _, ok := <-bus.Subscribe(EventFilter{}).Events(); ok {
+		t.Error("Expected subscriptions to a closed bus to be closed")
+	}
+}
+
+func TestStreamRequestPublishesEvents(t *testing.T) {
+	bot := NewCharacterBot(&config.Config{
+		DefaultProvider: "mock",
+		CacheConfig:     config.CacheConfig{DefaultTTL: 10 * time.Minute},
+	})
+	defer bot.Stop()
+	bot.RegisterProvider("mock", &mockProvider{name: "mock"})
+	if err := bot.CreateCharacter(&models.Character{ID: "keeper", Name: "Keeper", CurrentMood: models.EmotionalState{Joy: 0.5}}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	sub := bot.Events().Subscribe(EventFilter{CharacterID: "keeper", UserID: "alice", SessionID: "s1"})
+	defer sub.Close()
+	other := bot.Events().Subscribe(EventFilter{CharacterID: "keeper", UserID: "alice", SessionID: "s2"})
+	defer other.Close()
+
+	out := make(chan providers.PartialAIResponse, 10)
+	_, err := bot.ProcessStreamRequest(context.Background(), &models.ConversationRequest{
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Message:     "Hello",
+		Context:     models.ConversationContext{SessionID: "s1"},
+	}, out)
+	if err != nil {
+		t.Fatalf("Stream request failed: %v", err)
+	}
+
+	seen := make(map[EventType]Event)
+	for len(sub.Events()) > 0 {
+		event := <-sub.Events()
+		seen[event.Type] = event
+	}
+	if data, ok := seen[EventToken].Data.(TokenData); !ok || data.Content != "Mock stream response" {
+		t.Errorf("Expected a token event with the streamed chunk, got %+v", seen[EventToken])
+	}
+	if data, ok := seen[EventReply].Data.(ReplyData); !ok || data.Content != "Mock stream response" || data.Cached {
+		t.Errorf("Expected an uncached reply event, got %+v", seen[EventReply])
+	}
+	if data, ok := seen[EventMoodChanged].Data.(MoodChangeData); !ok || data.Previous.Joy != 0.5 {
+		t.Errorf("Expected a mood change from the base mood, got %+v", seen[EventMoodChanged])
+	}
+
+	// The other session of the same user only shares its state
+	for len(other.Events()) > 0 {
+		if event := <-other.Events(); event.Type != EventMoodChanged {
+			t.Errorf("Expected only state events for another session, got %s", event.Type)
+		}
+	}
+}
+
+func TestConsolidationPublishesEvent(t *testing.T) {
+	bot, _ := newConsolidationBot(t, config.MemoryConfig{ShortTermWindow: 1, ConsolidationRate: 0.5})
+	sub := bot.Events().Subscribe(EventFilter{CharacterID: "keeper"})
+	defer sub.Close()
+
+	now := time.Now()
+	state := &models.CharacterState{
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Memories: []models.Memory{
+			{ID: "m1", Type: models.ShortTermMemory, Content: "Met at the gate", Emotional: 0.4, Timestamp: now.Add(-2 * time.Minute)},
+			{ID: "m2", Type: models.ShortTermMemory, Content: "Shared bread", Emotional: 0.1, Timestamp: now.Add(-time.Minute)},
+			{ID: "m3", Type: models.ShortTermMemory, Content: "Said goodbye", Emotional: 0.2, Timestamp: now},
+		},
+	}
+	bot.consolidateMemories(context.Background(), state)
+
+	select {
+	case event := <-sub.Events():
+		data, ok := event.Data.(MemoryConsolidationData)
+		if event.Type != EventMemoryConsolidated || !ok || event.UserID != "alice" {
+			t.Fatalf("Expected a consolidation event for alice, got %+v", event)
+		}
+		if data.Summarized != 2 || data.Forgotten != 0 || len(data.Recollections) != 1 {
+			t.Errorf("Unexpected consolidation: %+v", data)
+		}
+	default:
+		t.Fatal("Expected a consolidation event")
+	}
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// episodeGap separates short-term memories into clusters summarised together
+	episodeGap = 30 * time.Minute
+	// longTermRecallCount is how often a medium-term memory must be recalled
+	// before it becomes long-term
+	longTermRecallCount = 3
+	// longTermEmotionalWeight promotes medium-term memories regardless of recall
+	longTermEmotionalWeight = 0.5
+	// consolidationTimeout bounds one consolidation pass, summaries included
+	consolidationTimeout = 60 * time.Second
+	// consolidationStopTimeout bounds how long Stop waits for running passes.
+	// Stop cancels their summaries first, so a pass only has to write back the
+	// memories it keeps verbatim.
+	consolidationStopTimeout = 10 * time.Second
+)
+
+// MemorySummarizer condenses a cluster of memories into one recollection
+type MemorySummarizer interface {
+	Summarize(ctx context.Context, characterName string, memories []models.Memory) (string, error)
+}
+
+// LLMMemorySummarizer asks a provider to rewrite memories as a first-person recollection
+type LLMMemorySummarizer struct {
+	provider providers.AIProvider
+}
+
+// NewLLMMemorySummarizer creates a summarizer backed by provider
+func NewLLMMemorySummarizer(provider providers.AIProvider) *LLMMemorySummarizer {
+	return &LLMMemorySummarizer{provider: provider}
+}
+
+const memorySummaryPrompt = `You are the memory of %s. Condense the moments below into one concise first-person recollection of at most two sentences, as %s would remember them.
+Keep names, facts and feelings. Respond ONLY with the recollection.`
+
+// Summarize returns the provider's recollection of memories
+func (s *LLMMemorySummarizer) Summarize(ctx context.Context, characterName string, memories []models.Memory) (string, error) {
+	var moments strings.Builder
+	for _, mem := range memories {
+		moments.WriteString(fmt.Sprintf("- (%s) %s\n", mem.Timestamp.Format("2006-01-02 15:04"), mem.Content))
+	}
+
+	temperature := 0.3
+	maxTokens := 150
+	resp, err := s.provider.SendRequest(ctx, &providers.PromptRequest{
+		CharacterID:  "system-memory-consolidator",
+		Message:      moments.String(),
+		SystemPrompt: fmt.Sprintf(memorySummaryPrompt, characterName, characterName),
+		Generation: models.GenerationParams{
+			Temperature: &temperature,
+			MaxTokens:   &maxTokens,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("memory summary request failed: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("memory summary was empty")
+	}
+	return summary, nil
+}
+
+// SetMemorySummarizer replaces the summarizer used for consolidation.
+// Without one, the default provider is used.
+func (cb *CharacterBot) SetMemorySummarizer(summarizer MemorySummarizer) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.summarizer = summarizer
+}
+
+// memorySummarizer returns the configured summarizer, falling back to the
+// provider chain, or nil when neither is available
+func (cb *CharacterBot) memorySummarizer() MemorySummarizer {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	if cb.summarizer != nil {
+		return cb.summarizer
+	}
+	if _, ok := cb.providers[cb.config.DefaultProvider]; ok {
+		return NewLLMMemorySummarizer(meteredProvider{cb})
+	}
+	return nil
+}
+
+// scheduleConsolidation runs a consolidation pass for state in the background.
+// Stop cancels its summaries and waits for it to finish.
+func (cb *CharacterBot) scheduleConsolidation(state *models.CharacterState) {
+	cb.consolidations.Add(1)
+	go func() {
+		defer cb.consolidations.Done()
+		cb.consolidateMemories(cb.background, state)
+	}()
+}
+
+// waitForConsolidation blocks until running passes finish or timeout elapses
+func (cb *CharacterBot) waitForConsolidation(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		cb.consolidations.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		fmt.Fprintf(cb.logs, "Warning: Gave up waiting for memory consolidation after %s\n", timeout)
+	}
+}
+
+// consolidateMemories moves memories between tiers for one character and user:
+//   - the ConsolidationRate share of short-term memories with the highest
+//     emotional weight is clustered into episodes, and each episode is
+//     summarised into a medium-term recollection
+//   - the rest of the short-term memories are trimmed to ShortTermWindow
+//   - medium-term memories recalled often enough, or emotional enough, become
+//     long-term; the others expire after MediumTermDuration
+//
+// Summaries are requested without holding the state lock, and only one pass
+// runs per state at a time.
+func (cb *CharacterBot) consolidateMemories(ctx context.Context, state *models.CharacterState) {
+	key := stateKey(state.CharacterID, state.UserID)
+	if !cb.beginConsolidation(key) {
+		return
+	}
+	defer cb.endConsolidation(key)
+
+	// Summaries are charged to the conversation being consolidated
+	ctx, cancel := context.WithTimeout(withSpendScope(ctx, state.UserID, state.CharacterID), consolidationTimeout)
+	defer cancel()
+
+	state.Lock()
+	selected, forgotten := cb.selectForConsolidation(state)
+	state.Unlock()
+
+	// Summarise outside the lock so prompts aren't blocked on the provider
+	consolidated := cb.summarizeEpisodes(ctx, state.CharacterID, selected)
+
+	state.Lock()
+	changed := removeMemories(state, selected, forgotten)
+	if cb.promoteMemories(state) {
+		changed = true
+	}
+	if len(consolidated) > 0 {
+		state.Memories = append(state.Memories, consolidated...)
+		changed = true
+	}
+	if changed {
+		state.LastModified = cb.now()
+	}
+	state.Unlock()
+
+	if changed {
+		cb.markDirty(state)
+		cb.events.Publish(Event{
+			Type:        EventMemoryConsolidated,
+			CharacterID: state.CharacterID,
+			UserID:      state.UserID,
+			Data: MemoryConsolidationData{
+				Summarized:    len(selected),
+				Forgotten:     len(forgotten),
+				Recollections: consolidated,
+			},
+		})
+	}
+	cb.indexConsolidated(ctx, state.CharacterID, state.UserID, consolidated)
+}
+
+// beginConsolidation claims key for a pass, reporting false if one is running
+func (cb *CharacterBot) beginConsolidation(key string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.consolidating[key] {
+		return false
+	}
+	cb.consolidating[key] = true
+	return true
+}
+
+// endConsolidation releases key after a pass
+func (cb *CharacterBot) endConsolidation(key string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.consolidating, key)
+}
+
+// selectForConsolidation picks the short-term memories to summarise and those
+// to forget. The caller must hold the state lock.
+func (cb *CharacterBot) selectForConsolidation(state *models.CharacterState) (selected, forgotten []models.Memory) {
+	window := cb.config.MemoryConfig.ShortTermWindow
+	short := make([]models.Memory, 0, len(state.Memories))
+	for i := range state.Memories {
+		mem := &state.Memories[i]
+		if mem.Type != models.ShortTermMemory {
+			continue
+		}
+		// Memories saved before IDs existed need one to be removed later
+		if mem.ID == "" {
+			mem.ID = uuid.New().String()
+		}
+		short = append(short, *mem)
+	}
+	if window <= 0 || len(short) <= window {
+		return nil, nil
+	}
+
+	rate := math.Min(cb.config.MemoryConfig.ConsolidationRate, 1)
+	count := 0
+	if rate > 0 {
+		count = int(math.Ceil(rate * float64(len(short))))
+	}
+
+	// Most emotional first, newest first among equals
+	byWeight := make([]models.Memory, len(short))
+	copy(byWeight, short)
+	sort.SliceStable(byWeight, func(i, j int) bool {
+		if byWeight[i].Emotional != byWeight[j].Emotional {
+			return byWeight[i].Emotional > byWeight[j].Emotional
+		}
+		return byWeight[i].Timestamp.After(byWeight[j].Timestamp)
+	})
+	selected = byWeight[:count]
+
+	chosen := make(map[string]bool, count)
+	for _, mem := range selected {
+		chosen[mem.ID] = true
+	}
+	remaining := make([]models.Memory, 0, len(short)-count)
+	for _, mem := range short {
+		if !chosen[mem.ID] {
+			remaining = append(remaining, mem)
+		}
+	}
+
+	// Oldest unselected memories fall out of the window
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Timestamp.Before(remaining[j].Timestamp)
+	})
+	if overflow := len(remaining) - window; overflow > 0 {
+		forgotten = remaining[:overflow]
+	}
+	return selected, forgotten
+}
+
+// clusterEpisodes groups memories into episodes, splitting wherever more than
+// episodeGap passes between consecutive memories
+func clusterEpisodes(memories []models.Memory) [][]models.Memory {
+	if len(memories) == 0 {
+		return nil
+	}
+
+	sorted := make([]models.Memory, len(memories))
+	copy(sorted, memories)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	episodes := [][]models.Memory{{sorted[0]}}
+	for _, mem := range sorted[1:] {
+		last := episodes[len(episodes)-1]
+		if mem.Timestamp.Sub(last[len(last)-1].Timestamp) > episodeGap {
+			episodes = append(episodes, []models.Memory{mem})
+		} else {
+			episodes[len(episodes)-1] = append(last, mem)
+		}
+	}
+	return episodes
+}
+
+// summarizeEpisodes turns each episode of selected into a medium-term memory.
+// Episodes the summarizer fails on are kept verbatim.
+func (cb *CharacterBot) summarizeEpisodes(ctx context.Context, characterID string, selected []models.Memory) []models.Memory {
+	episodes := clusterEpisodes(selected)
+	if len(episodes) == 0 {
+		return nil
+	}
+
+	characterName := characterID
+	if char, err := cb.GetCharacter(characterID); err == nil {
+		characterName = char.Name
+	}
+	summarizer := cb.memorySummarizer()
+
+	consolidated := make([]models.Memory, 0, len(episodes))
+	for _, episode := range episodes {
+		content := ""
+		if summarizer != nil {
+			summary, err := summarizer.Summarize(ctx, characterName, episode)
+			if err != nil {
+				fmt.Fprintf(cb.logs, "Warning: Memory summary failed, keeping memories verbatim: %v\n", err)
+			} else {
+				content = summary
+			}
+		}
+		if content == "" {
+			content = cb.synthesizeMemories(episode)
+		}
+
+		consolidated = append(consolidated, models.Memory{
+			ID:        uuid.New().String(),
+			Type:      models.MediumTermMemory,
+			Content:   content,
+			Timestamp: episode[len(episode)-1].Timestamp,
+			Emotional: cb.averageEmotionalWeight(episode),
+		})
+	}
+	return consolidated
+}
+
+// removeMemories drops the given memories from state by ID.
+// The caller must hold the state lock.
+func removeMemories(state *models.CharacterState, groups ...[]models.Memory) bool {
+	drop := make(map[string]bool)
+	for _, group := range groups {
+		for _, mem := range group {
+			drop[mem.ID] = true
+		}
+	}
+	if len(drop) == 0 {
+		return false
+	}
+
+	kept := make([]models.Memory, 0, len(state.Memories))
+	for _, mem := range state.Memories {
+		if mem.ID == "" || !drop[mem.ID] {
+			kept = append(kept, mem)
+		}
+	}
+	changed := len(kept) != len(state.Memories)
+	state.Memories = kept
+	return changed
+}
+
+// promoteMemories makes frequently recalled or emotional medium-term memories
+// long-term and expires the rest after MediumTermDuration.
+// The caller must hold the state lock.
+func (cb *CharacterBot) promoteMemories(state *models.CharacterState) bool {
+	var cutoff time.Time
+	if d := cb.config.MemoryConfig.MediumTermDuration; d > 0 {
+		cutoff = cb.now().Add(-d)
+	}
+
+	changed := false
+	kept := make([]models.Memory, 0, len(state.Memories))
+	for _, mem := range state.Memories {
+		if mem.Type == models.MediumTermMemory {
+			switch {
+			case mem.RecallCount >= longTermRecallCount || mem.Emotional >= longTermEmotionalWeight:
+				mem.Type = models.LongTermMemory
+				changed = true
+			case !cutoff.IsZero() && mem.Timestamp.Before(cutoff):
+				changed = true
+				continue
+			}
+		}
+		kept = append(kept, mem)
+	}
+	state.Memories = kept
+	return changed
+}
+
+// indexConsolidated adds new medium-term memories to the memory index so they
+// can be recalled, and their recalls counted toward promotion
+func (cb *CharacterBot) indexConsolidated(ctx context.Context, characterID, userID string, memories []models.Memory) {
+	embedder, index := cb.retrieval()
+	if embedder == nil {
+		return
+	}
+
+	for _, mem := range memories {
+		entry := repository.IndexedMemory{
+			ID:        mem.ID,
+			Content:   mem.Content,
+			Type:      mem.Type,
+			Emotional: mem.Emotional,
+			Timestamp: mem.Timestamp,
+			Model:     embedder.EmbeddingModel(),
+		}
+		if err := cb.addToIndex(ctx, embedder, index, characterID, userID, entry); err != nil {
+			fmt.Fprintf(cb.logs, "Warning: Failed to index consolidated memory: %v\n", err)
+		}
+	}
+}
+
+// recordRecall counts a recall for each medium-term memory among recalled
+func (cb *CharacterBot) recordRecall(characterID, userID string, recalled []repository.ScoredMemory) {
+	ids := make(map[string]bool)
+	for _, mem := range recalled {
+		if mem.Type == models.MediumTermMemory {
+			ids[mem.ID] = true
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	state, ok := cb.lookupState(stateKey(characterID, userID))
+	if !ok {
+		return
+	}
+
+	state.Lock()
+	changed := false
+	for i := range state.Memories {
+		if ids[state.Memories[i].ID] {
+			state.Memories[i].RecallCount++
+			changed = true
+		}
+	}
+	state.Unlock()
+
+	if changed {
+		cb.markDirty(state)
+	}
+}
+
+// countMemories returns how many memories are of type t
+func countMemories(memories []models.Memory, t models.MemoryType) int {
+	n := 0
+	for _, mem := range memories {
+		if mem.Type == t {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+func newConsolidationBot(t *testing.T, memCfg config.MemoryConfig) (*CharacterBot, *providers.MockProvider) {
+	t.Helper()
+	return newMockBot(t, &config.Config{MemoryConfig: memCfg}, &models.Character{ID: "keeper", Name: "Keeper"})
+}
+
+func TestConsolidateMemoriesSummarizesEpisodes(t *testing.T) {
+	bot, mock := newConsolidationBot(t, config.MemoryConfig{
+		ShortTermWindow:    4,
+		MediumTermDuration: 24 * time.Hour,
+		ConsolidationRate:  0.5,
+	})
+	mock.SetResponses([]string{"I remember our first walk.", "I remember the storm."})
+
+	start := time.Now().Add(-4 * time.Hour)
+	state := &models.CharacterState{
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Memories: []models.Memory{
+			{ID: "m1", Type: models.ShortTermMemory, Content: "Walked by the river", Emotional: 0.3, Timestamp: start},
+			{ID: "m2", Type: models.ShortTermMemory, Content: "Saw a heron", Emotional: 0.2, Timestamp: start.Add(5 * time.Minute)},
+			{ID: "m3", Type: models.ShortTermMemory, Content: "Talked about lunch", Emotional: 0.05, Timestamp: start.Add(10 * time.Minute)},
+			{ID: "m4", Type: models.ShortTermMemory, Content: "The storm hit", Emotional: 0.25, Timestamp: start.Add(3 * time.Hour)},
+			{ID: "m5", Type: models.ShortTermMemory, Content: "Closed the shutters", Emotional: 0.01, Timestamp: start.Add(3*time.Hour + 5*time.Minute)},
+			{Type: models.ShortTermMemory, Content: "Said goodnight", Timestamp: start.Add(3*time.Hour + 10*time.Minute)},
+		},
+	}
+
+	bot.consolidateMemories(context.Background(), state)
+
+	// Half of six memories, the most emotional, form two episodes
+	if got := mock.GetRequestCount(); got != 2 {
+		t.Fatalf("Expected one summary request per episode, got %d", got)
+	}
+	if req := mock.GetLastRequest(); req.CharacterID != "system-memory-consolidator" {
+		t.Errorf("Expected summary request from the consolidator, got %q", req.CharacterID)
+	}
+
+	var medium []string
+	short := map[string]bool{}
+	for _, mem := range state.Memories {
+		switch mem.Type {
+		case models.MediumTermMemory:
+			medium = append(medium, mem.Content)
+			if mem.ID == "" {
+				t.Error("Expected consolidated memory to have an ID")
+			}
+		case models.ShortTermMemory:
+			short[mem.Content] = true
+		}
+	}
+
+	if len(medium) != 2 || medium[0] != "I remember our first walk." || medium[1] != "I remember the storm." {
+		t.Errorf("Unexpected medium-term memories: %v", medium)
+	}
+	if len(short) != 3 || !short["Talked about lunch"] || !short["Closed the shutters"] || !short["Said goodnight"] {
+		t.Errorf("Expected only unselected short-term memories to remain, got %v", short)
+	}
+}
+
+func TestConsolidateMemoriesHonoursRate(t *testing.T) {
+	bot, mock := newConsolidationBot(t, config.MemoryConfig{
+		ShortTermWindow: 2,
+	})
+
+	now := time.Now()
+	state := &models.CharacterState{
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Memories: []models.Memory{
+			{ID: "old", Type: models.ShortTermMemory, Content: "oldest", Emotional: 0.9, Timestamp: now.Add(-3 * time.Minute)},
+			{ID: "mid", Type: models.ShortTermMemory, Content: "middle", Timestamp: now.Add(-2 * time.Minute)},
+			{ID: "new", Type: models.ShortTermMemory, Content: "newest", Timestamp: now.Add(-1 * time.Minute)},
+		},
+	}
+
+	// A zero rate consolidates nothing; the overflow is simply forgotten
+	bot.consolidateMemories(context.Background(), state)
+
+	if mock.GetRequestCount() != 0 {
+		t.Error("Expected no summaries with a zero consolidation rate")
+	}
+	if len(state.Memories) != 2 || state.Memories[0].ID != "mid" || state.Memories[1].ID != "new" {
+		t.Errorf("Expected the oldest memory to fall out of the window, got %+v", state.Memories)
+	}
+}
+
+func TestPromoteMemories(t *testing.T) {
+	bot, _ := newConsolidationBot(t, config.MemoryConfig{
+		ShortTermWindow:    20,
+		MediumTermDuration: time.Hour,
+	})
+
+	now := time.Now()
+	state := &models.CharacterState{
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Memories: []models.Memory{
+			{ID: "recalled", Type: models.MediumTermMemory, Emotional: 0.1, RecallCount: longTermRecallCount, Timestamp: now.Add(-2 * time.Hour)},
+			{ID: "emotional", Type: models.MediumTermMemory, Emotional: 0.6, Timestamp: now.Add(-2 * time.Hour)},
+			{ID: "stale", Type: models.MediumTermMemory, Emotional: 0.1, Timestamp: now.Add(-2 * time.Hour)},
+			{ID: "fresh", Type: models.MediumTermMemory, Emotional: 0.1, Timestamp: now},
+		},
+	}
+
+	bot.consolidateMemories(context.Background(), state)
+
+	got := map[string]models.MemoryType{}
+	for _, mem := range state.Memories {
+		got[mem.ID] = mem.Type
+	}
+	expected := map[string]models.MemoryType{
+		"recalled":  models.LongTermMemory,
+		"emotional": models.LongTermMemory,
+		"fresh":     models.MediumTermMemory,
+	}
+	if len(got) != len(expected) {
+		t.Errorf("Expected the stale memory to expire, got %v", got)
+	}
+	for id, want := range expected {
+		if got[id] != want {
+			t.Errorf("Memory %s: expected %s, got %s", id, want, got[id])
+		}
+	}
+}
+
+func TestRecalledMemoriesArePromoted(t *testing.T) {
+	bot, mock := newConsolidationBot(t, config.MemoryConfig{
+		ShortTermWindow:    1,
+		MediumTermDuration: 24 * time.Hour,
+		ConsolidationRate:  0.5,
+		RetrievalTopK:      3,
+	})
+	mock.SetResponses([]string{"I remember Alice's dog Biscuit learning to fetch the newspaper."})
+	bot.SetEmbedder(mock)
+	bot.SetMemoryIndex(repository.NewMemoryIndex(t.TempDir()))
+
+	state, err := bot.GetCharacterState("keeper", "alice")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	state.Lock()
+	state.Memories = []models.Memory{
+		{ID: "a", Type: models.ShortTermMemory, Content: "Biscuit brought the newspaper!", Emotional: 0.2, Timestamp: time.Now()},
+		{ID: "b", Type: models.ShortTermMemory, Content: "Nice weather.", Timestamp: time.Now()},
+	}
+	state.Unlock()
+
+	bot.consolidateMemories(context.Background(), state)
+
+	req := &models.ConversationRequest{CharacterID: "keeper", UserID: "alice", Message: "How is Biscuit doing with the newspaper?"}
+	for i := 0; i < longTermRecallCount; i++ {
+		if _, _, err := bot.BuildPrompt(req); err != nil {
+			t.Fatalf("Failed to build prompt: %v", err)
+		}
+	}
+
+	bot.consolidateMemories(context.Background(), state)
+
+	state.RLock()
+	defer state.RUnlock()
+	for _, mem := range state.Memories {
+		if mem.Content == "I remember Alice's dog Biscuit learning to fetch the newspaper." {
+			if mem.Type != models.LongTermMemory || mem.RecallCount != longTermRecallCount {
+				t.Errorf("Expected recalled memory to become long-term, got %s after %d recalls", mem.Type, mem.RecallCount)
+			}
+			return
+		}
+	}
+	t.Error("Expected the consolidated memory to be kept")
+}
+
+// stalledSummarizer never answers on its own; it returns once ctx ends
+type stalledSummarizer struct {
+	started chan struct{}
+}
+
+func (s stalledSummarizer) Summarize(ctx context.Context, characterName string, memories []models.Memory) (string, error) {
+	close(s.started)
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestStopCancelsRunningConsolidation(t *testing.T) {
+	bot, _ := newConsolidationBot(t, config.MemoryConfig{ShortTermWindow: 1, ConsolidationRate: 1})
+	summarizer := stalledSummarizer{started: make(chan struct{})}
+	bot.SetMemorySummarizer(summarizer)
+
+	state := &models.CharacterState{
+		CharacterID: "keeper",
+		UserID:      "alice",
+		Memories: []models.Memory{
+			{ID: "m1", Type: models.ShortTermMemory, Content: "Walked along the harbour at dawn", Emotional: 0.5, Timestamp: time.Now()},
+			{ID: "m2", Type: models.ShortTermMemory, Content: "Watched the fishing boats leave", Emotional: 0.5, Timestamp: time.Now()},
+		},
+	}
+	bot.scheduleConsolidation(state)
+	<-summarizer.started
+
+	stopped := make(chan struct{})
+	go func() {
+		captureStderr(t, bot.Stop)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(consolidationStopTimeout / 2):
+		t.Fatal("Expected Stop to cancel the running summary instead of waiting it out")
+	}
+
+	state.RLock()
+	defer state.RUnlock()
+	if len(state.Memories) != 1 || !strings.HasPrefix(state.Memories[0].Content, "Consolidated memories:") {
+		t.Errorf("Expected the pass to finish with the memories kept verbatim, got %+v", state.Memories)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// minRecallScore drops memories only loosely related to the message
+	minRecallScore = 0.2
+	// recallTimeout bounds the query embedding so a slow API can't stall a reply
+	recallTimeout = 5 * time.Second
+	// indexTimeout bounds embedding and storing one exchange
+	indexTimeout = 30 * time.Second
+	// indexingStopTimeout is how long Stop waits for pending index writes
+	indexingStopTimeout = 10 * time.Second
+)
+
+// retrieval returns the embedder and index, or nils when retrieval is disabled
+func (cb *CharacterBot) retrieval() (providers.Embedder, *repository.MemoryIndex) {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	if cb.embedder == nil || cb.memoryIndex == nil || cb.config.MemoryConfig.RetrievalTopK <= 0 {
+		return nil, nil
+	}
+	return cb.embedder, cb.memoryIndex
+}
+
+// recallMemories returns the indexed memories most relevant to the message.
+// It returns nil when retrieval is disabled or fails, and an empty slice when
+// nothing relevant was found.
+func (cb *CharacterBot) recallMemories(ctx context.Context, req *models.ConversationRequest) []repository.ScoredMemory {
+	embedder, index := cb.retrieval()
+	if embedder == nil || strings.TrimSpace(req.Message) == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, recallTimeout)
+	defer cancel()
+
+	vectors, err := embedder.Embed(ctx, []string{req.Message})
+	if err == nil && len(vectors) != 1 {
+		err = fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	if err != nil {
+		cb.warnRetrieval("Memory retrieval failed: %v", err)
+		return nil
+	}
+
+	recalled, err := index.Search(req.CharacterID, req.UserID, vectors[0], embedder.EmbeddingModel(),
+		cb.config.MemoryConfig.RetrievalTopK, minRecallScore)
+	if err != nil {
+		cb.warnRetrieval("Memory retrieval failed: %v", err)
+		return nil
+	}
+
+	// Recalls count toward promoting medium-term memories to long-term
+	cb.recordRecall(req.CharacterID, req.UserID, recalled)
+	return recalled
+}
+
+// indexExchange embeds the user's message and the reply in the background and
+// adds them to the memory index. Stop waits for pending writes.
+func (cb *CharacterBot) indexExchange(req *models.ConversationRequest, resp *providers.AIResponse) {
+	embedder, index := cb.retrieval()
+	if embedder == nil || resp.Content == "" {
+		return
+	}
+
+	memory := repository.IndexedMemory{
+		ID:        uuid.New().String(),
+		Content:   fmt.Sprintf("%s said: %s\nYou replied: %s", req.UserID, req.Message, resp.Content),
+		Type:      models.LongTermMemory,
+		Emotional: cb.calculateEmotionalWeight(resp.Emotions),
+		Timestamp: cb.now(),
+		Model:     embedder.EmbeddingModel(),
+	}
+
+	cb.indexing.Add(1)
+	go func() {
+		defer cb.indexing.Done()
+
+		// The request context may already be done once the reply is delivered
+		ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+		defer cancel()
+
+		if err := cb.addToIndex(ctx, embedder, index, req.CharacterID, req.UserID, memory); err != nil {
+			cb.warnRetrieval("Failed to index memory: %v", err)
+		}
+	}()
+}
+
+// addToIndex embeds memory.Content and stores it in the index
+func (cb *CharacterBot) addToIndex(ctx context.Context, embedder providers.Embedder, index *repository.MemoryIndex, characterID, userID string, memory repository.IndexedMemory) error {
+	vectors, err := embedder.Embed(ctx, []string{memory.Content})
+	if err != nil {
+		return fmt.Errorf("failed to embed memory: %w", err)
+	}
+	if len(vectors) != 1 {
+		return fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	memory.Vector = vectors[0]
+	return index.Add(characterID, userID, memory)
+}
+
+// warnRetrieval reports the first retrieval or indexing failure only, since
+// an endpoint that can't embed would otherwise warn on every turn
+func (cb *CharacterBot) warnRetrieval(format string, args ...interface{}) {
+	if cb.retrievalWarned.CompareAndSwap(false, true) {
+		fmt.Fprintf(cb.logs, "Warning: "+format+" (further memory retrieval failures are not reported)\n", args...)
+	}
+}
+
+// waitForIndexing blocks until pending index writes finish or timeout elapses
+func (cb *CharacterBot) waitForIndexing(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		cb.indexing.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		fmt.Fprintf(cb.logs, "Warning: Gave up waiting for memory indexing after %s\n", timeout)
+	}
+}
+
+// formatRecalledMemories renders retrieved memories for the user memory layer
+func formatRecalledMemories(recalled []repository.ScoredMemory) string {
+	if len(recalled) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\nRelevant memories of this user:\n")
+	for _, mem := range recalled {
+		sb.WriteString(fmt.Sprintf("- (%s) %s\n", mem.Timestamp.Format("2006-01-02"), mem.Content))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
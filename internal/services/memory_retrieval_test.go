@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+// hashEmbedder embeds offline with providers.HashEmbedding
+type hashEmbedder struct{}
+
+func (hashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = providers.HashEmbedding(text)
+	}
+	return vectors, nil
+}
+
+func (hashEmbedder) EmbeddingModel() string { return "hash" }
+
+func TestMemoryRetrieval(t *testing.T) {
+	cfg := &config.Config{
+		DefaultProvider: "mock",
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+		MemoryConfig: config.MemoryConfig{
+			ShortTermWindow: 20,
+			RetrievalTopK:   1,
+		},
+	}
+
+	bot := NewCharacterBot(cfg)
+	bot.SetMemoryIndex(repository.NewMemoryIndex(t.TempDir()))
+	bot.SetEmbedder(hashEmbedder{})
+
+	provider := &mockProvider{name: "mock", response: &providers.AIResponse{Content: "How lovely!"}}
+	bot.RegisterProvider("mock", provider)
+
+	char := &models.Character{ID: "memory-char", Name: "Memory Char"}
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	for _, message := range []string{
+		"My dog Biscuit just learned to fetch the newspaper",
+		"The weather has been stormy all week",
+	} {
+		_, err := bot.ProcessRequest(context.Background(), &models.ConversationRequest{
+			CharacterID: "memory-char",
+			UserID:      "alice",
+			Message:     message,
+		})
+		if err != nil {
+			t.Fatalf("Failed to process request: %v", err)
+		}
+	}
+	bot.waitForIndexing(5 * time.Second)
+
+	prompt, _, err := bot.BuildPrompt(&models.ConversationRequest{
+		CharacterID: "memory-char",
+		UserID:      "alice",
+		Message:     "Does Biscuit still fetch the newspaper?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+
+	if !strings.Contains(prompt, "Relevant memories of this user") || !strings.Contains(prompt, "dog Biscuit") {
+		t.Error("Expected the dog memory to be recalled into the prompt")
+	}
+	if strings.Contains(prompt, "stormy") {
+		t.Error("Expected only the top-k relevant memory to be recalled")
+	}
+
+	// Another user shares none of alice's memories
+	prompt, _, err = bot.BuildPrompt(&models.ConversationRequest{
+		CharacterID: "memory-char",
+		UserID:      "bob",
+		Message:     "Does Biscuit still fetch the newspaper?",
+	})
+	if err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+	if strings.Contains(prompt, "Biscuit just learned") {
+		t.Error("Expected alice's memories not to leak into bob's prompt")
+	}
+}
+
+// embeddingProvider is a chat provider that can also embed
+type embeddingProvider struct {
+	mockProvider
+	hashEmbedder
+}
+
+func TestInitializeEmbedderOnlyForEmbeddingProfiles(t *testing.T) {
+	newBot := func(embeddingModel string) *CharacterBot {
+		bot := NewCharacterBot(&config.Config{
+			DefaultProvider: "ollama",
+			MemoryConfig:    config.MemoryConfig{RetrievalTopK: 5, EmbeddingModel: embeddingModel},
+		})
+		bot.RegisterProvider("ollama", &embeddingProvider{mockProvider: mockProvider{name: "ollama"}})
+		bot.InitializeEmbedder()
+		return bot
+	}
+
+	// Ollama doesn't serve the default OpenAI embedding model
+	if embedder, _ := newBot("").retrieval(); embedder != nil {
+		t.Error("Expected retrieval to stay off for a profile without known embeddings")
+	}
+	bot := newBot("nomic-embed-text")
+	bot.SetMemoryIndex(repository.NewMemoryIndex(t.TempDir()))
+	if embedder, _ := bot.retrieval(); embedder == nil {
+		t.Error("Expected a configured embedding model to enable retrieval")
+	}
+}
+
+// failingEmbedder stands in for an endpoint without /embeddings
+type failingEmbedder struct{}
+
+func (failingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, errors.New("404 page not found")
+}
+
+func (failingEmbedder) EmbeddingModel() string { return "missing" }
+
+func TestMemoryRetrievalWarnsOnce(t *testing.T) {
+	bot := NewCharacterBot(&config.Config{
+		DefaultProvider: "mock",
+		MemoryConfig:    config.MemoryConfig{RetrievalTopK: 5},
+	})
+	var logs strings.Builder
+	bot.SetLogOutput(&logs)
+	bot.SetMemoryIndex(repository.NewMemoryIndex(t.TempDir()))
+	bot.SetEmbedder(failingEmbedder{})
+
+	for i := 0; i < 3; i++ {
+		req := &models.ConversationRequest{CharacterID: "c", UserID: "u", Message: "Hello there"}
+		if recalled := bot.recallMemories(context.Background(), req); recalled != nil {
+			t.Errorf("Expected no memories from a failing embedder, got %v", recalled)
+		}
+		bot.indexExchange(req, &providers.AIResponse{Content: "Hi"})
+	}
+	bot.waitForIndexing(time.Second)
+
+	if got := strings.Count(logs.String(), "Warning:"); got != 1 {
+		t.Errorf("Expected a single warning, got %d: %q", got, logs.String())
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// spendScopeKey carries the user and character the bot's own requests made
+// under a context are charged to
+type spendScopeKey struct{}
+
+type spendScope struct {
+	userID      string
+	characterID string
+}
+
+// withSpendScope charges requests the bot makes on its own behalf under ctx,
+// such as emotion scoring or memory summaries, to a user and character
+func withSpendScope(ctx context.Context, userID, characterID string) context.Context {
+	return context.WithValue(ctx, spendScopeKey{}, spendScope{userID: userID, characterID: characterID})
+}
+
+// sendMetered sends a request the bot makes on its own behalf through the
+// same failover, rate limits, pricing and budgets as replies. Without a spend
+// scope in ctx the request is rate limited and priced but not charged.
+func (cb *CharacterBot) sendMetered(ctx context.Context, apiReq *providers.PromptRequest) (*providers.AIResponse, error) {
+	scope, scoped := ctx.Value(spendScopeKey{}).(spendScope)
+	if scoped {
+		if err := cb.checkBudget(scope.userID, scope.characterID); err != nil {
+			return nil, err
+		}
+		if apiReq.UserID == "" {
+			apiReq.UserID = scope.userID
+		}
+	}
+
+	resp, err := cb.sendWithFailover(ctx, apiReq)
+	if err != nil {
+		return nil, err
+	}
+	cb.priceResponse(resp)
+	if scoped {
+		cb.recordSpend(scope.userID, scope.characterID, resp)
+	}
+	return resp, nil
+}
+
+// meteredProvider hands sendMetered to components that take a provider, such
+// as LLMEmotionAnalyzer and LLMMemorySummarizer
+type meteredProvider struct {
+	cb *CharacterBot
+}
+
+// MeteredProvider returns a provider that sends through the bot's failover
+// chain, rate limits and pricing, for requests made outside a conversation
+// such as generating a character
+func (cb *CharacterBot) MeteredProvider() providers.AIProvider {
+	return meteredProvider{cb}
+}
+
+func (p meteredProvider) Name() string {
+	return p.cb.config.DefaultProvider
+}
+
+func (p meteredProvider) SendRequest(ctx context.Context, req *providers.PromptRequest) (*providers.AIResponse, error) {
+	return p.cb.sendMetered(ctx, req)
+}
+
+func (p meteredProvider) SendStreamRequest(ctx context.Context, req *providers.PromptRequest, out chan<- providers.PartialAIResponse) error {
+	defer close(out)
+	resp, err := p.cb.sendMetered(ctx, req)
+	if err != nil {
+		return err
+	}
+	out <- providers.PartialAIResponse{Content: resp.Content, Done: true}
+	return nil
+}
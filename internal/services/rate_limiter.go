@@ -1,177 +1,519 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/dotcommander/roleplay/internal/cache"
+	"github.com/dotcommander/roleplay/internal/config"
 )
 
-// RateLimiter manages request rates per user-character pair to optimize cache routing
-type RateLimiter struct {
-	mu        sync.RWMutex
-	buckets   map[string]*bucket
-	maxRate   int           // Max requests per window
-	window    time.Duration // Time window (e.g., 1 minute)
-	cleanupInterval time.Duration
-	stopCleanup     chan struct{}
-}
-
-// bucket tracks requests for a specific user-character pair
-type bucket struct {
-	requests []time.Time
-	mu       sync.Mutex
-}
-
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(maxRate int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		buckets:         make(map[string]*bucket),
-		maxRate:         maxRate,
-		window:          window,
-		cleanupInterval: window * 2, // Cleanup stale buckets every 2 windows
-		stopCleanup:     make(chan struct{}),
-	}
-	
-	// Start background cleanup
-	go rl.cleanup()
-	
-	return rl
-}
-
-// Allow checks if a request should be allowed for the given user-character pair
-func (rl *RateLimiter) Allow(userID, characterID string) (bool, error) {
-	key := fmt.Sprintf("%s:%s", userID, characterID)
-	
-	rl.mu.RLock()
-	b, exists := rl.buckets[key]
-	rl.mu.RUnlock()
-	
-	if !exists {
-		rl.mu.Lock()
-		b = &bucket{requests: make([]time.Time, 0, rl.maxRate)}
-		rl.buckets[key] = b
-		rl.mu.Unlock()
-	}
-	
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	
-	now := time.Now()
-	cutoff := now.Add(-rl.window)
-	
-	// Remove expired requests
-	validRequests := make([]time.Time, 0, len(b.requests))
-	for _, t := range b.requests {
-		if t.After(cutoff) {
-			validRequests = append(validRequests, t)
-		}
-	}
-	b.requests = validRequests
-	
-	// Check if we're under the limit
-	if len(b.requests) >= rl.maxRate {
-		return false, fmt.Errorf("rate limit exceeded: %d requests in %v for user %s with character %s", 
-			len(b.requests), rl.window, userID, characterID)
-	}
-	
-	// Add current request
-	b.requests = append(b.requests, now)
-	return true, nil
-}
-
-// GetCurrentRate returns the current request rate for a user-character pair
-func (rl *RateLimiter) GetCurrentRate(userID, characterID string) int {
-	key := fmt.Sprintf("%s:%s", userID, characterID)
-	
-	rl.mu.RLock()
-	b, exists := rl.buckets[key]
-	rl.mu.RUnlock()
-	
-	if !exists {
+// defaultRateLimits apply to profiles missing from the rate_limits config.
+// They follow the entry-level published limits; local servers are unlimited.
+var defaultRateLimits = map[string]config.RateLimitPolicy{
+	"openai":     {RequestsPerMinute: 500, TokensPerMinute: 200000},
+	"anthropic":  {RequestsPerMinute: 50, TokensPerMinute: 50000},
+	"gemini":     {RequestsPerMinute: 15, TokensPerMinute: 1000000},
+	"groq":       {RequestsPerMinute: 30, TokensPerMinute: 6000},
+	"openrouter": {RequestsPerMinute: 20},
+	"ollama":     {},
+	"lmstudio":   {},
+	"lm_studio":  {},
+	"local":      {},
+	"mock":       {},
+}
+
+// RateLimitExceededError is returned when a request would exceed the rate
+// limit policy of a provider
+type RateLimitExceededError struct {
+	Provider   string
+	Limit      string        // "requests" or "tokens"
+	PerMinute  int           // The policy's limit
+	RetryAfter time.Duration // When enough capacity will be available
+}
+
+func (e *RateLimitExceededError) Error() string {
+	return fmt.Sprintf("rate limit exceeded: %s allows %d %s per minute; retry in %s",
+		e.Provider, e.PerMinute, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// RateLimitMode selects what happens to a request over a provider's rate limit
+type RateLimitMode string
+
+const (
+	// RateLimitFail fails the request with a *RateLimitExceededError
+	RateLimitFail RateLimitMode = "fail"
+	// RateLimitWait queues the request until the provider has capacity
+	RateLimitWait RateLimitMode = "wait"
+)
+
+// ParseRateLimitMode validates a mode given on the command line
+func ParseRateLimitMode(s string) (RateLimitMode, error) {
+	switch mode := RateLimitMode(strings.ToLower(s)); mode {
+	case RateLimitFail, RateLimitWait:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid rate limit mode %q: use wait or fail", s)
+}
+
+// RateLimitWaitEvent describes a request queued behind a provider's rate limit
+type RateLimitWaitEvent struct {
+	Provider string
+	Until    time.Time // Estimated time the request is sent
+}
+
+type rateLimitNotifierKey struct{}
+
+// WithRateLimitNotifier returns a context that reports rate limit waits to fn,
+// first when the request is queued and again whenever its estimate changes.
+// fn is called with the limiter locked and must not block.
+func WithRateLimitNotifier(ctx context.Context, fn func(RateLimitWaitEvent)) context.Context {
+	return context.WithValue(ctx, rateLimitNotifierKey{}, fn)
+}
+
+// tokenBucket refills continuously up to a minute's worth of capacity
+type tokenBucket struct {
+	capacity float64
+	level    float64
+	rate     float64 // Units per second
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		level:    float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.level = math.Min(b.capacity, b.level+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// delay returns how long until n units are available. Requests larger than
+// the bucket only need a full bucket, so they are slowed rather than refused.
+func (b *tokenBucket) delay(n float64, now time.Time) time.Duration {
+	b.refill(now)
+	n = math.Min(n, b.capacity)
+	if b.level >= n {
 		return 0
 	}
-	
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	
-	cutoff := time.Now().Add(-rl.window)
-	count := 0
-	for _, t := range b.requests {
-		if t.After(cutoff) {
-			count++
+	return time.Duration((n - b.level) / b.rate * float64(time.Second))
+}
+
+// take removes n units, letting the level go negative so underestimates are
+// paid back before the next request
+func (b *tokenBucket) take(n float64, now time.Time) {
+	b.refill(now)
+	b.level = math.Min(b.capacity, b.level-n)
+}
+
+// waiter is a request queued for a provider's capacity
+type waiter struct {
+	user      string
+	character string
+	tokens    int
+	notify    func(RateLimitWaitEvent)
+	granted   chan struct{} // Closed once the capacity has been taken for it
+}
+
+func (w *waiter) isGranted() bool {
+	select {
+	case <-w.granted:
+		return true
+	default:
+		return false
+	}
+}
+
+// providerLimiter holds the request and token buckets of one provider;
+// a nil bucket is unlimited
+type providerLimiter struct {
+	policy   config.RateLimitPolicy
+	requests *tokenBucket
+	tokens   *tokenBucket
+
+	queues map[string][]*waiter // Waiting requests per user, oldest first
+	order  []string             // Users with waiting requests, served round-robin
+	timer  *time.Timer          // Pending dispatch of the next waiter
+}
+
+// delay returns how long until both buckets can take a request of tokens
+func (pl *providerLimiter) delay(tokens int, now time.Time) time.Duration {
+	var wait time.Duration
+	if pl.requests != nil {
+		wait = pl.requests.delay(1, now)
+	}
+	if pl.tokens != nil {
+		if d := pl.tokens.delay(float64(tokens), now); d > wait {
+			wait = d
 		}
 	}
-	
-	return count
+	return wait
 }
 
-// cleanup removes stale buckets periodically
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(rl.cleanupInterval)
-	defer ticker.Stop()
-	
-	for {
-		select {
-		case <-ticker.C:
-			rl.cleanupStale()
-		case <-rl.stopCleanup:
-			return
+// take charges a request of tokens to both buckets; negative values refund
+func (pl *providerLimiter) take(requests, tokens int, now time.Time) {
+	if pl.requests != nil {
+		pl.requests.take(float64(requests), now)
+	}
+	if pl.tokens != nil {
+		pl.tokens.take(float64(tokens), now)
+	}
+}
+
+// enqueue adds w behind the other requests of its user
+func (pl *providerLimiter) enqueue(w *waiter) {
+	if len(pl.queues[w.user]) == 0 {
+		pl.order = append(pl.order, w.user)
+	}
+	pl.queues[w.user] = append(pl.queues[w.user], w)
+}
+
+// remove drops a waiter that gave up
+func (pl *providerLimiter) remove(w *waiter) {
+	queue := pl.queues[w.user]
+	for i, q := range queue {
+		if q == w {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		pl.queues[w.user] = queue
+		return
+	}
+	delete(pl.queues, w.user)
+	for i, user := range pl.order {
+		if user == w.user {
+			pl.order = append(pl.order[:i], pl.order[i+1:]...)
+			break
 		}
 	}
 }
 
-// cleanupStale removes buckets that haven't been used recently
-func (rl *RateLimiter) cleanupStale() {
+// sharedBuckets is the bucket levels of a provider as stored for other
+// processes
+type sharedBuckets struct {
+	Requests float64   `json:"requests"`
+	Tokens   float64   `json:"tokens"`
+	Updated  time.Time `json:"updated"`
+}
+
+// rateLimitsDocument names the bucket levels of every provider in the store
+const rateLimitsDocument = "rate_limits"
+
+// restore replaces the bucket levels with those saved by another process
+func (pl *providerLimiter) restore(shared sharedBuckets) {
+	if pl.requests != nil {
+		pl.requests.level = math.Min(pl.requests.capacity, shared.Requests)
+		pl.requests.last = shared.Updated
+	}
+	if pl.tokens != nil {
+		pl.tokens.level = math.Min(pl.tokens.capacity, shared.Tokens)
+		pl.tokens.last = shared.Updated
+	}
+}
+
+// snapshot returns the bucket levels as of now
+func (pl *providerLimiter) snapshot(now time.Time) sharedBuckets {
+	shared := sharedBuckets{Updated: now}
+	if pl.requests != nil {
+		pl.requests.refill(now)
+		shared.Requests = pl.requests.level
+	}
+	if pl.tokens != nil {
+		pl.tokens.refill(now)
+		shared.Tokens = pl.tokens.level
+	}
+	return shared
+}
+
+// RateLimiter throttles requests per provider profile with token buckets for
+// requests per minute and estimated tokens per minute
+type RateLimiter struct {
+	mu        sync.Mutex
+	policies  map[string]config.RateLimitPolicy
+	providers map[string]*providerLimiter
+	store     *cache.DiskStore // Shares bucket levels between processes; nil keeps them in memory
+	now       func() time.Time
+	logs      io.Writer // Where store failures are reported
+}
+
+// NewRateLimiter creates a rate limiter. Policies are keyed by provider
+// profile, with "*" applying to profiles that have no policy of their own
+// or in defaultRateLimits.
+func NewRateLimiter(policies map[string]config.RateLimitPolicy) *RateLimiter {
+	normalized := make(map[string]config.RateLimitPolicy, len(policies))
+	for name, policy := range policies {
+		normalized[strings.ToLower(name)] = policy
+	}
+	return &RateLimiter{
+		policies:  normalized,
+		providers: make(map[string]*providerLimiter),
+		now:       time.Now,
+		logs:      stderr{},
+	}
+}
+
+// Policy returns the policy applied to a provider profile
+func (rl *RateLimiter) Policy(provider string) config.RateLimitPolicy {
+	provider = strings.ToLower(provider)
+	if policy, ok := rl.policies[provider]; ok {
+		return policy
+	}
+	if policy, ok := defaultRateLimits[provider]; ok {
+		return policy
+	}
+	return rl.policies["*"]
+}
+
+// SetStore shares the limits with other processes through store, so
+// concurrent CLI invocations draw from the same buckets
+func (rl *RateLimiter) SetStore(store *cache.DiskStore) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-	
-	cutoff := time.Now().Add(-rl.window * 2)
-	
-	for key, b := range rl.buckets {
-		b.mu.Lock()
-		if len(b.requests) == 0 || (len(b.requests) > 0 && b.requests[len(b.requests)-1].Before(cutoff)) {
-			delete(rl.buckets, key)
+	rl.store = store
+}
+
+// shared runs fn on the provider's buckets. With a store, fn sees the levels
+// other processes left and its changes are saved for them, all under the
+// store's lock. The caller holds rl.mu.
+func (rl *RateLimiter) shared(provider string, pl *providerLimiter, fn func()) {
+	if rl.store == nil || (pl.requests == nil && pl.tokens == nil) {
+		fn()
+		return
+	}
+
+	ran := false
+	levels := make(map[string]sharedBuckets)
+	err := rl.store.Update(rateLimitsDocument, &levels, func() error {
+		if shared, ok := levels[provider]; ok {
+			pl.restore(shared)
+		}
+		fn()
+		ran = true
+		levels[provider] = pl.snapshot(rl.now())
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(rl.logs, "Warning: Failed to share rate limits: %v\n", err)
+		if !ran {
+			fn()
 		}
-		b.mu.Unlock()
 	}
 }
 
-// Stop gracefully stops the rate limiter
-func (rl *RateLimiter) Stop() {
-	close(rl.stopCleanup)
+// limiter returns the buckets of a provider; the caller holds rl.mu
+func (rl *RateLimiter) limiter(provider string) *providerLimiter {
+	pl, ok := rl.providers[provider]
+	if !ok {
+		policy := rl.Policy(provider)
+		now := rl.now()
+		pl = &providerLimiter{
+			policy:   policy,
+			requests: newTokenBucket(policy.RequestsPerMinute, now),
+			tokens:   newTokenBucket(policy.TokensPerMinute, now),
+			queues:   make(map[string][]*waiter),
+		}
+		rl.providers[provider] = pl
+	}
+	return pl
 }
 
-// GetStats returns statistics about current rate limiting
-func (rl *RateLimiter) GetStats() map[string]interface{} {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-	
-	stats := map[string]interface{}{
-		"total_buckets": len(rl.buckets),
-		"max_rate":      rl.maxRate,
-		"window":        rl.window.String(),
-		"active_pairs":  make([]map[string]interface{}, 0),
-	}
-	
-	for key, b := range rl.buckets {
-		b.mu.Lock()
-		cutoff := time.Now().Add(-rl.window)
-		activeCount := 0
-		for _, t := range b.requests {
-			if t.After(cutoff) {
-				activeCount++
+// Allow takes one request and the estimated tokens from the provider's
+// buckets, or returns a *RateLimitExceededError without taking anything
+func (rl *RateLimiter) Allow(provider string, tokens int) error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	pl := rl.limiter(provider)
+	var err error
+	rl.shared(provider, pl, func() {
+		err = pl.allow(provider, tokens, rl.now())
+	})
+	return err
+}
+
+// allow takes a request from the buckets if both have capacity
+func (pl *providerLimiter) allow(provider string, tokens int, now time.Time) error {
+	if pl.requests != nil {
+		if wait := pl.requests.delay(1, now); wait > 0 {
+			return &RateLimitExceededError{Provider: provider, Limit: "requests", PerMinute: pl.policy.RequestsPerMinute, RetryAfter: wait}
+		}
+	}
+	if pl.tokens != nil {
+		if wait := pl.tokens.delay(float64(tokens), now); wait > 0 {
+			return &RateLimitExceededError{Provider: provider, Limit: "tokens", PerMinute: pl.policy.TokensPerMinute, RetryAfter: wait}
+		}
+	}
+
+	pl.take(1, tokens, now)
+	return nil
+}
+
+// Wait takes one request and the estimated tokens from the provider's
+// buckets, blocking until they are available or ctx is done. Waiting
+// requests are served round-robin across users, so one user's backlog
+// cannot starve another's, and in order within each user.
+func (rl *RateLimiter) Wait(ctx context.Context, userID, characterID, provider string, tokens int) error {
+	rl.mu.Lock()
+	pl := rl.limiter(provider)
+	if len(pl.order) == 0 {
+		var err error
+		rl.shared(provider, pl, func() {
+			err = pl.allow(provider, tokens, rl.now())
+		})
+		if err == nil {
+			rl.mu.Unlock()
+			return nil
+		}
+	}
+
+	w := &waiter{
+		user:      userID,
+		character: characterID,
+		tokens:    tokens,
+		granted:   make(chan struct{}),
+	}
+	w.notify, _ = ctx.Value(rateLimitNotifierKey{}).(func(RateLimitWaitEvent))
+	pl.enqueue(w)
+	rl.dispatch(provider, pl)
+	// dispatch told the head of the queue when it goes; give the others
+	// an estimate too
+	if w.notify != nil && !w.isGranted() && pl.order[0] != w.user {
+		now := rl.now()
+		w.notify(RateLimitWaitEvent{Provider: provider, Until: now.Add(pl.delay(tokens, now))})
+	}
+	rl.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if w.isGranted() {
+		// Granted while giving up; return the capacity
+		rl.shared(provider, pl, func() {
+			pl.take(-1, -tokens, rl.now())
+		})
+	} else {
+		pl.remove(w)
+	}
+	rl.dispatch(provider, pl)
+	return ctx.Err()
+}
+
+// dispatch grants queued requests while the provider has capacity, then
+// schedules itself for when the next one fits; the caller holds rl.mu
+func (rl *RateLimiter) dispatch(provider string, pl *providerLimiter) {
+	if pl.timer != nil {
+		pl.timer.Stop()
+		pl.timer = nil
+	}
+	if len(pl.order) == 0 {
+		return
+	}
+
+	rl.shared(provider, pl, func() {
+		now := rl.now()
+		for len(pl.order) > 0 {
+			user := pl.order[0]
+			w := pl.queues[user][0]
+			if wait := pl.delay(w.tokens, now); wait > 0 {
+				if w.notify != nil {
+					w.notify(RateLimitWaitEvent{Provider: provider, Until: now.Add(wait)})
+				}
+				pl.timer = time.AfterFunc(wait, func() {
+					rl.mu.Lock()
+					defer rl.mu.Unlock()
+					rl.dispatch(provider, pl)
+				})
+				return
 			}
+
+			pl.take(1, w.tokens, now)
+			pl.remove(w)
+			// Serve the user's next request after everyone else's
+			if len(pl.queues[user]) > 0 {
+				pl.order = append(pl.order[1:], user)
+			}
+			close(w.granted)
 		}
-		b.mu.Unlock()
-		
-		if activeCount > 0 {
-			stats["active_pairs"] = append(stats["active_pairs"].([]map[string]interface{}), map[string]interface{}{
-				"key":   key,
-				"count": activeCount,
-				"rate":  fmt.Sprintf("%.1f%%", float64(activeCount)/float64(rl.maxRate)*100),
-			})
+	})
+}
+
+// Adjust corrects a provider's token bucket once the real usage of a request
+// is known; delta is actual minus estimated tokens
+func (rl *RateLimiter) Adjust(provider string, delta int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if pl := rl.limiter(provider); pl.tokens != nil {
+		rl.shared(provider, pl, func() {
+			pl.tokens.take(float64(delta), rl.now())
+		})
+	}
+}
+
+// GetStats returns the policy and remaining capacity of each provider used so far
+func (rl *RateLimiter) GetStats() map[string]interface{} {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Report the levels other processes left
+	levels := make(map[string]sharedBuckets)
+	if rl.store != nil {
+		if _, err := rl.store.Load(rateLimitsDocument, &levels); err != nil {
+			fmt.Fprintf(rl.logs, "Warning: Failed to read shared rate limits: %v\n", err)
+		}
+	}
+
+	now := rl.now()
+	providers := make(map[string]interface{}, len(rl.providers))
+	for name, pl := range rl.providers {
+		if shared, ok := levels[name]; ok {
+			pl.restore(shared)
 		}
+		stats := map[string]interface{}{
+			"requests_per_minute": pl.policy.RequestsPerMinute,
+			"tokens_per_minute":   pl.policy.TokensPerMinute,
+		}
+		if pl.requests != nil {
+			pl.requests.refill(now)
+			stats["requests_available"] = int(pl.requests.level)
+		}
+		if pl.tokens != nil {
+			pl.tokens.refill(now)
+			stats["tokens_available"] = int(pl.tokens.level)
+		}
+		var queued []string
+		for _, user := range pl.order {
+			for _, w := range pl.queues[user] {
+				queued = append(queued, w.user+":"+w.character)
+			}
+		}
+		stats["queued"] = queued
+		providers[name] = stats
+	}
+
+	return map[string]interface{}{
+		"providers": providers,
 	}
-	
-	return stats
-}
\ No newline at end of file
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/cache"
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+func TestRateLimiterPolicies(t *testing.T) {
+	rl := NewRateLimiter(map[string]config.RateLimitPolicy{
+		"OpenAI": {RequestsPerMinute: 60},
+		"*":      {RequestsPerMinute: 5},
+	})
+
+	tests := []struct {
+		provider string
+		want     config.RateLimitPolicy
+	}{
+		{"openai", config.RateLimitPolicy{RequestsPerMinute: 60}},
+		{"groq", defaultRateLimits["groq"]},
+		{"ollama", config.RateLimitPolicy{}},
+		{"my-proxy", config.RateLimitPolicy{RequestsPerMinute: 5}},
+	}
+	for _, tt := range tests {
+		if got := rl.Policy(tt.provider); got != tt.want {
+			t.Errorf("Policy(%q) = %+v, want %+v", tt.provider, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterTokenBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rl := NewRateLimiter(map[string]config.RateLimitPolicy{
+		"openai": {RequestsPerMinute: 2},
+		"groq":   {TokensPerMinute: 600},
+	})
+	rl.now = func() time.Time { return now }
+
+	if err := rl.Allow("openai", 100); err != nil {
+		t.Fatalf("Expected the first request to pass, got %v", err)
+	}
+	if err := rl.Allow("openai", 100); err != nil {
+		t.Fatalf("Expected the second request to pass, got %v", err)
+	}
+
+	// The request bucket is empty and refills one request every 30 seconds
+	var limited *RateLimitExceededError
+	if err := rl.Allow("openai", 100); !errors.As(err, &limited) || limited.Limit != "requests" {
+		t.Fatalf("Expected the request limit to apply, got %v", err)
+	}
+	if limited.RetryAfter != 30*time.Second {
+		t.Errorf("Expected to retry in 30s, got %v", limited.RetryAfter)
+	}
+
+	// Tokens refill at 10 per second
+	if err := rl.Allow("groq", 500); err != nil {
+		t.Fatalf("Expected 500 of 600 tokens to pass, got %v", err)
+	}
+	if err := rl.Allow("groq", 200); !errors.As(err, &limited) || limited.Limit != "tokens" {
+		t.Fatalf("Expected the token limit to apply, got %v", err)
+	}
+	if limited.RetryAfter != 10*time.Second {
+		t.Errorf("Expected to retry in 10s, got %v", limited.RetryAfter)
+	}
+
+	// A reply that used more than estimated is paid back from the bucket
+	rl.Adjust("groq", 300)
+	now = now.Add(10 * time.Second)
+	if err := rl.Allow("groq", 200); !errors.As(err, &limited) || limited.RetryAfter != 30*time.Second {
+		t.Fatalf("Expected the underestimate to delay the next request by 30s, got %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := rl.Allow("groq", 200); err != nil {
+		t.Errorf("Expected capacity for 200 tokens, got %v", err)
+	}
+
+	// Unlimited providers are never throttled
+	for i := 0; i < 1000; i++ {
+		if err := rl.Allow("ollama", 100000); err != nil {
+			t.Fatalf("Expected ollama to be unlimited, got %v", err)
+		}
+	}
+}
+
+func TestProcessRequestFailsOverWhenRateLimited(t *testing.T) {
+	bot, mock := newContextBot(t)
+	bot.rateLimiter = NewRateLimiter(map[string]config.RateLimitPolicy{
+		"mock": {RequestsPerMinute: 1},
+	})
+
+	req := &models.ConversationRequest{CharacterID: "narrator", UserID: "alice", Message: "Hello"}
+	if _, err := bot.ProcessRequest(context.Background(), req); err != nil {
+		t.Fatalf("Expected the first request to pass, got %v", err)
+	}
+
+	req.Message = "Hello again"
+	_, err := bot.ProcessRequest(context.Background(), req)
+	var limited *RateLimitExceededError
+	if !errors.As(err, &limited) || limited.Provider != "mock" {
+		t.Fatalf("Expected a rate limit error for the only provider, got %v", err)
+	}
+	if mock.GetRequestCount() != 1 {
+		t.Errorf("Expected the throttled request not to reach the provider, got %d requests", mock.GetRequestCount())
+	}
+
+	// A second provider takes over while the first is throttled
+	backup := &mockProvider{name: "backup"}
+	bot.RegisterProvider("backup", backup)
+	resp, err := bot.ProcessRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected failover to the backup provider, got %v", err)
+	}
+	if resp.Provider != "backup" {
+		t.Errorf("Expected the backup provider to answer, got %s", resp.Provider)
+	}
+}
+
+// drain empties a provider's request bucket
+func drain(t *testing.T, rl *RateLimiter, provider string) {
+	t.Helper()
+	for i := 0; rl.Allow(provider, 0) == nil; i++ {
+		if i > 100000 {
+			t.Fatalf("Expected %s to run out of requests", provider)
+		}
+	}
+}
+
+// queued returns the requests waiting for a provider
+func queued(rl *RateLimiter, provider string) []string {
+	providers, _ := rl.GetStats()["providers"].(map[string]interface{})
+	stats, _ := providers[provider].(map[string]interface{})
+	waiting, _ := stats["queued"].([]string)
+	return waiting
+}
+
+func TestRateLimiterWaitIsFairAcrossUsers(t *testing.T) {
+	// The limiter's clock only moves when the test advances it
+	var mu sync.Mutex
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rl := NewRateLimiter(map[string]config.RateLimitPolicy{"mock": {RequestsPerMinute: 60}})
+	rl.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	drain(t, rl, "mock")
+
+	served := make(chan string, 4)
+	for i, user := range []string{"alice", "alice", "alice", "bob"} {
+		user := user
+		go func() {
+			if err := rl.Wait(context.Background(), user, "narrator", "mock", 0); err != nil {
+				t.Errorf("Expected %s to get a slot, got %v", user, err)
+			}
+			served <- user
+		}()
+		// Queue the requests in a known order
+		for len(queued(rl, "mock")) == i {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Free one slot at a time
+	var order []string
+	for range 4 {
+		mu.Lock()
+		now = now.Add(time.Second)
+		mu.Unlock()
+		rl.mu.Lock()
+		rl.dispatch("mock", rl.providers["mock"])
+		rl.mu.Unlock()
+
+		select {
+		case user := <-served:
+			order = append(order, user)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for a slot, served %v", order)
+		}
+	}
+	want := []string{"alice", "bob", "alice", "alice"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Expected bob not to wait behind alice's backlog: want %v, got %v", want, order)
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	rl := NewRateLimiter(map[string]config.RateLimitPolicy{"mock": {RequestsPerMinute: 1}})
+	drain(t, rl, "mock")
+
+	var notified RateLimitWaitEvent
+	ctx := WithRateLimitNotifier(context.Background(), func(ev RateLimitWaitEvent) { notified = ev })
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	if err := rl.Wait(ctx, "alice", "narrator", "mock", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the wait to end with the context, got %v", err)
+	}
+	if notified.Provider != "mock" || time.Until(notified.Until) < 55*time.Second {
+		t.Errorf("Expected to be told about a wait of about a minute, got %+v", notified)
+	}
+	if waiting := queued(rl, "mock"); len(waiting) != 0 {
+		t.Errorf("Expected the cancelled request to leave the queue, got %v", waiting)
+	}
+}
+
+func TestProcessRequestWaitsForRateLimit(t *testing.T) {
+	bot, mock := newContextBot(t)
+	bot.rateLimiter = NewRateLimiter(map[string]config.RateLimitPolicy{"mock": {RequestsPerMinute: 600}})
+	bot.SetRateLimitMode(RateLimitWait)
+	drain(t, bot.rateLimiter, "mock")
+
+	req := &models.ConversationRequest{CharacterID: "narrator", UserID: "alice", Message: "Hello"}
+	if _, err := bot.ProcessRequest(context.Background(), req); err != nil {
+		t.Fatalf("Expected the request to wait for a slot, got %v", err)
+	}
+	if mock.GetRequestCount() != 1 {
+		t.Errorf("Expected the request to reach the provider, got %d requests", mock.GetRequestCount())
+	}
+}
+
+func TestRateLimiterSharedThroughDiskStore(t *testing.T) {
+	store := cache.NewDiskStore(t.TempDir())
+	policies := map[string]config.RateLimitPolicy{"openai": {RequestsPerMinute: 2}}
+
+	// Each CLI invocation creates its own limiter
+	first := NewRateLimiter(policies)
+	first.SetStore(store)
+	if err := first.Allow("openai", 0); err != nil {
+		t.Fatalf("Expected the first request to pass, got %v", err)
+	}
+
+	second := NewRateLimiter(policies)
+	second.SetStore(store)
+	if err := second.Allow("openai", 0); err != nil {
+		t.Fatalf("Expected the second request to pass, got %v", err)
+	}
+
+	third := NewRateLimiter(policies)
+	third.SetStore(store)
+	var limited *RateLimitExceededError
+	if err := third.Allow("openai", 0); !errors.As(err, &limited) {
+		t.Fatalf("Expected requests from earlier processes to count, got %v", err)
+	}
+}
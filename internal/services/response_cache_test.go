@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+func TestResponseCacheKeyDependsOnContext(t *testing.T) {
+	bot, mock := newContextBot(t)
+	mock.SetResponses([]string{"Then onward!", "Very well.", "As you wish.", "Indeed."})
+
+	earlier := []models.Message{
+		{Role: "user", Content: "Shall we cross the bridge?", Timestamp: time.Now()},
+		{Role: "assistant", Content: "The bridge looks old.", Timestamp: time.Now()},
+	}
+	req := &models.ConversationRequest{
+		CharacterID: "narrator",
+		UserID:      "alice",
+		Message:     "yes",
+		Context:     models.ConversationContext{RecentMessages: earlier},
+	}
+	send := func() string {
+		t.Helper()
+		resp, err := bot.ProcessRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ProcessRequest failed: %v", err)
+		}
+		return resp.Content
+	}
+
+	first := send()
+	if again := send(); again != first || mock.GetRequestCount() != 1 {
+		t.Fatalf("Expected the same message after the same turns to be served from cache, got %q after %d requests", again, mock.GetRequestCount())
+	}
+
+	// The same word after different turns is a new question
+	req.Context.RecentMessages = []models.Message{
+		{Role: "user", Content: "Should we turn back?", Timestamp: time.Now()},
+		{Role: "assistant", Content: "Night is falling.", Timestamp: time.Now()},
+	}
+	if reply := send(); reply == first || mock.GetRequestCount() != 2 {
+		t.Errorf("Expected a different history to miss the cache, got %q after %d requests", reply, mock.GetRequestCount())
+	}
+
+	// A changed character definition invalidates its replies
+	req.Context.RecentMessages = earlier
+	char, _ := bot.GetCharacter("narrator")
+	char.Backstory = "A retired lighthouse keeper."
+	send()
+	if mock.GetRequestCount() != 3 {
+		t.Errorf("Expected a new character version to miss the cache, got %d requests", mock.GetRequestCount())
+	}
+}
+
+func TestResponseCacheKeyStableForMapFields(t *testing.T) {
+	bot, _ := newContextBot(t)
+	err := bot.CreateCharacter(&models.Character{
+		ID:                "innkeeper",
+		Name:              "Innkeeper",
+		Relationships:     map[string]string{"smith": "brother", "mayor": "rival", "bard": "regular", "guard": "cousin"},
+		EmotionalTriggers: map[string]string{"debts": "anger", "music": "joy", "storms": "fear", "gossip": "surprise"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	req := &models.ConversationRequest{CharacterID: "innkeeper", UserID: "alice", Message: "A room, please"}
+	first := bot.responseCacheKey(req)
+	for i := 0; i < 50; i++ {
+		if key := bot.responseCacheKey(req); key != first {
+			t.Fatalf("Expected the same key on every call, got %s then %s", first, key)
+		}
+	}
+}
+
+func TestResponseCachePolicies(t *testing.T) {
+	bot, mock := newContextBot(t)
+	mock.SetResponses([]string{"First.", "Second.", "Third."})
+
+	req := &models.ConversationRequest{CharacterID: "narrator", UserID: "alice", Message: "Tell me a story"}
+	send := func(policy models.CachePolicy) string {
+		t.Helper()
+		req.CachePolicy = policy
+		resp, err := bot.ProcessRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ProcessRequest failed: %v", err)
+		}
+		return resp.Content
+	}
+
+	send(models.CacheUse)
+
+	// Refresh always asks the provider and replaces the cached reply
+	if reply := send(models.CacheRefresh); reply != "Second." {
+		t.Errorf("Expected refresh to ask the provider, got %q", reply)
+	}
+	if reply := send(models.CacheUse); reply != "Second." {
+		t.Errorf("Expected the refreshed reply to be cached, got %q", reply)
+	}
+
+	// Bypass neither reads nor writes the cache
+	if reply := send(models.CacheBypass); reply != "Third." {
+		t.Errorf("Expected bypass to ask the provider, got %q", reply)
+	}
+	if reply := send(models.CacheUse); reply != "Second." {
+		t.Errorf("Expected bypass to leave the cached reply alone, got %q", reply)
+	}
+	if mock.GetRequestCount() != 3 {
+		t.Errorf("Expected 3 provider requests, got %d", mock.GetRequestCount())
+	}
+}
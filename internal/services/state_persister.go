@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+// CharacterStateStore persists per-user character state;
+// repository.CharacterStateRepository implements it
+type CharacterStateStore interface {
+	SaveState(state *models.CharacterState) error
+	LoadState(characterID, userID string) (*models.CharacterState, error)
+}
+
+// defaultSaveDebounce is used when no persistence debounce is configured
+const defaultSaveDebounce = 2 * time.Second
+
+// statePersister writes evolved character state back to a CharacterStateStore.
+// Saves are debounced so a burst of replies produces a single write; Stop
+// flushes whatever is still pending.
+type statePersister struct {
+	store    CharacterStateStore
+	lookup   func(key string) (*models.CharacterState, bool)
+	debounce time.Duration
+	logs     io.Writer // Where failed background saves are reported
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	timer   *time.Timer
+	stopped bool
+}
+
+// newStatePersister creates a persister that resolves state keys through lookup
+func newStatePersister(store CharacterStateStore, lookup func(key string) (*models.CharacterState, bool), debounce time.Duration) *statePersister {
+	if debounce <= 0 {
+		debounce = defaultSaveDebounce
+	}
+	return &statePersister{
+		store:    store,
+		lookup:   lookup,
+		debounce: debounce,
+		logs:     stderr{},
+		pending:  make(map[string]struct{}),
+	}
+}
+
+// MarkDirty schedules a save of the state once changes settle.
+// After Stop the save happens immediately.
+func (p *statePersister) MarkDirty(key string) {
+	p.mu.Lock()
+	p.pending[key] = struct{}{}
+	if p.stopped {
+		p.mu.Unlock()
+		if err := p.Flush(); err != nil {
+			fmt.Fprintf(p.logs, "Warning: Failed to persist character state: %v\n", err)
+		}
+		return
+	}
+	if p.timer == nil {
+		p.timer = time.AfterFunc(p.debounce, p.flushAsync)
+	} else {
+		p.timer.Reset(p.debounce)
+	}
+	p.mu.Unlock()
+}
+
+// flushAsync is the timer callback; errors can only be reported
+func (p *statePersister) flushAsync() {
+	if err := p.Flush(); err != nil {
+		fmt.Fprintf(p.logs, "Warning: Failed to persist character state: %v\n", err)
+	}
+}
+
+// Flush saves every pending state now
+func (p *statePersister) Flush() error {
+	p.mu.Lock()
+	keys := p.pending
+	p.pending = make(map[string]struct{})
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.mu.Unlock()
+
+	var errs []error
+	for key := range keys {
+		state, ok := p.lookup(key)
+		if !ok {
+			continue
+		}
+
+		state.RLock()
+		err := p.store.SaveState(state)
+		state.RUnlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to save state of %s for %s: %w", state.CharacterID, state.UserID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stop flushes pending saves; later changes are written immediately
+func (p *statePersister) Stop() error {
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	return p.Flush()
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// recordingStore keeps saved states in memory and counts saves
+type recordingStore struct {
+	mu     sync.Mutex
+	saves  map[string]int
+	states map[string]*models.CharacterState
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{
+		saves:  make(map[string]int),
+		states: make(map[string]*models.CharacterState),
+	}
+}
+
+func (s *recordingStore) SaveState(state *models.CharacterState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(state.CharacterID, state.UserID)
+	s.saves[key]++
+	s.states[key] = &models.CharacterState{
+		CharacterID: state.CharacterID,
+		UserID:      state.UserID,
+		CurrentMood: state.CurrentMood,
+		Memories:    append([]models.Memory(nil), state.Memories...),
+	}
+	return nil
+}
+
+func (s *recordingStore) LoadState(characterID, userID string) (*models.CharacterState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved, ok := s.states[stateKey(characterID, userID)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &models.CharacterState{
+		CharacterID: saved.CharacterID,
+		UserID:      saved.UserID,
+		CurrentMood: saved.CurrentMood,
+		Memories:    saved.Memories,
+	}, nil
+}
+
+func (s *recordingStore) count(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves[key]
+}
+
+func TestStatePersisterDebounce(t *testing.T) {
+	state := &models.CharacterState{CharacterID: "debounce-test", UserID: "user-123"}
+	key := stateKey(state.CharacterID, state.UserID)
+	lookup := func(string) (*models.CharacterState, bool) { return state, true }
+
+	store := newRecordingStore()
+	p := newStatePersister(store, lookup, 50*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		p.MarkDirty(key)
+	}
+	if got := store.count(key); got != 0 {
+		t.Fatalf("Expected no save before the debounce elapses, got %d", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for store.count(key) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := store.count(key); got != 1 {
+		t.Errorf("Expected a burst of changes to produce one save, got %d", got)
+	}
+}
+
+func TestStatePersisterStopFlushes(t *testing.T) {
+	state := &models.CharacterState{CharacterID: "stop-test", UserID: "user-123"}
+	key := stateKey(state.CharacterID, state.UserID)
+	lookup := func(string) (*models.CharacterState, bool) { return state, true }
+
+	store := newRecordingStore()
+	p := newStatePersister(store, lookup, time.Hour)
+
+	p.MarkDirty(key)
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if got := store.count(key); got != 1 {
+		t.Errorf("Expected Stop to flush the pending save, got %d saves", got)
+	}
+
+	// Changes after Stop are written straight away
+	p.MarkDirty(key)
+	if got := store.count(key); got != 2 {
+		t.Errorf("Expected an immediate save after Stop, got %d saves", got)
+	}
+}
+
+func newStateTestBot(store CharacterStateStore) *CharacterBot {
+	cfg := &config.Config{
+		DefaultProvider: "mock",
+		CacheConfig: config.CacheConfig{
+			DefaultTTL:      10 * time.Minute,
+			CleanupInterval: 5 * time.Minute,
+		},
+		MemoryConfig: config.MemoryConfig{
+			ShortTermWindow: 20,
+		},
+		Persistence: config.PersistenceConfig{
+			SaveDebounce: time.Hour,
+		},
+	}
+
+	bot := NewCharacterBot(cfg)
+	bot.SetStateStore(store)
+	bot.RegisterProvider("mock", &mockProvider{name: "mock"})
+	return bot
+}
+
+func TestCharacterBotPersistsStateOnStop(t *testing.T) {
+	store := newRecordingStore()
+	bot := newStateTestBot(store)
+
+	char := &models.Character{ID: "persist-test", Name: "Persist Test"}
+	if err := bot.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	state, err := bot.GetCharacterState("persist-test", "user-123")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	bot.updateCharacterState(state, &providers.AIResponse{
+		Content:  "A joyful reply",
+		Emotions: models.EmotionalState{Joy: 1},
+	})
+
+	key := stateKey("persist-test", "user-123")
+	if got := store.count(key); got != 0 {
+		t.Fatalf("Expected the save to be deferred, got %d saves", got)
+	}
+
+	bot.Stop()
+	bot.Stop() // Safe to call twice
+
+	if got := store.count(key); got != 1 {
+		t.Fatalf("Expected one save on Stop, got %d", got)
+	}
+	if store.states[key].CurrentMood.Joy == 0 {
+		t.Error("Expected the saved state to carry the updated mood")
+	}
+	if char.CurrentMood.Joy != 0 || len(char.Memories) != 0 {
+		t.Error("Expected the base character to stay unchanged")
+	}
+
+	// A new bot picks up where the last one left off
+	restarted := newStateTestBot(store)
+	if err := restarted.CreateCharacter(&models.Character{ID: "persist-test", Name: "Persist Test"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	reloaded, err := restarted.GetCharacterState("persist-test", "user-123")
+	if err != nil {
+		t.Fatalf("Failed to reload state: %v", err)
+	}
+	if reloaded.CurrentMood.Joy == 0 || len(reloaded.Memories) != 1 {
+		t.Errorf("Expected the persisted state to be reloaded, got %+v", reloaded)
+	}
+}
+
+func TestCharacterBotUpdatesStateAfterStop(t *testing.T) {
+	store := newRecordingStore()
+	bot := newStateTestBot(store)
+	if err := bot.CreateCharacter(&models.Character{ID: "late-reply", Name: "Late Reply"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	state, err := bot.GetCharacterState("late-reply", "user-123")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	bot.Stop()
+
+	// A reply finishing during shutdown is saved at once
+	done := make(chan struct{})
+	go func() {
+		bot.updateCharacterState(state, &providers.AIResponse{Content: "Goodbye"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Updating state after Stop deadlocked")
+	}
+
+	if got := store.count(stateKey("late-reply", "user-123")); got != 1 {
+		t.Errorf("Expected an immediate save after Stop, got %d saves", got)
+	}
+}
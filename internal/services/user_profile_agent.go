@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,6 +22,7 @@ type UserProfileAgent struct {
 	provider   providers.AIProvider
 	repo       *repository.UserProfileRepository
 	promptPath string
+	logs       io.Writer // Where extraction failures are reported
 }
 
 // NewUserProfileAgent creates a new user profile agent
@@ -54,6 +56,7 @@ func NewUserProfileAgent(provider providers.AIProvider, repo *repository.UserPro
 		provider:   provider,
 		repo:       repo,
 		promptPath: finalPath,
+		logs:       stderr{},
 	}
 }
 
@@ -166,7 +169,7 @@ func (upa *UserProfileAgent) UpdateUserProfile(
 	extractedJSON, err := utils.ExtractValidJSON(response.Content)
 	if err != nil {
 		// Log the error but return existing profile to maintain stability
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE: Failed to extract valid JSON from LLM response for %s/%s.\nError: %v\nRaw content (first 500 chars): %s\n", 
+		fmt.Fprintf(upa.logs, "BACKGROUND PROFILE UPDATE: Failed to extract valid JSON from LLM response for %s/%s.\nError: %v\nRaw content (first 500 chars): %s\n", 
 			userID, character.ID, err, truncateString(response.Content, 500))
 		return existingProfile, fmt.Errorf("failed to extract valid JSON for user profile update: %w", err)
 	}
@@ -174,14 +177,14 @@ func (upa *UserProfileAgent) UpdateUserProfile(
 	var updatedProfile models.UserProfile
 	if err := json.Unmarshal([]byte(extractedJSON), &updatedProfile); err != nil {
 		// This should be rare now that we're using ExtractValidJSON
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE: Failed to parse extracted JSON for %s/%s.\nError: %v\nExtracted JSON (first 500 chars): %s\n", 
+		fmt.Fprintf(upa.logs, "BACKGROUND PROFILE UPDATE: Failed to parse extracted JSON for %s/%s.\nError: %v\nExtracted JSON (first 500 chars): %s\n", 
 			userID, character.ID, err, truncateString(extractedJSON, 500))
 		return existingProfile, fmt.Errorf("failed to parse extracted JSON for user profile update: %w", err)
 	}
 
 	// Validate the response
 	if updatedProfile.UserID != userID || updatedProfile.CharacterID != character.ID {
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE: LLM returned profile for incorrect user/character. Expected %s/%s, got %s/%s\n",
+		fmt.Fprintf(upa.logs, "BACKGROUND PROFILE UPDATE: LLM returned profile for incorrect user/character. Expected %s/%s, got %s/%s\n",
 			userID, character.ID, updatedProfile.UserID, updatedProfile.CharacterID)
 		return existingProfile, fmt.Errorf("LLM returned profile for incorrect user/character")
 	}
@@ -189,7 +192,7 @@ func (upa *UserProfileAgent) UpdateUserProfile(
 	// Save the updated profile
 	if err := upa.repo.SaveUserProfile(&updatedProfile); err != nil {
 		// Log save failure but return the in-memory updated profile
-		fmt.Fprintf(os.Stderr, "BACKGROUND PROFILE UPDATE: Updated user profile for %s/%s in memory but FAILED TO SAVE: %v\n",
+		fmt.Fprintf(upa.logs, "BACKGROUND PROFILE UPDATE: Updated user profile for %s/%s in memory but FAILED TO SAVE: %v\n",
 			userID, character.ID, err)
 		return &updatedProfile, fmt.Errorf("updated user profile in memory but FAILED TO SAVE: %w", err)
 	}
@@ -0,0 +1,114 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BPE is a byte-pair encoding tokenizer over a tiktoken vocabulary
+type BPE struct {
+	name  string
+	ranks map[string]int
+	split func(text string) []string
+}
+
+// NewBPE reads a tiktoken vocabulary, one "<base64 token> <rank>" per line.
+// split pre-tokenizes text into the pieces that are merged independently.
+func NewBPE(name string, vocab io.Reader, split func(text string) []string) (*BPE, error) {
+	ranks := make(map[string]int)
+
+	scanner := bufio.NewScanner(vocab)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s vocabulary line %d: expected token and rank", name, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s vocabulary line %d: %w", name, line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s vocabulary line %d: %w", name, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s vocabulary: %w", name, err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("%s vocabulary is empty", name)
+	}
+
+	return &BPE{name: name, ranks: ranks, split: split}, nil
+}
+
+// Name implements Tokenizer
+func (b *BPE) Name() string {
+	return b.name
+}
+
+// Count implements Tokenizer
+func (b *BPE) Count(text string) int {
+	count := 0
+	for _, piece := range b.split(text) {
+		if _, ok := b.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(b.merge(piece))
+	}
+	return count
+}
+
+// Encode returns the token ranks of text. Special tokens such as
+// <|endoftext|> are encoded as ordinary text.
+func (b *BPE) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range b.split(text) {
+		if rank, ok := b.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		for _, part := range b.merge(piece) {
+			tokens = append(tokens, b.ranks[part])
+		}
+	}
+	return tokens
+}
+
+// merge splits piece into bytes and repeatedly joins the adjacent pair with
+// the lowest rank until no pair is in the vocabulary
+func (b *BPE) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts[i] = piece[i : i+1]
+	}
+
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := b.ranks[parts[i]+parts[i+1]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
@@ -0,0 +1,219 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// The tiktoken pre-tokenization patterns use lookahead, which Go's regexp
+// does not support, so they are matched by hand. Each alternative returns the
+// number of runes it matches at position i, or 0 when it does not match, and
+// alternatives are tried in pattern order like a leftmost-first regexp.
+
+// splitters holds the pre-tokenizer of each supported encoding
+var splitters = map[string]func(string) []string{
+	CL100K: splitCL100K,
+	O200K:  splitO200K,
+}
+
+type matcher func(r []rune, i int) int
+
+// split cuts text into pieces using the first alternative matching at each position
+func split(text string, alternatives []matcher) []string {
+	r := []rune(text)
+	offsets := make([]int, len(r)+1)
+	for i, c := range r {
+		offsets[i+1] = offsets[i] + utf8.RuneLen(c)
+	}
+
+	var pieces []string
+	for i := 0; i < len(r); {
+		n := 0
+		for _, alt := range alternatives {
+			if n = alt(r, i); n > 0 {
+				break
+			}
+		}
+		if n == 0 {
+			// Unreachable for valid patterns; keep the rune rather than loop
+			n = 1
+		}
+		pieces = append(pieces, text[offsets[i]:offsets[i+n]])
+		i += n
+	}
+	return pieces
+}
+
+// splitCL100K implements the cl100k_base pattern:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitCL100K(text string) []string {
+	return split(text, []matcher{
+		matchContraction,
+		matchPrefixed(func(r []rune, i int) int { return runLength(r, i, unicode.IsLetter) }),
+		matchDigits,
+		matchPunctuation(isNewline),
+		matchNewlines,
+		matchTrailingSpace,
+		matchSpace,
+	})
+}
+
+// splitO200K implements the o200k_base pattern:
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?
+//	|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?
+//	|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitO200K(text string) []string {
+	return split(text, []matcher{
+		matchPrefixed(matchLowerWord),
+		matchPrefixed(matchUpperWord),
+		matchDigits,
+		matchPunctuation(func(c rune) bool { return isNewline(c) || c == '/' }),
+		matchNewlines,
+		matchTrailingSpace,
+		matchSpace,
+	})
+}
+
+func isNewline(c rune) bool {
+	return c == '\r' || c == '\n'
+}
+
+func isLetterOrNumber(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsNumber(c)
+}
+
+// isUpperish matches [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]
+func isUpperish(c rune) bool {
+	return unicode.In(c, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// isLowerish matches [\p{Ll}\p{Lm}\p{Lo}\p{M}]
+func isLowerish(c rune) bool {
+	return unicode.In(c, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// runLength counts consecutive runes from i satisfying class
+func runLength(r []rune, i int, class func(rune) bool) int {
+	n := 0
+	for i+n < len(r) && class(r[i+n]) {
+		n++
+	}
+	return n
+}
+
+// matchContraction matches (?i:'s|'t|'re|'ve|'m|'ll|'d)
+func matchContraction(r []rune, i int) int {
+	if i >= len(r) || r[i] != '\'' {
+		return 0
+	}
+	for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+		n := 0
+		for _, c := range suffix {
+			if i+1+n >= len(r) || unicode.ToLower(r[i+1+n]) != c {
+				n = -1
+				break
+			}
+			n++
+		}
+		if n > 0 {
+			return n + 1
+		}
+	}
+	return 0
+}
+
+// matchPrefixed matches [^\r\n\p{L}\p{N}]? followed by body, preferring to
+// take the optional rune as a greedy regexp would
+func matchPrefixed(body matcher) matcher {
+	return func(r []rune, i int) int {
+		if !isNewline(r[i]) && !isLetterOrNumber(r[i]) && i+1 < len(r) {
+			if n := body(r, i+1); n > 0 {
+				return n + 1
+			}
+		}
+		return body(r, i)
+	}
+}
+
+// matchLowerWord matches [Upperish]*[Lowerish]+ plus an optional contraction,
+// backtracking the greedy upper run until a lower run can start
+func matchLowerWord(r []rune, i int) int {
+	upper := runLength(r, i, isUpperish)
+	for start := i + upper; start >= i; start-- {
+		if lower := runLength(r, start, isLowerish); lower > 0 {
+			end := start + lower
+			return end - i + matchContraction(r, end)
+		}
+	}
+	return 0
+}
+
+// matchUpperWord matches [Upperish]+[Lowerish]* plus an optional contraction
+func matchUpperWord(r []rune, i int) int {
+	upper := runLength(r, i, isUpperish)
+	if upper == 0 {
+		return 0
+	}
+	end := i + upper
+	end += runLength(r, end, isLowerish)
+	return end - i + matchContraction(r, end)
+}
+
+// matchDigits matches \p{N}{1,3}
+func matchDigits(r []rune, i int) int {
+	n := runLength(r, i, unicode.IsNumber)
+	if n > 3 {
+		n = 3
+	}
+	return n
+}
+
+// matchPunctuation matches ` ?[^\s\p{L}\p{N}]+` followed by trailing runes
+func matchPunctuation(trailing func(rune) bool) matcher {
+	isSymbol := func(c rune) bool { return !unicode.IsSpace(c) && !isLetterOrNumber(c) }
+	return func(r []rune, i int) int {
+		start := i
+		if r[i] == ' ' && i+1 < len(r) && isSymbol(r[i+1]) {
+			start++
+		}
+		n := runLength(r, start, isSymbol)
+		if n == 0 {
+			return 0
+		}
+		end := start + n
+		end += runLength(r, end, trailing)
+		return end - i
+	}
+}
+
+// matchNewlines matches \s*[\r\n]+, which ends at the last newline of the
+// whitespace run
+func matchNewlines(r []rune, i int) int {
+	end := i + runLength(r, i, unicode.IsSpace)
+	for j := end - 1; j >= i; j-- {
+		if isNewline(r[j]) {
+			return j + 1 - i
+		}
+	}
+	return 0
+}
+
+// matchTrailingSpace matches \s+(?!\S): whitespace at the end of the text,
+// or all but the last whitespace rune before a word
+func matchTrailingSpace(r []rune, i int) int {
+	n := runLength(r, i, unicode.IsSpace)
+	if i+n == len(r) {
+		return n
+	}
+	if n > 1 {
+		return n - 1
+	}
+	return 0
+}
+
+// matchSpace matches \s+
+func matchSpace(r []rune, i int) int {
+	return runLength(r, i, unicode.IsSpace)
+}
@@ -0,0 +1,115 @@
+// Package tokenizer counts tokens the way model providers bill them.
+//
+// Byte-pair encodings are loaded from tiktoken vocabulary files on local disk
+// (cl100k_base.tiktoken, o200k_base.tiktoken). When a file is missing, counts
+// fall back to a characters-per-token heuristic.
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tokenizer counts the tokens in a text
+type Tokenizer interface {
+	Count(text string) int
+	Name() string
+}
+
+// Encoding names understood by ForModel
+const (
+	CL100K = "cl100k_base"
+	O200K  = "o200k_base"
+)
+
+// o200kModels are model name prefixes that use the o200k_base encoding
+var o200kModels = []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4", "chatgpt-4o"}
+
+// EncodingForModel returns the encoding used to count tokens for model.
+// Models outside the OpenAI families are approximated with cl100k_base.
+func EncodingForModel(model string) string {
+	model = strings.ToLower(model)
+	// Strip routing prefixes such as "openai/"
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, prefix := range o200kModels {
+		if strings.HasPrefix(model, prefix) {
+			return O200K
+		}
+	}
+	return CL100K
+}
+
+// Heuristic estimates about four bytes per token
+type Heuristic struct{}
+
+// Count implements Tokenizer
+func (Heuristic) Count(text string) int {
+	return len(text) / 4
+}
+
+// Name implements Tokenizer
+func (Heuristic) Name() string {
+	return "heuristic"
+}
+
+var (
+	loadedMu sync.Mutex
+	loaded   = make(map[string]*BPE)
+)
+
+// ForModel returns the BPE tokenizer for model's encoding, loaded from
+// <dir>/<encoding>.tiktoken, or the heuristic when the file is not available.
+// Encodings are loaded once per process.
+func ForModel(model, dir string) Tokenizer {
+	tok, err := LoadEncoding(EncodingForModel(model), dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: Using estimated token counts: %v\n", err)
+		}
+		return Heuristic{}
+	}
+	return tok
+}
+
+// LoadEncoding loads the named encoding from <dir>/<name>.tiktoken, reusing
+// an encoding already loaded from the same file. A missing file is reported
+// with an error satisfying os.IsNotExist.
+func LoadEncoding(name, dir string) (*BPE, error) {
+	if dir == "" {
+		return nil, os.ErrNotExist
+	}
+	path := filepath.Join(dir, name+".tiktoken")
+
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+
+	if bpe, ok := loaded[path]; ok {
+		return bpe, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to open %s vocabulary: %w", name, err)
+	}
+	defer f.Close()
+
+	split, ok := splitters[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+	bpe, err := NewBPE(name, f, split)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded[path] = bpe
+	return bpe, nil
+}
@@ -0,0 +1,145 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeVocab writes a tiktoken vocabulary of every single byte followed by merges
+func writeVocab(t *testing.T, dir, name string, merges ...string) {
+	t.Helper()
+
+	var sb strings.Builder
+	rank := 0
+	for b := 0; b < 256; b++ {
+		sb.WriteString(fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), rank))
+		rank++
+	}
+	for _, m := range merges {
+		sb.WriteString(fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), rank))
+		rank++
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".tiktoken"), []byte(sb.String()), 0644); err != nil {
+		t.Fatalf("Failed to write vocabulary: %v", err)
+	}
+}
+
+func TestSplitCL100K(t *testing.T) {
+	tests := map[string][]string{
+		"Hello world":  {"Hello", " world"},
+		"I'm fine":     {"I", "'m", " fine"},
+		"12345":        {"123", "45"},
+		"hello  world": {"hello", " ", " world"},
+		"a\n\nb":       {"a", "\n\n", "b"},
+		"foo!!\n":      {"foo", "!!\n"},
+		"  \n x":       {"  \n", " x"},
+		"end  ":        {"end", "  "},
+		"naïve café":   {"naïve", " café"},
+		"日本語のテキスト":     {"日本語のテキスト"},
+	}
+
+	for text, want := range tests {
+		if got := splitCL100K(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCL100K(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	tests := map[string][]string{
+		"HelloWorld": {"Hello", "World"},
+		"don't stop": {"don't", " stop"},
+		"HTTPServer": {"HTTPServer"},
+		"path/to\n":  {"path", "/to", "\n"},
+		"a./\nb":     {"a", "./\n", "b"},
+		"1234 items": {"123", "4", " items"},
+		"WHY'S IT":   {"WHY'S", " IT"},
+	}
+
+	for text, want := range tests {
+		if got := splitO200K(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitO200K(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestBPECount(t *testing.T) {
+	dir := t.TempDir()
+	writeVocab(t, dir, CL100K, "he", "ll", "hell", "hello", " w", "or", " wor", " world")
+
+	bpe, err := LoadEncoding(CL100K, dir)
+	if err != nil {
+		t.Fatalf("Failed to load encoding: %v", err)
+	}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello world", 2}, // both pieces are single tokens
+		{"hellos", 2},      // "hello" + "s"
+		{"shell", 2},       // "s" + "hell"
+		{"", 0},            // nothing to count
+		{"é", 2},           // two UTF-8 bytes without a merge
+		{"hello hello", 3}, // "hello" + " " + "hello" ("h" has no space merge)
+	}
+	for _, tt := range tests {
+		if got := bpe.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	if got := bpe.Encode("hello world"); !reflect.DeepEqual(got, []int{259, 263}) {
+		t.Errorf("Encode returned %v", got)
+	}
+
+	// The same file is loaded once per process
+	again, _ := LoadEncoding(CL100K, dir)
+	if again != bpe {
+		t.Error("Expected the loaded encoding to be reused")
+	}
+}
+
+func TestForModel(t *testing.T) {
+	dir := t.TempDir()
+	writeVocab(t, dir, O200K, "hi")
+
+	if tok := ForModel("gpt-4o-mini", dir); tok.Name() != O200K || tok.Count("hi") != 1 {
+		t.Errorf("Expected o200k for gpt-4o-mini, got %s", tok.Name())
+	}
+
+	// cl100k_base is not on disk, so counts fall back to the heuristic
+	tok := ForModel("gpt-3.5-turbo", dir)
+	if _, ok := tok.(Heuristic); !ok {
+		t.Errorf("Expected heuristic fallback, got %s", tok.Name())
+	}
+	if tok.Count("12345678") != 2 {
+		t.Error("Expected four bytes per token from the heuristic")
+	}
+
+	if _, ok := ForModel("gpt-4o", "").(Heuristic); !ok {
+		t.Error("Expected heuristic without a vocabulary directory")
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o":                  O200K,
+		"openai/gpt-4.1-mini":     O200K,
+		"o3-mini":                 O200K,
+		"gpt-4-turbo":             CL100K,
+		"gpt-3.5-turbo":           CL100K,
+		"claude-3-haiku-20240307": CL100K,
+		"llama3":                  CL100K,
+	}
+	for model, want := range tests {
+		if got := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
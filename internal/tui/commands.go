@@ -195,6 +195,7 @@ func (m *Model) showMood() slashCommandResult {
 
 	mood := m.getDominantMood()
 	icon := m.getMoodIcon(mood)
+	current := m.currentMood()
 	content := fmt.Sprintf(`%s Current Mood: %s
 
 Emotional State:
@@ -202,12 +203,12 @@ Emotional State:
 • Anger: %.1f    • Fear: %.1f  
 • Sadness: %.1f  • Disgust: %.1f`,
 		icon, mood,
-		m.character.CurrentMood.Joy,
-		m.character.CurrentMood.Surprise,
-		m.character.CurrentMood.Anger,
-		m.character.CurrentMood.Fear,
-		m.character.CurrentMood.Sadness,
-		m.character.CurrentMood.Disgust)
+		current.Joy,
+		current.Surprise,
+		current.Anger,
+		current.Fear,
+		current.Sadness,
+		current.Disgust)
 
 	return slashCommandResult{
 		cmdType: "mood",
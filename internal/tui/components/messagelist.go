@@ -22,11 +22,12 @@ type Message struct {
 
 // MessageList displays the chat history
 type MessageList struct {
-	messages []Message
-	viewport viewport.Model
-	width    int
-	height   int
-	styles   messageListStyles
+	messages  []Message
+	streaming bool // last message is still receiving chunks
+	viewport  viewport.Model
+	width     int
+	height    int
+	styles    messageListStyles
 }
 
 type messageListStyles struct {
@@ -131,6 +132,24 @@ func (m *MessageList) Update(msg tea.Msg) tea.Cmd {
 		})
 		m.viewport.SetContent(m.renderMessages())
 		m.viewport.GotoBottom()
+
+	case MessageStreamMsg:
+		if m.streaming && len(m.messages) > 0 {
+			m.messages[len(m.messages)-1].Content += msg.Content
+		} else {
+			m.messages = append(m.messages, Message{
+				Role:    msg.Role,
+				Content: msg.Content,
+				Time:    time.Now(),
+				MsgType: "normal",
+			})
+			m.streaming = true
+		}
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+
+	case MessageStreamEndMsg:
+		m.streaming = false
 	}
 
 	m.viewport, cmd = m.viewport.Update(msg)
@@ -320,5 +339,6 @@ func (m *MessageList) formatSpecialMessage(content string, msgType string, width
 // ClearMessages clears all messages
 func (m *MessageList) ClearMessages() {
 	m.messages = []Message{}
+	m.streaming = false
 	m.viewport.SetContent("")
 }
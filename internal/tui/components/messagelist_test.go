@@ -0,0 +1,24 @@
+package components
+
+import "testing"
+
+func TestMessageListStreaming(t *testing.T) {
+	ml := NewMessageList(80, 20)
+
+	ml.Update(MessageStreamMsg{Role: "Alice", Content: "Hel"})
+	ml.Update(MessageStreamMsg{Role: "Alice", Content: "lo"})
+
+	if len(ml.messages) != 1 {
+		t.Fatalf("Expected chunks to share one message, got %d", len(ml.messages))
+	}
+	if ml.messages[0].Content != "Hello" {
+		t.Errorf("Expected 'Hello', got %q", ml.messages[0].Content)
+	}
+
+	ml.Update(MessageStreamEndMsg{})
+	ml.Update(MessageStreamMsg{Role: "Alice", Content: "Again"})
+
+	if len(ml.messages) != 2 {
+		t.Errorf("Expected a new message after stream end, got %d", len(ml.messages))
+	}
+}
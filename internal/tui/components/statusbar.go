@@ -17,6 +17,7 @@ type StatusBar struct {
 	sessionID   string
 	model       string
 	lastError   error
+	retrying    string // e.g. "retrying (2/4)…", empty when idle
 	styles      statusBarStyles
 }
 
@@ -67,6 +68,13 @@ func (s *StatusBar) Update(msg tea.Msg) tea.Cmd {
 			s.model = msg.Model
 		}
 		s.lastError = msg.Error
+		s.retrying = ""
+
+	case RetryStatusMsg:
+		s.retrying = ""
+		if msg.Attempt > 0 {
+			s.retrying = fmt.Sprintf("retrying (%d/%d)…", msg.Attempt, msg.MaxAttempts)
+		}
 	}
 
 	return nil
@@ -105,8 +113,11 @@ func (s *StatusBar) View() string {
 
 	status := lipgloss.JoinHorizontal(lipgloss.Left, parts[0], " │ ", parts[1], " │", parts[2], " │ ", parts[3], " │", parts[4])
 
-	// Add error if present
-	if s.lastError != nil {
+	// A retry in progress replaces the raw error
+	if s.retrying != "" {
+		retryText := s.styles.error.Render(" │ " + s.retrying)
+		status = lipgloss.JoinHorizontal(lipgloss.Left, status, retryText)
+	} else if s.lastError != nil {
 		errorText := s.styles.error.Render(fmt.Sprintf(" │ Error: %v", s.lastError))
 		status = lipgloss.JoinHorizontal(lipgloss.Left, status, errorText)
 	}
@@ -40,6 +40,16 @@ func TestStatusBar(t *testing.T) {
 			},
 			contains: []string{"Error: API rate limit"},
 		},
+		{
+			name: "Shows retry progress instead of the error",
+			updates: []tea.Msg{
+				StatusUpdateMsg{
+					Error: errors.New("API rate limit exceeded"),
+				},
+				RetryStatusMsg{Attempt: 2, MaxAttempts: 4},
+			},
+			contains: []string{"retrying (2/4)…"},
+		},
 		{
 			name: "Updates session ID",
 			updates: []tea.Msg{
@@ -57,6 +57,21 @@ type (
 		MsgType string // "normal", "help", "list", "stats", etc.
 	}
 
+	// MessageStreamMsg appends a streamed chunk to the in-progress reply
+	MessageStreamMsg struct {
+		Role    string
+		Content string
+	}
+
+	// MessageStreamEndMsg marks the in-progress reply as complete
+	MessageStreamEndMsg struct{}
+
+	// RetryStatusMsg shows a provider retry in progress; Attempt 0 clears it
+	RetryStatusMsg struct {
+		Attempt     int
+		MaxAttempts int
+	}
+
 	// ProcessingStateMsg updates the processing state
 	ProcessingStateMsg struct {
 		IsProcessing bool
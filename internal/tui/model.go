@@ -11,6 +11,7 @@ import (
 
 	"github.com/dotcommander/roleplay/internal/cache"
 	"github.com/dotcommander/roleplay/internal/models"
+	"github.com/dotcommander/roleplay/internal/providers"
 	"github.com/dotcommander/roleplay/internal/services"
 	"github.com/dotcommander/roleplay/internal/tui/components"
 )
@@ -43,6 +44,9 @@ type Model struct {
 	historyIndex   int
 	historyBuffer  string
 
+	// Streaming state
+	streaming bool
+
 	// Cache metrics
 	lastCacheHit    bool
 	lastTokensSaved int
@@ -217,18 +221,45 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.character = msg.character
 		m.updateCharacterDisplay()
 
+	case retryMsg:
+		m.statusBar.Update(components.RetryStatusMsg{
+			Attempt:     msg.event.Attempt,
+			MaxAttempts: msg.event.MaxAttempts,
+		})
+		return m, waitForStream(msg.stream)
+
+	case streamChunkMsg:
+		if !m.streaming {
+			m.statusBar.Update(components.RetryStatusMsg{})
+		}
+		m.streaming = true
+		m.messageList.Update(components.MessageStreamMsg{
+			Role:    m.character.Name,
+			Content: msg.content,
+		})
+		return m, waitForStream(msg.stream)
+
 	case responseMsg:
 		m.inputArea.Update(components.ProcessingStateMsg{IsProcessing: false})
+		m.statusBar.Update(components.RetryStatusMsg{})
+
+		streamed := m.streaming
+		if streamed {
+			m.streaming = false
+			m.messageList.Update(components.MessageStreamEndMsg{})
+		}
 
 		if msg.err != nil {
 			m.statusBar.Update(components.StatusUpdateMsg{Error: msg.err})
 		} else {
-			// Add character response
-			m.messageList.Update(components.MessageAppendMsg{
-				Role:    m.character.Name,
-				Content: msg.content,
-				MsgType: "normal",
-			})
+			// Add character response unless it was already streamed in
+			if !streamed {
+				m.messageList.Update(components.MessageAppendMsg{
+					Role:    m.character.Name,
+					Content: msg.content,
+					MsgType: "normal",
+				})
+			}
 
 			// Update cache metrics
 			if msg.metrics != nil {
@@ -461,18 +492,32 @@ func (m *Model) updateContext() {
 	// This is simplified for the example
 }
 
+// currentMood returns the character's mood toward this user, falling back to
+// the base definition when no state is available
+func (m *Model) currentMood() models.EmotionalState {
+	if m.bot != nil {
+		if state, err := m.bot.GetCharacterState(m.characterID, m.userID); err == nil {
+			state.RLock()
+			defer state.RUnlock()
+			return state.CurrentMood
+		}
+	}
+	return m.character.CurrentMood
+}
+
 func (m *Model) getDominantMood() string {
 	if m.character == nil {
 		return "Unknown"
 	}
 
+	mood := m.currentMood()
 	moods := map[string]float64{
-		"Joy":      m.character.CurrentMood.Joy,
-		"Surprise": m.character.CurrentMood.Surprise,
-		"Anger":    m.character.CurrentMood.Anger,
-		"Fear":     m.character.CurrentMood.Fear,
-		"Sadness":  m.character.CurrentMood.Sadness,
-		"Disgust":  m.character.CurrentMood.Disgust,
+		"Joy":      mood.Joy,
+		"Surprise": mood.Surprise,
+		"Anger":    mood.Anger,
+		"Fear":     mood.Fear,
+		"Sadness":  mood.Sadness,
+		"Disgust":  mood.Disgust,
 	}
 
 	maxMood := "Neutral"
@@ -525,6 +570,23 @@ type responseMsg struct {
 	err     error
 }
 
+// replyStream carries a reply being streamed from the bot
+type replyStream struct {
+	chunks  chan providers.PartialAIResponse
+	retries chan providers.RetryEvent
+	result  chan responseMsg
+}
+
+type streamChunkMsg struct {
+	content string
+	stream  *replyStream
+}
+
+type retryMsg struct {
+	event  providers.RetryEvent
+	stream *replyStream
+}
+
 type slashCommandResult struct {
 	cmdType        string // "help", "list", "stats", etc.
 	content        string
@@ -547,19 +609,32 @@ func (m *Model) loadCharacterInfo() tea.Cmd {
 }
 
 func (m *Model) sendMessage(message string) tea.Cmd {
-	return func() tea.Msg {
-		req := &models.ConversationRequest{
-			CharacterID: m.characterID,
-			UserID:      m.userID,
-			Message:     message,
-			ScenarioID:  m.scenarioID,
-			Context:     m.context,
-		}
+	req := &models.ConversationRequest{
+		CharacterID: m.characterID,
+		UserID:      m.userID,
+		Message:     message,
+		ScenarioID:  m.scenarioID,
+		Context:     m.context,
+	}
 
-		ctx := context.Background()
-		resp, err := m.bot.ProcessRequest(ctx, req)
+	stream := &replyStream{
+		chunks:  make(chan providers.PartialAIResponse),
+		retries: make(chan providers.RetryEvent, 4),
+		result:  make(chan responseMsg, 1),
+	}
+
+	go func() {
+		ctx := providers.WithRetryNotifier(context.Background(), func(ev providers.RetryEvent) {
+			// Never block the provider on a slow UI
+			select {
+			case stream.retries <- ev:
+			default:
+			}
+		})
+		resp, err := m.bot.ProcessStreamRequest(ctx, req, stream.chunks)
 		if err != nil {
-			return responseMsg{err: err}
+			stream.result <- responseMsg{err: err}
+			return
 		}
 
 		// Get updated character state
@@ -568,10 +643,31 @@ func (m *Model) sendMessage(message string) tea.Cmd {
 			m.character = char
 		}
 
-		return responseMsg{
+		stream.result <- responseMsg{
 			content: resp.Content,
 			metrics: &resp.CacheMetrics,
 		}
+	}()
+
+	return waitForStream(stream)
+}
+
+// waitForStream delivers the next chunk, or the final response once the stream closes
+func waitForStream(stream *replyStream) tea.Cmd {
+	return func() tea.Msg {
+		for {
+			select {
+			case ev := <-stream.retries:
+				return retryMsg{event: ev, stream: stream}
+			case chunk, ok := <-stream.chunks:
+				if !ok {
+					return <-stream.result
+				}
+				if chunk.Content != "" {
+					return streamChunkMsg{content: chunk.Content, stream: stream}
+				}
+			}
+		}
 	}
 }
 
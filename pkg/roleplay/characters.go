@@ -0,0 +1,110 @@
+package roleplay
+
+import (
+	"fmt"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+// CreateCharacter stores a new character. It needs an ID and a name; an ID
+// already in use fails with ErrExists.
+func (c *Client) CreateCharacter(char *Character) error {
+	internal, err := toInternalCharacter(char)
+	if err != nil {
+		return err
+	}
+	if _, err := c.mgr.GetOrLoadCharacter(char.ID); err == nil {
+		return fmt.Errorf("character %s: %w", char.ID, ErrExists)
+	}
+
+	if err := c.mgr.CreateCharacter(internal); err != nil {
+		return fmt.Errorf("failed to create character: %w", err)
+	}
+	char.LastModified = internal.LastModified
+	return nil
+}
+
+// Character returns a stored character
+func (c *Client) Character(id string) (*Character, error) {
+	if err := checkID("character", id); err != nil {
+		return nil, err
+	}
+	internal, err := c.mgr.GetOrLoadCharacter(id)
+	if err != nil {
+		return nil, fmt.Errorf("character %s: %w", id, ErrNotFound)
+	}
+
+	var char Character
+	internal.RLock()
+	defer internal.RUnlock()
+	if err := convert(internal, &char); err != nil {
+		return nil, err
+	}
+	return &char, nil
+}
+
+// Characters lists the stored characters
+func (c *Client) Characters() ([]CharacterInfo, error) {
+	infos, err := c.mgr.ListAvailableCharacters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list characters: %w", err)
+	}
+	var characters []CharacterInfo
+	if err := convert(infos, &characters); err != nil {
+		return nil, err
+	}
+	return characters, nil
+}
+
+// UpdateCharacter replaces a stored character's definition. What each user's
+// conversations have changed, such as mood and memories, is kept.
+func (c *Client) UpdateCharacter(char *Character) error {
+	internal, err := toInternalCharacter(char)
+	if err != nil {
+		return err
+	}
+	if _, err := c.mgr.GetOrLoadCharacter(char.ID); err != nil {
+		return fmt.Errorf("character %s: %w", char.ID, ErrNotFound)
+	}
+
+	if err := c.mgr.UpdateCharacter(internal); err != nil {
+		return fmt.Errorf("failed to update character: %w", err)
+	}
+	char.LastModified = internal.LastModified
+	return nil
+}
+
+// DeleteCharacter removes a stored character
+func (c *Client) DeleteCharacter(id string) error {
+	if err := checkID("character", id); err != nil {
+		return err
+	}
+	if _, err := c.mgr.GetOrLoadCharacter(id); err != nil {
+		return fmt.Errorf("character %s: %w", id, ErrNotFound)
+	}
+	if err := c.mgr.DeleteCharacter(id); err != nil {
+		return fmt.Errorf("failed to delete character: %w", err)
+	}
+	return nil
+}
+
+// toInternalCharacter checks char and converts it for the manager
+func toInternalCharacter(char *Character) (*models.Character, error) {
+	if err := checkID("character", char.ID); err != nil {
+		return nil, err
+	}
+	if char.Name == "" {
+		return nil, fmt.Errorf("roleplay: character %s needs a name", char.ID)
+	}
+
+	var internal models.Character
+	if err := convert(char, &internal); err != nil {
+		return nil, err
+	}
+	if internal.Generation != nil {
+		if err := internal.Generation.Validate(); err != nil {
+			return nil, fmt.Errorf("roleplay: invalid generation settings: %w", err)
+		}
+	}
+	return &internal, nil
+}
@@ -0,0 +1,105 @@
+package roleplay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// ChatRequest is a message from a user to a character
+type ChatRequest struct {
+	CharacterID string
+	UserID      string // Who is talking; the character keeps separate state for each user
+	SessionID   string // Conversation to continue; a new one is started when empty
+	Message     string
+
+	// OnToken, when set, receives the reply piece by piece as it is
+	// generated. Providers passed to WithProvider deliver it in one piece.
+	// The reply's Usage is then estimated, as streaming providers don't
+	// report it reliably.
+	OnToken func(token string)
+}
+
+// Reply is a character's answer
+type Reply struct {
+	Content   string
+	SessionID string         // Pass back in ChatRequest to continue the conversation
+	Emotions  EmotionalState // Detected in the reply; the character's mood shifts toward them
+	Cached    bool           // Served from the response or prompt cache
+	Usage     Usage
+}
+
+// Chat sends a message to a character and returns its reply. The exchange is
+// recorded in the session, and the character's mood, memories and
+// relationship with the user evolve from it.
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*Reply, error) {
+	if err := checkID("character", req.CharacterID); err != nil {
+		return nil, err
+	}
+	if err := checkID("user", req.UserID); err != nil {
+		return nil, err
+	}
+	if req.SessionID != "" {
+		if err := checkID("session", req.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	if req.Message == "" {
+		return nil, fmt.Errorf("roleplay: message is empty")
+	}
+	if !c.hasProvider {
+		return nil, ErrNoProvider
+	}
+	if _, err := c.mgr.GetOrLoadCharacter(req.CharacterID); err != nil {
+		return nil, fmt.Errorf("character %s: %w", req.CharacterID, ErrNotFound)
+	}
+
+	chatReq := manager.ChatRequest{
+		CharacterID: req.CharacterID,
+		UserID:      req.UserID,
+		SessionID:   req.SessionID,
+		Message:     req.Message,
+	}
+	var streamed chan struct{}
+	if req.OnToken != nil {
+		stream := make(chan providers.PartialAIResponse)
+		chatReq.Stream = stream
+		streamed = make(chan struct{})
+		go func() {
+			defer close(streamed)
+			for chunk := range stream {
+				if chunk.Content != "" {
+					req.OnToken(chunk.Content)
+				}
+			}
+		}()
+	}
+
+	result, err := c.mgr.Chat(ctx, chatReq)
+	if streamed != nil {
+		<-streamed
+	}
+	if errors.Is(err, manager.ErrSessionOwner) {
+		return nil, fmt.Errorf("session %s: %w", req.SessionID, ErrSessionOwner)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := result.Response
+	reply := &Reply{
+		Content:   resp.Content,
+		SessionID: result.Session.ID,
+		Cached:    resp.CacheMetrics.Hit,
+	}
+	if err := convert(resp.Emotions, &reply.Emotions); err != nil {
+		return nil, err
+	}
+	if err := convert(manager.ReplyUsage(resp), &reply.Usage); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
@@ -0,0 +1,218 @@
+// Package roleplay embeds roleplay characters in Go programs: create
+// characters, hold conversations with them and read how they have evolved,
+// without shelling out to the CLI.
+//
+// A Client keeps characters, sessions and per-user character state under a
+// data directory laid out the way the roleplay CLI lays out
+// ~/.config/roleplay. Replies come from a built-in provider chosen with
+// WithProviderConfig, or from any Provider passed to WithProvider.
+//
+//	client, err := roleplay.New(
+//		roleplay.WithDataDir("/var/lib/myapp/roleplay"),
+//		roleplay.WithProviderConfig(roleplay.ProviderConfig{Profile: "openai", APIKey: key}),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	reply, err := client.Chat(ctx, roleplay.ChatRequest{
+//		CharacterID: "sophia",
+//		UserID:      "alice",
+//		Message:     "What is virtue?",
+//	})
+//
+// Unlike the internal packages it wraps, this package's API is kept stable
+// across releases.
+package roleplay
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/config"
+	"github.com/dotcommander/roleplay/internal/factory"
+	"github.com/dotcommander/roleplay/internal/manager"
+	"github.com/dotcommander/roleplay/internal/pricing"
+	"github.com/dotcommander/roleplay/internal/repository"
+)
+
+var (
+	// ErrNotFound is returned for characters, sessions and profiles that don't exist
+	ErrNotFound = errors.New("roleplay: not found")
+	// ErrExists is returned when creating a character whose ID is taken
+	ErrExists = errors.New("roleplay: already exists")
+	// ErrNoProvider is returned by Chat when the client was given no provider
+	ErrNoProvider = errors.New("roleplay: no provider configured")
+	// ErrSessionOwner is returned by Chat when the session belongs to another user
+	ErrSessionOwner = errors.New("roleplay: session belongs to another user")
+)
+
+// checkID rejects an ID that can't name a file
+func checkID(kind, id string) error {
+	if repository.ValidateID(id) != nil {
+		return fmt.Errorf("roleplay: invalid %s ID %q", kind, id)
+	}
+	return nil
+}
+
+// Option configures a Client
+type Option func(*options)
+
+type options struct {
+	dataDir        string
+	provider       Provider
+	providerConfig *ProviderConfig
+	now            func() time.Time
+	logger         *slog.Logger
+}
+
+// WithDataDir keeps characters, sessions and state under dir instead of
+// ~/.config/roleplay. Clients sharing a directory, in one process or several,
+// see each other's characters and conversations.
+func WithDataDir(dir string) Option {
+	return func(o *options) { o.dataDir = dir }
+}
+
+// WithProvider generates replies with p
+func WithProvider(p Provider) Option {
+	return func(o *options) {
+		o.provider = p
+		o.providerConfig = nil
+	}
+}
+
+// WithProviderConfig generates replies with one of the built-in providers
+func WithProviderConfig(pc ProviderConfig) Option {
+	return func(o *options) {
+		o.providerConfig = &pc
+		o.provider = nil
+	}
+}
+
+// WithClock reads the time from now when timestamping messages, memories
+// and state, e.g. a fixed clock in tests
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+// WithLogger reports warnings, such as state that failed to save, to logger
+// instead of stderr
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// Client creates characters and holds conversations with them. It is safe
+// for concurrent use; turns in the same session take place one at a time.
+type Client struct {
+	mgr         *manager.CharacterManager
+	hasProvider bool
+}
+
+// New creates a client. Without WithProvider or WithProviderConfig the
+// client can manage characters and read state, but Chat fails.
+func New(opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg := defaultConfig(o.dataDir)
+	switch {
+	case o.provider != nil:
+		cfg.DefaultProvider = o.provider.Name()
+		// Its replies cost what it reports, so it needs no price table entry
+		cfg.Pricing = []pricing.Entry{{Provider: cfg.DefaultProvider}}
+	case o.providerConfig != nil:
+		cfg.DefaultProvider = o.providerConfig.Profile
+		cfg.APIKey = o.providerConfig.APIKey
+		cfg.Model = o.providerConfig.Model
+		cfg.BaseURL = o.providerConfig.BaseURL
+	}
+
+	mgr, err := manager.NewCharacterManagerWithoutProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	bot := mgr.GetBot()
+	if o.now != nil {
+		bot.SetClock(o.now)
+	}
+	if o.logger != nil {
+		bot.SetLogOutput(logWriter{o.logger})
+	}
+
+	c := &Client{mgr: mgr}
+	switch {
+	case o.provider != nil:
+		mgr.UseProvider(providerAdapter{o.provider})
+		c.hasProvider = true
+	case o.providerConfig != nil:
+		provider, err := factory.CreateProvider(cfg)
+		if err != nil {
+			bot.Stop()
+			return nil, fmt.Errorf("failed to initialize provider: %w", err)
+		}
+		mgr.UseProvider(provider)
+		c.hasProvider = true
+	}
+	return c, nil
+}
+
+// Close waits for background work such as memory consolidation and saves
+// pending character state. The client must not be used afterwards.
+func (c *Client) Close() error {
+	c.mgr.GetBot().Stop()
+	return nil
+}
+
+// defaultConfig returns the settings the CLI uses when config.yaml leaves
+// them unset, storing data under dataDir
+func defaultConfig(dataDir string) *config.Config {
+	cfg := &config.Config{
+		DataDir: dataDir,
+		CacheConfig: config.CacheConfig{
+			MaxEntries:                   10000,
+			MaxBytes:                     64 << 20,
+			CleanupInterval:              5 * time.Minute,
+			DefaultTTL:                   5 * time.Minute,
+			CoreCharacterSystemPromptTTL: 7 * 24 * time.Hour,
+		},
+		MemoryConfig: config.MemoryConfig{
+			ShortTermWindow:    20,
+			MediumTermDuration: 24 * time.Hour,
+			ConsolidationRate:  0.1,
+			RetrievalTopK:      5,
+		},
+		PersonalityConfig: config.PersonalityConfig{
+			EvolutionEnabled:   true,
+			MaxDriftRate:       0.02,
+			StabilityThreshold: 10,
+		},
+		CircuitBreaker: config.CircuitBreakerConfig{
+			FailureThreshold: 3,
+			Cooldown:         30 * time.Second,
+		},
+		Retry:       config.RetryConfig{MaxAttempts: 4},
+		Emotion:     config.EmotionConfig{Analyzer: "lexicon"},
+		Persistence: config.PersistenceConfig{SaveDebounce: 2 * time.Second},
+		Budgets:     config.BudgetConfig{WarnAt: 0.8},
+	}
+	cfg.Tokenizer.Dir = filepath.Join(cfg.StorageDir(), "tokenizers")
+	return cfg
+}
+
+// logWriter turns the warnings the bot writes into log records
+type logWriter struct {
+	logger *slog.Logger
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	w.logger.Warn(strings.TrimPrefix(msg, "Warning: "))
+	return len(p), nil
+}
@@ -0,0 +1,318 @@
+package roleplay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/roleplay/internal/models"
+)
+
+// recordingProvider replies with a fixed text and keeps every request
+type recordingProvider struct {
+	mu       sync.Mutex
+	reply    string
+	requests []CompletionRequest
+}
+
+func (p *recordingProvider) Name() string { return "recorder" }
+
+func (p *recordingProvider) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	return &Completion{Content: p.reply, Model: "recorder-1", PromptTokens: 100, CompletionTokens: 20, Cost: 0.002}, nil
+}
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	client, err := New(append([]Option{WithDataDir(t.TempDir())}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// fill sets every field of v to a non-zero value
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(reflect.ValueOf("key"), reflect.ValueOf("value"))
+	case reflect.String:
+		v.SetString("short_term")
+	case reflect.Int:
+		v.SetInt(7)
+	case reflect.Float64:
+		v.SetFloat(0.5)
+	}
+}
+
+// TestTypesMirrorInternal guards against internal fields the public types
+// would silently drop, e.g. when updating a character
+func TestTypesMirrorInternal(t *testing.T) {
+	tests := []struct {
+		internal interface{}
+		public   interface{}
+		back     interface{}
+	}{
+		{&models.Character{}, &Character{}, &models.Character{}},
+		{&models.CharacterState{}, &CharacterState{}, &models.CharacterState{}},
+		{&models.UserProfile{}, &UserProfile{}, &models.UserProfile{}},
+	}
+
+	for _, tt := range tests {
+		fill(reflect.ValueOf(tt.internal).Elem())
+		if err := convert(tt.internal, tt.public); err != nil {
+			t.Fatalf("Failed to convert %T: %v", tt.internal, err)
+		}
+		if err := convert(tt.public, tt.back); err != nil {
+			t.Fatalf("Failed to convert %T: %v", tt.public, err)
+		}
+
+		original, back := reflect.ValueOf(tt.internal).Elem(), reflect.ValueOf(tt.back).Elem()
+		for i := 0; i < original.NumField(); i++ {
+			field := original.Type().Field(i)
+			if field.IsExported() && !reflect.DeepEqual(original.Field(i).Interface(), back.Field(i).Interface()) {
+				t.Errorf("%T.%s is lost by %T", tt.internal, field.Name, tt.public)
+			}
+		}
+	}
+}
+
+func TestCharacters(t *testing.T) {
+	dir := t.TempDir()
+	client, err := New(WithDataDir(dir))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	char := &Character{ID: "sophia", Name: "Sophia", Backstory: "A Stoic", Quirks: []string{"quotes Seneca"}}
+	if err := client.CreateCharacter(char); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	if char.LastModified.IsZero() {
+		t.Error("Expected LastModified to be set")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "characters", "sophia.json")); err != nil {
+		t.Errorf("Expected the character to be stored in the data directory: %v", err)
+	}
+	if err := client.CreateCharacter(&Character{ID: "sophia", Name: "Again"}); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+
+	char.Backstory = "A Stoic who has seen much"
+	if err := client.UpdateCharacter(char); err != nil {
+		t.Fatalf("Failed to update character: %v", err)
+	}
+	got, err := client.Character("sophia")
+	if err != nil {
+		t.Fatalf("Failed to get character: %v", err)
+	}
+	if got.Backstory != "A Stoic who has seen much" || len(got.Quirks) != 1 {
+		t.Errorf("Unexpected character: %+v", got)
+	}
+
+	infos, err := client.Characters()
+	if err != nil || len(infos) != 1 || infos[0].Name != "Sophia" {
+		t.Errorf("Expected one listed character, got %+v (%v)", infos, err)
+	}
+
+	if err := client.DeleteCharacter("sophia"); err != nil {
+		t.Fatalf("Failed to delete character: %v", err)
+	}
+	if _, err := client.Character("sophia"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after deleting, got %v", err)
+	}
+}
+
+func TestChat(t *testing.T) {
+	provider := &recordingProvider{reply: "Virtue is the only good."}
+	client := newTestClient(t, WithProvider(provider))
+	if err := client.CreateCharacter(&Character{ID: "sophia", Name: "Sophia the Philosopher"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	var tokens []string
+	reply, err := client.Chat(context.Background(), ChatRequest{
+		CharacterID: "sophia",
+		UserID:      "alice",
+		Message:     "What is virtue?",
+		OnToken:     func(token string) { tokens = append(tokens, token) },
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if reply.Content != "Virtue is the only good." || reply.SessionID == "" {
+		t.Errorf("Unexpected reply: %+v", reply)
+	}
+	if strings.Join(tokens, "") != reply.Content {
+		t.Errorf("Expected the reply streamed to OnToken, got %q", tokens)
+	}
+
+	// Unlike a streamed reply, which estimates its usage, a complete one
+	// carries the provider's own figures
+	second, err := client.Chat(context.Background(), ChatRequest{
+		CharacterID: "sophia",
+		UserID:      "alice",
+		SessionID:   reply.SessionID,
+		Message:     "And justice?",
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if second.SessionID != reply.SessionID {
+		t.Errorf("Expected session %s to continue, got %s", reply.SessionID, second.SessionID)
+	}
+	if _, err := client.Chat(context.Background(), ChatRequest{CharacterID: "sophia", UserID: "mallory", SessionID: reply.SessionID, Message: "Hi"}); !errors.Is(err, ErrSessionOwner) {
+		t.Errorf("Expected ErrSessionOwner for another user's session, got %v", err)
+	}
+	want := Usage{Provider: "recorder", Model: "recorder-1", PromptTokens: 100, CompletionTokens: 20, Cost: 0.002}
+	if second.Usage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, second.Usage)
+	}
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("Expected two provider requests, got %d", len(provider.requests))
+	}
+	firstReq, secondReq := provider.requests[0], provider.requests[1]
+	if !strings.Contains(firstReq.SystemPrompt, "Sophia the Philosopher") || firstReq.Message != "What is virtue?" || len(firstReq.History) != 0 {
+		t.Errorf("Unexpected first request: %+v", firstReq)
+	}
+	if len(secondReq.History) != 2 || secondReq.History[0].Role != "user" || secondReq.History[1].Role != "character" {
+		t.Errorf("Expected the first exchange as history, got %+v", secondReq.History)
+	}
+
+	session, err := client.Session("sophia", reply.SessionID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if len(session.Messages) != 4 || session.UserID != "alice" || session.Messages[1].Usage == nil {
+		t.Errorf("Unexpected session: %+v", session)
+	}
+	state, err := client.State("sophia", "alice")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	if len(state.Memories) != 2 || state.UserID != "alice" {
+		t.Errorf("Expected a memory of each reply, got %+v", state)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	if err := client.CreateCharacter(&Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+	if _, err := client.Chat(ctx, ChatRequest{CharacterID: "narrator", UserID: "alice", Message: "Hi"}); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("Expected ErrNoProvider, got %v", err)
+	}
+
+	notFound := map[string]error{}
+	_, notFound["character"] = client.Character("nobody")
+	_, notFound["state"] = client.State("nobody", "alice")
+	_, notFound["session"] = client.Session("narrator", "missing")
+	_, notFound["profile"] = client.UserProfile("alice", "narrator")
+	notFound["update"] = client.UpdateCharacter(&Character{ID: "nobody", Name: "Nobody"})
+	notFound["delete"] = client.DeleteCharacter("nobody")
+	for name, err := range notFound {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound from %s, got %v", name, err)
+		}
+	}
+
+	invalid := map[string]error{
+		"path in ID":   client.CreateCharacter(&Character{ID: "../escape", Name: "Escape"}),
+		"missing name": client.CreateCharacter(&Character{ID: "nameless"}),
+	}
+	_, invalid["path in user"] = client.State("narrator", "../alice")
+	_, invalid["empty message"] = client.Chat(ctx, ChatRequest{CharacterID: "narrator", UserID: "alice"})
+	for name, err := range invalid {
+		if err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected %s to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(t,
+		WithProvider(&recordingProvider{reply: "Once upon a time."}),
+		WithClock(func() time.Time { return now }),
+	)
+	if err := client.CreateCharacter(&Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	reply, err := client.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "alice", Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	session, err := client.Session("narrator", reply.SessionID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if !session.StartTime.Equal(now) || !session.LastActivity.Equal(now) || !session.Messages[1].Timestamp.Equal(now) {
+		t.Errorf("Expected timestamps from the clock, got %+v", session)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	dir := t.TempDir()
+	var logs bytes.Buffer
+	client, err := New(
+		WithDataDir(dir),
+		WithProvider(&recordingProvider{reply: "Once upon a time."}),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	if err := client.CreateCharacter(&Character{ID: "narrator", Name: "The Narrator"}); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	// A file where the character's session directory belongs makes saving fail
+	if err := os.MkdirAll(filepath.Join(dir, "sessions"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sessions", "narrator"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Chat(context.Background(), ChatRequest{CharacterID: "narrator", UserID: "alice", Message: "Hello"}); err != nil {
+		t.Fatalf("Expected the reply despite the failed save, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "Failed to save session") {
+		t.Errorf("Expected the save failure in the log, got %q", logs.String())
+	}
+}
@@ -0,0 +1,176 @@
+package roleplay_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/dotcommander/roleplay/pkg/roleplay"
+)
+
+// cannedProvider answers every message with the same reply
+type cannedProvider struct {
+	reply string
+}
+
+func (p cannedProvider) Name() string { return "canned" }
+
+func (p cannedProvider) Complete(ctx context.Context, req roleplay.CompletionRequest) (*roleplay.Completion, error) {
+	return &roleplay.Completion{Content: p.reply, Model: "canned-1"}, nil
+}
+
+func Example() {
+	dir, err := os.MkdirTemp("", "roleplay-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := roleplay.New(
+		roleplay.WithDataDir(dir),
+		roleplay.WithProvider(cannedProvider{reply: "Virtue is the only good, friend."}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	err = client.CreateCharacter(&roleplay.Character{
+		ID:          "sophia",
+		Name:        "Sophia the Philosopher",
+		Backstory:   "A wandering Stoic who questions everyone she meets.",
+		SpeechStyle: "Calm and Socratic",
+		Personality: roleplay.PersonalityTraits{Openness: 0.9, Agreeableness: 0.7},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reply, err := client.Chat(context.Background(), roleplay.ChatRequest{
+		CharacterID: "sophia",
+		UserID:      "alice",
+		Message:     "What is virtue?",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(reply.Content)
+
+	session, err := client.Session("sophia", reply.SessionID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, msg := range session.Messages {
+		fmt.Printf("%s: %s\n", msg.Role, msg.Content)
+	}
+	// Output:
+	// Virtue is the only good, friend.
+	// user: What is virtue?
+	// character: Virtue is the only good, friend.
+}
+
+func ExampleClient_Chat() {
+	dir, err := os.MkdirTemp("", "roleplay-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := roleplay.New(roleplay.WithDataDir(dir), roleplay.WithProvider(cannedProvider{reply: "Aye, the sea remembers."}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.CreateCharacter(&roleplay.Character{ID: "captain", Name: "Captain Reed"}); err != nil {
+		log.Fatal(err)
+	}
+
+	// Continue one conversation by passing its session ID back, and watch
+	// the reply arrive as it is generated
+	var sessionID string
+	for _, message := range []string{"Tell me about the storm.", "Were you afraid?"} {
+		reply, err := client.Chat(context.Background(), roleplay.ChatRequest{
+			CharacterID: "captain",
+			UserID:      "bob",
+			SessionID:   sessionID,
+			Message:     message,
+			OnToken:     func(token string) { fmt.Print(token) },
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println()
+		sessionID = reply.SessionID
+	}
+
+	sessions, err := client.Sessions("captain")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(len(sessions), "session,", sessions[0].MessageCount, "messages")
+	// Output:
+	// Aye, the sea remembers.
+	// Aye, the sea remembers.
+	// 1 session, 4 messages
+}
+
+func ExampleClient_State() {
+	dir, err := os.MkdirTemp("", "roleplay-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	client, err := roleplay.New(
+		roleplay.WithDataDir(dir),
+		roleplay.WithProvider(cannedProvider{reply: "What a wonderful, happy day!"}),
+		roleplay.WithClock(func() time.Time { return start }),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.CreateCharacter(&roleplay.Character{ID: "sunny", Name: "Sunny"}); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := client.Chat(context.Background(), roleplay.ChatRequest{CharacterID: "sunny", UserID: "carol", Message: "Good morning!"}); err != nil {
+		log.Fatal(err)
+	}
+
+	// Each user sees their own evolution of the character
+	state, err := client.State("sunny", "carol")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("memories:", len(state.Memories), "at", state.Memories[0].Timestamp.Format(time.RFC3339))
+	fmt.Println("joyful:", state.CurrentMood.Joy > 0)
+
+	stranger, err := client.State("sunny", "dave")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("memories of dave:", len(stranger.Memories))
+	// Output:
+	// memories: 1 at 2025-06-01T12:00:00Z
+	// joyful: true
+	// memories of dave: 0
+}
+
+func ExampleWithProviderConfig() {
+	client, err := roleplay.New(
+		roleplay.WithDataDir("/var/lib/myapp/roleplay"),
+		roleplay.WithProviderConfig(roleplay.ProviderConfig{
+			Profile: "openai",
+			APIKey:  os.Getenv("OPENAI_API_KEY"),
+			Model:   "gpt-4o-mini",
+		}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+}
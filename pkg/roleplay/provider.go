@@ -0,0 +1,103 @@
+package roleplay
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dotcommander/roleplay/internal/providers"
+)
+
+// Provider generates replies. Implement it to use a model the built-in
+// providers don't cover, or to give tests canned replies.
+type Provider interface {
+	// Name identifies the provider in usage records and pricing
+	Name() string
+	// Complete answers the conversation in req
+	Complete(ctx context.Context, req CompletionRequest) (*Completion, error)
+}
+
+// CompletionRequest is a conversation for a Provider to continue
+type CompletionRequest struct {
+	SystemPrompt string    // The character, its state toward the user and anything it recalls
+	History      []Message // Earlier turns, oldest first
+	Message      string    // The user's new message
+	Generation   GenerationParams
+}
+
+// Completion is a Provider's reply
+type Completion struct {
+	Content          string
+	Model            string // Reported in usage and used to look up pricing
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64 // USD billed for the request, if known
+}
+
+// ProviderConfig selects a built-in provider the way the provider, model,
+// api_key and base_url settings in config.yaml do
+type ProviderConfig struct {
+	Profile string // e.g. "openai", "anthropic", "gemini", "groq" or "ollama"
+	APIKey  string // May be empty for local endpoints such as Ollama
+	Model   string // Empty uses the profile's default model
+	BaseURL string // Empty uses the profile's default endpoint
+}
+
+// providerAdapter lets the bot use a Provider. Streamed replies arrive as a
+// single chunk.
+type providerAdapter struct {
+	provider Provider
+}
+
+func (a providerAdapter) Name() string {
+	return a.provider.Name()
+}
+
+func (a providerAdapter) SendRequest(ctx context.Context, req *providers.PromptRequest) (*providers.AIResponse, error) {
+	creq := CompletionRequest{
+		SystemPrompt: req.SystemPrompt,
+		Message:      req.Message,
+	}
+	if creq.SystemPrompt == "" {
+		parts := make([]string, 0, len(req.CacheBreakpoints))
+		for _, bp := range req.CacheBreakpoints {
+			parts = append(parts, bp.Content)
+		}
+		creq.SystemPrompt = strings.TrimSpace(strings.Join(parts, "\n\n"))
+	}
+	for _, msg := range req.Context.RecentMessages {
+		role := msg.Role
+		if role == "assistant" {
+			role = "character"
+		}
+		creq.History = append(creq.History, Message{Role: role, Content: msg.Content, Timestamp: msg.Timestamp})
+	}
+	if err := convert(req.Generation, &creq.Generation); err != nil {
+		return nil, err
+	}
+
+	completion, err := a.provider.Complete(ctx, creq)
+	if err != nil {
+		return nil, err
+	}
+	return &providers.AIResponse{
+		Content: completion.Content,
+		TokensUsed: providers.TokenUsage{
+			Prompt:     completion.PromptTokens,
+			Completion: completion.CompletionTokens,
+			Total:      completion.PromptTokens + completion.CompletionTokens,
+		},
+		Provider: a.provider.Name(),
+		Model:    completion.Model,
+		Cost:     completion.Cost,
+	}, nil
+}
+
+func (a providerAdapter) SendStreamRequest(ctx context.Context, req *providers.PromptRequest, out chan<- providers.PartialAIResponse) error {
+	defer close(out)
+	resp, err := a.SendRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	out <- providers.PartialAIResponse{Content: resp.Content, Done: true}
+	return nil
+}
@@ -0,0 +1,92 @@
+package roleplay
+
+import (
+	"fmt"
+	"os"
+)
+
+// State returns how a character has evolved toward a user. A user the
+// character hasn't met gets the character's starting state.
+func (c *Client) State(characterID, userID string) (*CharacterState, error) {
+	if err := checkID("character", characterID); err != nil {
+		return nil, err
+	}
+	if err := checkID("user", userID); err != nil {
+		return nil, err
+	}
+	if _, err := c.mgr.GetOrLoadCharacter(characterID); err != nil {
+		return nil, fmt.Errorf("character %s: %w", characterID, ErrNotFound)
+	}
+
+	internal, err := c.mgr.GetBot().GetCharacterState(characterID, userID)
+	if err != nil {
+		return nil, err
+	}
+	var state CharacterState
+	internal.RLock()
+	defer internal.RUnlock()
+	if err := convert(internal, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Session returns a stored conversation with all its messages
+func (c *Client) Session(characterID, sessionID string) (*Session, error) {
+	if err := checkID("character", characterID); err != nil {
+		return nil, err
+	}
+	if err := checkID("session", sessionID); err != nil {
+		return nil, err
+	}
+	internal, err := c.mgr.GetSessionRepository().LoadSession(characterID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session %s: %w", sessionID, ErrNotFound)
+	}
+
+	var session Session
+	if err := convert(internal, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Sessions lists a character's stored conversations, most recent first
+func (c *Client) Sessions(characterID string) ([]SessionInfo, error) {
+	if err := checkID("character", characterID); err != nil {
+		return nil, err
+	}
+	infos, err := c.mgr.GetSessionRepository().ListSessions(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var sessions []SessionInfo
+	if err := convert(infos, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// UserProfile returns what a character has learned about a user. Profiles
+// are built by the CLI and server when user profiling is enabled.
+func (c *Client) UserProfile(userID, characterID string) (*UserProfile, error) {
+	if err := checkID("user", userID); err != nil {
+		return nil, err
+	}
+	if err := checkID("character", characterID); err != nil {
+		return nil, err
+	}
+	internal, err := c.mgr.GetUserProfileRepository().LoadUserProfile(userID, characterID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("profile of %s for %s: %w", userID, characterID, ErrNotFound)
+		}
+		return nil, err
+	}
+
+	var profile UserProfile
+	if err := convert(internal, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -0,0 +1,202 @@
+package roleplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PersonalityTraits scores a character on the OCEAN model, each in [0, 1]
+type PersonalityTraits struct {
+	Openness          float64 `json:"openness"`
+	Conscientiousness float64 `json:"conscientiousness"`
+	Extraversion      float64 `json:"extraversion"`
+	Agreeableness     float64 `json:"agreeableness"`
+	Neuroticism       float64 `json:"neuroticism"`
+}
+
+// EmotionalState is the strength of each basic emotion, each in [0, 1]
+type EmotionalState struct {
+	Joy      float64 `json:"joy"`
+	Surprise float64 `json:"surprise"`
+	Anger    float64 `json:"anger"`
+	Fear     float64 `json:"fear"`
+	Sadness  float64 `json:"sadness"`
+	Disgust  float64 `json:"disgust"`
+}
+
+// Memory is something a character remembers
+type Memory struct {
+	ID              string    `json:"id,omitempty"`
+	Type            string    `json:"type"` // "short_term", "medium_term" or "long_term"
+	Content         string    `json:"content"`
+	Timestamp       time.Time `json:"timestamp"`
+	EmotionalWeight float64   `json:"emotional_weight"`
+	RecallCount     int       `json:"recall_count,omitempty"` // Times retrieved into a prompt
+}
+
+// GenerationParams holds optional sampler settings; nil fields use the
+// provider's defaults
+type GenerationParams struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+}
+
+// Character is a complete character definition. Its JSON form is the
+// character file format the CLI reads and writes.
+type Character struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Backstory    string            `json:"backstory"`
+	Personality  PersonalityTraits `json:"personality"`
+	CurrentMood  EmotionalState    `json:"current_mood"`
+	Quirks       []string          `json:"quirks"`
+	SpeechStyle  string            `json:"speech_style"`
+	Memories     []Memory          `json:"memories"`
+	LastModified time.Time         `json:"last_modified"`
+
+	Age               string            `json:"age,omitempty"`
+	Gender            string            `json:"gender,omitempty"`
+	Occupation        string            `json:"occupation,omitempty"`
+	Education         string            `json:"education,omitempty"`
+	Nationality       string            `json:"nationality,omitempty"`
+	Ethnicity         string            `json:"ethnicity,omitempty"`
+	PhysicalTraits    []string          `json:"physical_traits,omitempty"`
+	Skills            []string          `json:"skills,omitempty"`
+	Interests         []string          `json:"interests,omitempty"`
+	Fears             []string          `json:"fears,omitempty"`
+	Goals             []string          `json:"goals,omitempty"`
+	Relationships     map[string]string `json:"relationships,omitempty"`
+	CoreBeliefs       []string          `json:"core_beliefs,omitempty"`
+	MoralCode         []string          `json:"moral_code,omitempty"`
+	Flaws             []string          `json:"flaws,omitempty"`
+	Strengths         []string          `json:"strengths,omitempty"`
+	CatchPhrases      []string          `json:"catch_phrases,omitempty"`
+	DialogueExamples  []string          `json:"dialogue_examples,omitempty"`
+	BehaviorPatterns  []string          `json:"behavior_patterns,omitempty"`
+	EmotionalTriggers map[string]string `json:"emotional_triggers,omitempty"`
+	DecisionMaking    string            `json:"decision_making,omitempty"`
+	ConflictStyle     string            `json:"conflict_style,omitempty"`
+	WorldView         string            `json:"world_view,omitempty"`
+	LifePhilosophy    string            `json:"life_philosophy,omitempty"`
+	DailyRoutines     []string          `json:"daily_routines,omitempty"`
+	Hobbies           []string          `json:"hobbies,omitempty"`
+	PetPeeves         []string          `json:"pet_peeves,omitempty"`
+	Secrets           []string          `json:"secrets,omitempty"`
+	Regrets           []string          `json:"regrets,omitempty"`
+	Achievements      []string          `json:"achievements,omitempty"`
+
+	// Sampler settings for this character's replies (optional)
+	Generation *GenerationParams `json:"generation,omitempty"`
+}
+
+// CharacterInfo summarises a stored character
+type CharacterInfo struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	SpeechStyle string   `json:"speech_style,omitempty"`
+}
+
+// Relationship is how a character feels about one user, each in [0, 1]
+type Relationship struct {
+	Trust       float64 `json:"trust"`
+	Affection   float64 `json:"affection"`
+	Familiarity float64 `json:"familiarity"`
+}
+
+// CharacterState is how a character has evolved toward one user: its mood,
+// what it remembers of them and how its personality has drifted. Each user
+// has their own state on top of the shared character definition.
+type CharacterState struct {
+	CharacterID      string            `json:"character_id"`
+	UserID           string            `json:"user_id"`
+	CurrentMood      EmotionalState    `json:"current_mood"`
+	Memories         []Memory          `json:"memories"`
+	Relationship     Relationship      `json:"relationship"`
+	PersonalityDrift PersonalityTraits `json:"personality_drift"` // Offset applied to the base personality
+	InteractionCount int               `json:"interaction_count"`
+	CreatedAt        time.Time         `json:"created_at"`
+	LastModified     time.Time         `json:"last_modified"`
+}
+
+// Usage is the model and cost behind a reply
+type Usage struct {
+	Provider         string  `json:"provider,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`                 // USD; 0 when the model is unpriced
+	CostSaved        float64 `json:"cost_saved,omitempty"` // USD saved by caching
+}
+
+// Message is one turn of a conversation
+type Message struct {
+	Role      string    `json:"role"` // "user" or "character"
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	Usage     *Usage    `json:"usage,omitempty"` // Set on character replies
+}
+
+// Session is a stored conversation between a user and a character
+type Session struct {
+	ID           string    `json:"id"`
+	CharacterID  string    `json:"character_id"`
+	UserID       string    `json:"user_id"`
+	StartTime    time.Time `json:"start_time"`
+	LastActivity time.Time `json:"last_activity"`
+	Messages     []Message `json:"messages"`
+
+	// Summary condenses the earliest turns, which no longer fit the model's
+	// context window
+	Summary string `json:"summary,omitempty"`
+}
+
+// SessionInfo summarises a stored session
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	CharacterID  string    `json:"character_id"`
+	StartTime    time.Time `json:"start_time"`
+	LastActivity time.Time `json:"last_activity"`
+	MessageCount int       `json:"message_count"`
+}
+
+// UserFact is something a character has learned about a user
+type UserFact struct {
+	Key         string    `json:"key"`
+	Value       string    `json:"value"`
+	SourceTurn  int       `json:"source_turn"`
+	Confidence  float64   `json:"confidence"` // In [0, 1]
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// UserProfile is what a character has learned about a user over time
+type UserProfile struct {
+	UserID           string     `json:"user_id"`
+	CharacterID      string     `json:"character_id"`
+	Facts            []UserFact `json:"facts"`
+	OverallSummary   string     `json:"overall_summary"`
+	InteractionStyle string     `json:"interaction_style"`
+	LastAnalyzed     time.Time  `json:"last_analyzed"`
+	Version          int        `json:"version"`
+}
+
+// convert copies src into dst through their shared JSON form. The public
+// types mirror the internal ones field for field, so the file formats stay
+// the contract between them.
+func convert(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return fmt.Errorf("failed to encode %T: %w", src, err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to decode %T: %w", dst, err)
+	}
+	return nil
+}